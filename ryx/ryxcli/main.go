@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+const usage = `usage:
+  ryxcli merge <base> <ours> <theirs>
+      Three-way merge of Alteryx documents.  The result is written to <ours>.  Exits with 1 if there are
+      conflicts, in which case ours was kept for every conflicting change.  Intended as a git merge driver.
+  ryxcli svg <document> [<output>]
+      Draws the document as an SVG image.  The image is written to <output>, or to stdout if no output is given.
+      Only macros are drawn with icons; other tools are drawn as labelled boxes.`
+
+func main() {
+	if len(os.Args) < 2 {
+		printErr(usage)
+		os.Exit(2)
+	}
+	var code int
+	switch os.Args[1] {
+	case `merge`:
+		code = merge(os.Args[2:])
+	case `svg`:
+		code = svg(os.Args[2:])
+	default:
+		printErr(usage)
+		code = 2
+	}
+	os.Exit(code)
+}
+
+func printErr(msg string) {
+	_, _ = fmt.Fprintln(os.Stderr, msg)
+}
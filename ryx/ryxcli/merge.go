@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"github.com/tlarsen7572/Golang-Public/ryx/ryxdoc"
+)
+
+func merge(args []string) int {
+	if len(args) != 3 {
+		printErr(`merge requires the base, ours and theirs files`)
+		return 2
+	}
+	docs := []*ryxdoc.RyxDoc{}
+	for _, path := range args {
+		doc, err := ryxdoc.ReadFileLossless(path)
+		if err != nil {
+			printErr(fmt.Sprintf(`error reading '%v': %v`, path, err.Error()))
+			return 2
+		}
+		docs = append(docs, doc)
+	}
+
+	merged, conflicts := ryxdoc.Merge(docs[0], docs[1], docs[2])
+	err := merged.Save(args[1])
+	if err != nil {
+		printErr(fmt.Sprintf(`error saving '%v': %v`, args[1], err.Error()))
+		return 2
+	}
+	if len(conflicts) == 0 {
+		return 0
+	}
+	for _, conflict := range conflicts {
+		printErr(describeConflict(conflict))
+	}
+	return 1
+}
+
+func describeConflict(conflict *ryxdoc.MergeConflict) string {
+	if conflict.Kind == ryxdoc.ToolConflict {
+		return fmt.Sprintf(`CONFLICT (tool %v, %v): %v`, conflict.ToolId, conflict.Key, conflict.Description)
+	}
+	return fmt.Sprintf(`CONFLICT (%v %v): %v`, conflict.Kind, conflict.Key, conflict.Description)
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"github.com/tlarsen7572/Golang-Public/ryx/ryxproject"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+func svg(args []string) int {
+	if len(args) < 1 || len(args) > 2 {
+		printErr(`svg requires the document and, optionally, the output file`)
+		return 2
+	}
+	docPath, err := filepath.Abs(args[0])
+	if err != nil {
+		printErr(fmt.Sprintf(`error reading '%v': %v`, args[0], err.Error()))
+		return 2
+	}
+	proj, err := ryxproject.Open(filepath.Dir(docPath))
+	if err != nil {
+		printErr(fmt.Sprintf(`error reading '%v': %v`, args[0], err.Error()))
+		return 2
+	}
+	image, err := proj.RenderSvg(docPath, nil)
+	if err != nil {
+		printErr(fmt.Sprintf(`error reading '%v': %v`, args[0], err.Error()))
+		return 2
+	}
+	if len(args) == 1 {
+		_, _ = os.Stdout.Write(image)
+		return 0
+	}
+	err = ioutil.WriteFile(args[1], image, 0644)
+	if err != nil {
+		printErr(fmt.Sprintf(`error saving '%v': %v`, args[1], err.Error()))
+		return 2
+	}
+	return 0
+}
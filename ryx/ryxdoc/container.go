@@ -0,0 +1,149 @@
+package ryxdoc
+
+import (
+	"errors"
+	"fmt"
+	"github.com/tlarsen7572/Golang-Public/ryx/ryxnode"
+)
+
+const defaultContainerColor = `#314c4a`
+
+// WrapInContainer moves the tools into a new Tool Container sized to fit around them.  The tools must share the same
+// parent, either the canvas or a container.  Tools inside a selected container move along with it.  If no color is
+// given, Designer's default container color is used.
+func (ryxDoc *RyxDoc) WrapInContainer(toolIds []int, caption string, color string) (*ryxnode.RyxNode, error) {
+	if len(toolIds) == 0 {
+		return nil, errors.New(`no tools were selected to wrap`)
+	}
+	if color == `` {
+		color = defaultContainerColor
+	}
+	nodes := ryxDoc.ReadMappedNodes()
+	for _, id := range toolIds {
+		if _, ok := nodes[id]; !ok {
+			return nil, errors.New(fmt.Sprintf(`tool %v does not exist`, id))
+		}
+	}
+	toolIds = ryxDoc.removeNestedIds(toolIds)
+	parent := ryxDoc.findParent(toolIds[0])
+	for _, id := range toolIds[1:] {
+		if ryxDoc.findParent(id) != parent {
+			return nil, errors.New(`the selected tools are not all in the same container`)
+		}
+	}
+
+	left, top, right, bottom := ryxDoc.getExtents(toolIds...)
+	container, err := ryxnode.NewContainer(
+		ryxDoc.nextId,
+		caption,
+		color,
+		left-containerPadding,
+		top-containerHeader,
+		right-left+containerPadding*2,
+		bottom-top+containerHeader+containerPadding,
+	)
+	if err != nil {
+		return nil, err
+	}
+	ryxDoc.grabNextIdAndIncrement()
+
+	siblings := []*ryxnode.RyxNode{}
+	for _, node := range ryxDoc.readSiblings(parent) {
+		if !node.MatchesIds(toolIds...) {
+			siblings = append(siblings, node)
+			continue
+		}
+		if len(container.ChildNodes) == 0 {
+			siblings = append(siblings, container)
+		}
+		container.ChildNodes = append(container.ChildNodes, node)
+	}
+	ryxDoc.setSiblings(parent, siblings)
+	return container, nil
+}
+
+// Unwrap removes a Tool Container and moves the tools inside it to the container's parent.  The tools keep their
+// positions.
+func (ryxDoc *RyxDoc) Unwrap(containerId int) error {
+	container, ok := ryxDoc.ReadMappedNodes()[containerId]
+	if !ok {
+		return errors.New(fmt.Sprintf(`tool %v does not exist`, containerId))
+	}
+	if container.ReadCategory() != ryxnode.Container {
+		return errors.New(fmt.Sprintf(`tool %v is not a container`, containerId))
+	}
+	parent := ryxDoc.findParent(containerId)
+	siblings := []*ryxnode.RyxNode{}
+	for _, node := range ryxDoc.readSiblings(parent) {
+		if node == container {
+			siblings = append(siblings, container.ChildNodes...)
+			continue
+		}
+		siblings = append(siblings, node)
+	}
+	ryxDoc.setSiblings(parent, siblings)
+	return nil
+}
+
+// removeNestedIds drops tools that are inside another selected tool, since they move with their container.
+func (ryxDoc *RyxDoc) removeNestedIds(toolIds []int) []int {
+	kept := []int{}
+	for _, id := range toolIds {
+		nested := false
+		for parent := ryxDoc.findParent(id); parent != nil; {
+			parentId, _ := parent.ReadId()
+			if intsContain(toolIds, parentId) {
+				nested = true
+				break
+			}
+			parent = ryxDoc.findParent(parentId)
+		}
+		if !nested && !intsContain(kept, id) {
+			kept = append(kept, id)
+		}
+	}
+	return kept
+}
+
+// getExtents works like getBoundingBox but includes the width and height of the tools.
+func (ryxDoc *RyxDoc) getExtents(toolIds ...int) (left float64, top float64, right float64, bottom float64) {
+	left = maxDouble()
+	top = maxDouble()
+	for _, node := range ryxDoc.ReadMappedNodes() {
+		if !node.MatchesIds(toolIds...) {
+			continue
+		}
+		position, err := node.ReadPosition()
+		if err != nil {
+			continue
+		}
+		if position.X < left {
+			left = position.X
+		}
+		if position.Y < top {
+			top = position.Y
+		}
+		if position.X+position.Width > right {
+			right = position.X + position.Width
+		}
+		if position.Y+position.Height > bottom {
+			bottom = position.Y + position.Height
+		}
+	}
+	return left, top, right, bottom
+}
+
+func (ryxDoc *RyxDoc) readSiblings(parent *ryxnode.RyxNode) []*ryxnode.RyxNode {
+	if parent == nil {
+		return ryxDoc.Nodes // It is ok to use RyxDoc.Nodes here
+	}
+	return parent.ChildNodes
+}
+
+func (ryxDoc *RyxDoc) setSiblings(parent *ryxnode.RyxNode, siblings []*ryxnode.RyxNode) {
+	if parent == nil {
+		ryxDoc.Nodes = siblings // It is ok to use RyxDoc.Nodes here
+		return
+	}
+	parent.ChildNodes = siblings
+}
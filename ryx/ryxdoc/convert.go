@@ -0,0 +1,198 @@
+package ryxdoc
+
+import (
+	"errors"
+	"fmt"
+	"github.com/tlarsen7572/Golang-Public/ryx/ryxnode"
+	"github.com/tlarsen7572/Golang-Public/txml"
+	"strconv"
+)
+
+type DocumentType string
+
+const (
+	Workflow    DocumentType = `Workflow`
+	Macro       DocumentType = `Macro`
+	AnalyticApp DocumentType = `Wizard`
+)
+
+var documentExtensions = map[DocumentType]string{
+	Workflow:    `.yxmd`,
+	Macro:       `.yxmc`,
+	AnalyticApp: `.yxwz`,
+}
+
+// ToolAnchors returns the names of a tool's input and output anchors.  The last value is false if the tool's anchors
+// are not known.
+type ToolAnchors func(node *ryxnode.RyxNode) (inputs []string, outputs []string, ok bool)
+
+func (docType DocumentType) Extension() (string, error) {
+	extension, ok := documentExtensions[docType]
+	if !ok {
+		return ``, errors.New(fmt.Sprintf(`'%v' is not a valid document type; use %v, %v or %v`, docType, Workflow, Macro, AnalyticApp))
+	}
+	return extension, nil
+}
+
+// ConvertDocumentType sets the document's ModuleType.  Converting to a macro adds a Macro Input to every input
+// anchor and a Macro Output to every output anchor that is not connected, using readAnchors to find the anchors of
+// each tool.  Tools with unknown anchors are skipped.  Converting to a workflow or analytic app removes the Macro
+// Input and Macro Output tools along with their connections and questions.  Workflows do not have a ModuleType, so
+// it is removed.
+func (ryxDoc *RyxDoc) ConvertDocumentType(docType DocumentType, readAnchors ToolAnchors) error {
+	if _, err := docType.Extension(); err != nil {
+		return err
+	}
+	current := ryxDoc.ReadDocumentType()
+	runtime := ryxDoc.ensureRuntimeProperties()
+	moduleType := runtime.First(`ModuleType`)
+	if docType == Workflow {
+		runtime.RemoveFirst(`ModuleType`)
+	} else if moduleType.Name == `` {
+		runtime.Nodes = append(runtime.Nodes, &txml.Node{Name: `ModuleType`, InnerText: string(docType)})
+	} else {
+		moduleType.InnerText = string(docType)
+	}
+
+	if docType == Macro && current != Macro {
+		ryxDoc.addMacroInterface(readAnchors)
+	}
+	if docType != Macro && current == Macro {
+		ryxDoc.removeMacroInterface()
+	}
+	return nil
+}
+
+// ReadDocumentType returns the document type set in RuntimeProperties/ModuleType.  Documents without a ModuleType
+// are workflows.
+func (ryxDoc *RyxDoc) ReadDocumentType() DocumentType {
+	if ryxDoc.Properties == nil {
+		return Workflow
+	}
+	moduleType := DocumentType(ryxDoc.Properties.First(`RuntimeProperties`).First(`ModuleType`).InnerText)
+	if _, ok := documentExtensions[moduleType]; !ok {
+		return Workflow
+	}
+	return moduleType
+}
+
+func (ryxDoc *RyxDoc) ensureRuntimeProperties() *txml.Node {
+	if ryxDoc.Properties == nil {
+		ryxDoc.Properties = &txml.Node{Name: `Properties`, Attributes: map[string]string{}}
+	}
+	runtime := ryxDoc.Properties.First(`RuntimeProperties`)
+	if runtime.Name != `` {
+		return runtime
+	}
+	template, _ := ReadBytes([]byte(docXml))
+	runtime = template.Properties.First(`RuntimeProperties`)
+	runtime.RemoveFirst(`ModuleType`)
+	ryxDoc.Properties.Nodes = append(ryxDoc.Properties.Nodes, runtime)
+	return runtime
+}
+
+func (ryxDoc *RyxDoc) addMacroInterface(readAnchors ToolAnchors) {
+	if readAnchors == nil {
+		return
+	}
+	nodes := ryxDoc.ReadMappedNodes()
+	outputConns, inputConns := readIoConns(ryxDoc)
+	var tab *txml.Node
+	readTab := func() *txml.Node {
+		if tab == nil {
+			tab = ryxDoc.findOrAddQuestionTab()
+		}
+		return tab
+	}
+	for _, id := range sortedIds(nodes) {
+		node := nodes[id]
+		if isInterfacePlugin(node.ReadPlugin()) {
+			continue
+		}
+		inputs, outputs, ok := readAnchors(node)
+		if !ok {
+			continue
+		}
+		position, _ := node.ReadPosition()
+		openInputs := 0
+		for _, anchor := range inputs {
+			if len(matchingToAnchor(inputConns[id], anchor)) > 0 {
+				continue
+			}
+			questionTab := readTab()
+			inputId := ryxDoc.grabNextIdAndIncrement()
+			name := `Input` + strconv.Itoa(inputId)
+			y := position.Y + verticalGap*float64(openInputs)
+			ryxDoc.Nodes = append(ryxDoc.Nodes, newMacroInput(inputId, name, position.X-horizontalGap, y)) // It is ok to use RyxDoc.Nodes here
+			addQuestionToTab(questionTab, `MacroInput`, fmt.Sprintf(`Macro Input (%v)`, inputId), inputId)
+			ryxDoc.AddConnection(&RyxConn{FromId: inputId, FromAnchor: `Output`, ToId: id, ToAnchor: anchor})
+			openInputs++
+		}
+		openOutputs := 0
+		for _, anchor := range outputs {
+			if len(matchingFromAnchor(outputConns[id], anchor)) > 0 {
+				continue
+			}
+			questionTab := readTab()
+			outputId := ryxDoc.grabNextIdAndIncrement()
+			name := `Output` + strconv.Itoa(outputId)
+			y := position.Y + verticalGap*float64(openOutputs)
+			ryxDoc.Nodes = append(ryxDoc.Nodes, newMacroOutput(outputId, name, position.X+position.Width+horizontalGap, y)) // It is ok to use RyxDoc.Nodes here
+			addQuestionToTab(questionTab, `MacroOutput`, fmt.Sprintf(`Macro Output (%v)`, outputId), outputId)
+			ryxDoc.AddConnection(&RyxConn{FromId: id, FromAnchor: anchor, ToId: outputId, ToAnchor: `Input`})
+			openOutputs++
+		}
+	}
+}
+
+// findOrAddQuestionTab returns the Tab question that interface questions are listed under, adding a Tab tool if the
+// document does not have one.
+func (ryxDoc *RyxDoc) findOrAddQuestionTab() *txml.Node {
+	questions := ryxDoc.Properties.First(`RuntimeProperties`).First(`Questions`)
+	for _, node := range ryxDoc.ReadMappedNodes() {
+		if node.ReadPlugin() != questionsTabPlugin {
+			continue
+		}
+		for _, question := range questions.Nodes {
+			if question.First(`ToolId`).Attributes[`value`] == node.ToolId {
+				if question.First(`Questions`).Name == `` {
+					question.Nodes = append(question.Nodes, &txml.Node{Name: `Questions`})
+				}
+				return question
+			}
+		}
+	}
+	if questions.Name == `` {
+		questions = &txml.Node{Name: `Questions`}
+		runtime := ryxDoc.Properties.First(`RuntimeProperties`)
+		runtime.Nodes = append([]*txml.Node{questions}, runtime.Nodes...)
+	}
+	tabId := ryxDoc.grabNextIdAndIncrement()
+	ryxDoc.Nodes = append(ryxDoc.Nodes, newQuestionTab(tabId)) // It is ok to use RyxDoc.Nodes here
+	return addTabQuestion(ryxDoc, tabId)
+}
+
+func (ryxDoc *RyxDoc) removeMacroInterface() {
+	ids := []int{}
+	for id, node := range ryxDoc.ReadMappedNodes() {
+		if plugin := node.ReadPlugin(); plugin == macroInputPlugin || plugin == macroOutputPlugin {
+			ids = append(ids, id)
+		}
+	}
+	ryxDoc.RemoveNodes(ids...)
+	ryxDoc.removeDanglingConnections()
+	removeQuestions(ryxDoc.Properties.First(`RuntimeProperties`).First(`Questions`), ids)
+}
+
+func removeQuestions(questions *txml.Node, toolIds []int) {
+	var keep []*txml.Node
+	for _, question := range questions.Nodes {
+		id, err := strconv.Atoi(question.First(`ToolId`).Attributes[`value`])
+		if err == nil && intsContain(toolIds, id) {
+			continue
+		}
+		removeQuestions(question.First(`Questions`), toolIds)
+		keep = append(keep, question)
+	}
+	questions.Nodes = keep
+}
@@ -0,0 +1,68 @@
+package ryxdoc
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"github.com/tlarsen7572/Golang-Public/ryx/ryxnode"
+)
+
+// CopyTools copies tools from one document into another.  Tools inside a selected container are copied with it, as
+// are the connections between the copied tools.  The copies receive new IDs from the destination document and are
+// placed so the top-left tool sits at x and y.  Macros stored relative to fromFolder are rewritten relative to
+// toFolder.  The map of old IDs to new IDs is returned.
+func CopyTools(fromDoc *RyxDoc, toolIds []int, toDoc *RyxDoc, x float64, y float64, fromFolder string, toFolder string, macroPaths ...string) (map[int]int, error) {
+	if len(toolIds) == 0 {
+		return nil, errors.New(`no tools were selected to copy`)
+	}
+	nodes := fromDoc.ReadMappedNodes()
+	for _, id := range toolIds {
+		if _, ok := nodes[id]; !ok {
+			return nil, errors.New(fmt.Sprintf(`tool %v does not exist`, id))
+		}
+	}
+	toolIds = fromDoc.expandContainers(toolIds...)
+
+	clipboard := &RyxDoc{}
+	for _, node := range selectNodes(fromDoc.Nodes, toolIds...) { // It is ok to use RyxDoc.Nodes here
+		clone, err := cloneNode(node)
+		if err != nil {
+			return nil, err
+		}
+		clipboard.Nodes = append(clipboard.Nodes, clone) // It is ok to use RyxDoc.Nodes here
+	}
+	for _, node := range clipboard.ReadMappedNodes() {
+		macro := node.ReadMacro(append([]string{fromFolder}, macroPaths...)...)
+		if macro.FoundPath != `` && macro.RelativeTo == fromFolder {
+			_ = node.MakeMacroRelative(toFolder, fromFolder)
+		}
+	}
+
+	left, top, _, _ := clipboard.getBoundingBox(toolIds...)
+	offsetNodes(clipboard, x-left, y-top)
+	newIds := toDoc.remapNodeIds(clipboard)
+	for _, conn := range fromDoc.Connections {
+		fromId, fromCopied := newIds[conn.FromId]
+		toId, toCopied := newIds[conn.ToId]
+		if fromCopied && toCopied {
+			toDoc.AddConnection(&RyxConn{
+				Name:       conn.Name,
+				FromId:     fromId,
+				FromAnchor: conn.FromAnchor,
+				ToId:       toId,
+				ToAnchor:   conn.ToAnchor,
+				Wireless:   conn.Wireless,
+			})
+		}
+	}
+	toDoc.Nodes = append(toDoc.Nodes, clipboard.Nodes...) // It is ok to use RyxDoc.Nodes here
+	return newIds, nil
+}
+
+func cloneNode(node *ryxnode.RyxNode) (*ryxnode.RyxNode, error) {
+	content, err := xml.Marshal(node)
+	if err != nil {
+		return nil, err
+	}
+	return ryxnode.GenerateNodeFromXml(string(content))
+}
@@ -0,0 +1,351 @@
+package ryxdoc
+
+import (
+	"encoding/xml"
+	"fmt"
+	"github.com/tlarsen7572/Golang-Public/ryx/ryxnode"
+	"github.com/tlarsen7572/Golang-Public/txml"
+	"sort"
+	"strings"
+)
+
+const diffContext = 2
+
+type DocDiff struct {
+	AddedTools         []*ToolSummary
+	RemovedTools       []*ToolSummary
+	MovedTools         []*ToolMove
+	ChangedTools       []*ToolChange
+	AddedConnections   []*RyxConn
+	RemovedConnections []*RyxConn
+	PropertyChanges    []*DiffLine
+}
+
+type ToolSummary struct {
+	ToolId int
+	Plugin string
+	Macro  string
+}
+
+type ToolMove struct {
+	ToolId     int
+	FromX      float64
+	FromY      float64
+	ToX        float64
+	ToY        float64
+	FromParent int
+	ToParent   int
+}
+
+type ToolChange struct {
+	ToolId        int
+	Plugin        string
+	Configuration []*DiffLine
+}
+
+// DiffLine is a single line of a line-by-line diff.  Kind is + for added lines, - for removed lines, a space for
+// unchanged lines kept as context, and ... where unchanged lines were left out.
+type DiffLine struct {
+	Kind string
+	Text string
+}
+
+// Diff compares two documents tool by tool using tool IDs.  Configurations and document properties are compared
+// after normalizing their XML, so formatting differences are not reported.
+func Diff(from *RyxDoc, to *RyxDoc) *DocDiff {
+	diff := &DocDiff{
+		AddedTools:         []*ToolSummary{},
+		RemovedTools:       []*ToolSummary{},
+		MovedTools:         []*ToolMove{},
+		ChangedTools:       []*ToolChange{},
+		AddedConnections:   []*RyxConn{},
+		RemovedConnections: []*RyxConn{},
+	}
+	fromNodes := from.ReadMappedNodes()
+	toNodes := to.ReadMappedNodes()
+	fromParents := readParents(from)
+	toParents := readParents(to)
+
+	for _, id := range sortedIds(fromNodes) {
+		fromNode := fromNodes[id]
+		toNode, ok := toNodes[id]
+		if !ok {
+			diff.RemovedTools = append(diff.RemovedTools, summarizeTool(id, fromNode))
+			continue
+		}
+		fromPosition, _ := fromNode.ReadPosition()
+		toPosition, _ := toNode.ReadPosition()
+		if fromPosition.X != toPosition.X || fromPosition.Y != toPosition.Y || fromParents[id] != toParents[id] {
+			diff.MovedTools = append(diff.MovedTools, &ToolMove{
+				ToolId:     id,
+				FromX:      fromPosition.X,
+				FromY:      fromPosition.Y,
+				ToX:        toPosition.X,
+				ToY:        toPosition.Y,
+				FromParent: fromParents[id],
+				ToParent:   toParents[id],
+			})
+		}
+		lines := diffLines(configurationLines(fromNode), configurationLines(toNode))
+		if fromNode.ReadPlugin() != toNode.ReadPlugin() {
+			lines = append([]*DiffLine{
+				{Kind: `-`, Text: `Plugin: ` + fromNode.ReadPlugin()},
+				{Kind: `+`, Text: `Plugin: ` + toNode.ReadPlugin()},
+			}, lines...)
+		}
+		if len(lines) > 0 {
+			diff.ChangedTools = append(diff.ChangedTools, &ToolChange{ToolId: id, Plugin: toNode.ReadPlugin(), Configuration: lines})
+		}
+	}
+	for _, id := range sortedIds(toNodes) {
+		if _, ok := fromNodes[id]; !ok {
+			diff.AddedTools = append(diff.AddedTools, summarizeTool(id, toNodes[id]))
+		}
+	}
+
+	for _, conn := range to.Connections {
+		if !connectionsContain(from.Connections, conn) {
+			diff.AddedConnections = append(diff.AddedConnections, conn)
+		}
+	}
+	for _, conn := range from.Connections {
+		if !connectionsContain(to.Connections, conn) {
+			diff.RemovedConnections = append(diff.RemovedConnections, conn)
+		}
+	}
+
+	diff.PropertyChanges = diffLines(propertyLines(from.Properties), propertyLines(to.Properties))
+	return diff
+}
+
+func (diff *DocDiff) IsEmpty() bool {
+	return len(diff.AddedTools) == 0 &&
+		len(diff.RemovedTools) == 0 &&
+		len(diff.MovedTools) == 0 &&
+		len(diff.ChangedTools) == 0 &&
+		len(diff.AddedConnections) == 0 &&
+		len(diff.RemovedConnections) == 0 &&
+		len(diff.PropertyChanges) == 0
+}
+
+func (diff *DocDiff) String() string {
+	if diff.IsEmpty() {
+		return "No differences\n"
+	}
+	builder := &strings.Builder{}
+	for _, tool := range diff.AddedTools {
+		builder.WriteString(fmt.Sprintf("Added tool %v\n", describeTool(tool)))
+	}
+	for _, tool := range diff.RemovedTools {
+		builder.WriteString(fmt.Sprintf("Removed tool %v\n", describeTool(tool)))
+	}
+	for _, move := range diff.MovedTools {
+		builder.WriteString(fmt.Sprintf("Moved tool %v from %v,%v to %v,%v", move.ToolId, move.FromX, move.FromY, move.ToX, move.ToY))
+		if move.FromParent != move.ToParent {
+			builder.WriteString(fmt.Sprintf(" and from %v to %v", describeParent(move.FromParent), describeParent(move.ToParent)))
+		}
+		builder.WriteString("\n")
+	}
+	for _, change := range diff.ChangedTools {
+		builder.WriteString(fmt.Sprintf("Changed tool %v (%v)\n", change.ToolId, change.Plugin))
+		writeDiffLines(builder, change.Configuration)
+	}
+	for _, conn := range diff.AddedConnections {
+		builder.WriteString(fmt.Sprintf("Added connection %v\n", describeConnection(conn)))
+	}
+	for _, conn := range diff.RemovedConnections {
+		builder.WriteString(fmt.Sprintf("Removed connection %v\n", describeConnection(conn)))
+	}
+	if len(diff.PropertyChanges) > 0 {
+		builder.WriteString("Changed document properties\n")
+		writeDiffLines(builder, diff.PropertyChanges)
+	}
+	return builder.String()
+}
+
+func summarizeTool(id int, node *ryxnode.RyxNode) *ToolSummary {
+	summary := &ToolSummary{ToolId: id, Plugin: node.ReadPlugin()}
+	if node.EngineSettings != nil {
+		summary.Macro = node.ReadMacro().StoredPath
+	}
+	return summary
+}
+
+func describeTool(tool *ToolSummary) string {
+	if tool.Macro != `` {
+		return fmt.Sprintf(`%v (%v)`, tool.ToolId, tool.Macro)
+	}
+	return fmt.Sprintf(`%v (%v)`, tool.ToolId, tool.Plugin)
+}
+
+func describeParent(parent int) string {
+	if parent == 0 {
+		return `the canvas`
+	}
+	return fmt.Sprintf(`container %v`, parent)
+}
+
+func describeConnection(conn *RyxConn) string {
+	description := fmt.Sprintf(`%v.%v -> %v.%v`, conn.FromId, conn.FromAnchor, conn.ToId, conn.ToAnchor)
+	if conn.Wireless {
+		description += ` (wireless)`
+	}
+	return description
+}
+
+func writeDiffLines(builder *strings.Builder, lines []*DiffLine) {
+	for _, line := range lines {
+		builder.WriteString(strings.TrimRight(fmt.Sprintf("    %v %v", line.Kind, line.Text), ` `) + "\n")
+	}
+}
+
+// readParents maps each tool inside a container to the ID of its container.  Tools on the canvas are not included.
+func readParents(doc *RyxDoc) map[int]int {
+	parents := map[int]int{}
+	for id, node := range doc.ReadMappedNodes() {
+		for _, child := range node.ChildNodes {
+			if childId, err := child.ReadId(); err == nil {
+				parents[childId] = id
+			}
+		}
+	}
+	return parents
+}
+
+func sortedIds(nodes map[int]*ryxnode.RyxNode) []int {
+	ids := []int{}
+	for id := range nodes {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+func connectionsContain(conns []*RyxConn, check *RyxConn) bool {
+	for _, conn := range conns {
+		if conn.FromId == check.FromId &&
+			conn.ToId == check.ToId &&
+			conn.FromAnchor == check.FromAnchor &&
+			conn.ToAnchor == check.ToAnchor &&
+			conn.Wireless == check.Wireless {
+			return true
+		}
+	}
+	return false
+}
+
+func configurationLines(node *ryxnode.RyxNode) []string {
+	if node.Properties == nil {
+		return []string{}
+	}
+	config, err := txml.Parse(`<Configuration>` + node.Properties.Configuration.InnerXml + `</Configuration>`)
+	if err != nil {
+		return strings.Split(strings.TrimSpace(node.Properties.Configuration.InnerXml), "\n")
+	}
+	config.SortAttributes()
+	return xmlLines(config)
+}
+
+// propertyLines sorts the attributes of a copy of the document properties so reordered attributes are not reported
+// as changes.
+func propertyLines(properties *txml.Node) []string {
+	if properties == nil {
+		return []string{}
+	}
+	data, err := xml.Marshal(properties)
+	if err != nil {
+		return xmlLines(properties)
+	}
+	copied, err := txml.Parse(string(data))
+	if err != nil {
+		return xmlLines(properties)
+	}
+	copied.SortAttributes()
+	return xmlLines(copied)
+}
+
+func xmlLines(node *txml.Node) []string {
+	if node == nil {
+		return []string{}
+	}
+	indented, err := node.ToXml(`  `)
+	if err != nil {
+		return []string{}
+	}
+	return strings.Split(indented, "\n")
+}
+
+// diffLines computes a line diff from the longest common subsequence of the two inputs.  Unchanged lines more than
+// diffContext lines away from a change are collapsed.  No lines are returned if the inputs are the same.
+func diffLines(from []string, to []string) []*DiffLine {
+	lengths := make([][]int, len(from)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(to)+1)
+	}
+	for i := len(from) - 1; i >= 0; i-- {
+		for j := len(to) - 1; j >= 0; j-- {
+			if from[i] == to[j] {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else if lengths[i+1][j] >= lengths[i][j+1] {
+				lengths[i][j] = lengths[i+1][j]
+			} else {
+				lengths[i][j] = lengths[i][j+1]
+			}
+		}
+	}
+
+	all := []*DiffLine{}
+	changed := false
+	i, j := 0, 0
+	for i < len(from) || j < len(to) {
+		switch {
+		case i < len(from) && j < len(to) && from[i] == to[j]:
+			all = append(all, &DiffLine{Kind: ` `, Text: from[i]})
+			i++
+			j++
+		case j < len(to) && (i == len(from) || lengths[i][j+1] > lengths[i+1][j]):
+			all = append(all, &DiffLine{Kind: `+`, Text: to[j]})
+			changed = true
+			j++
+		default:
+			all = append(all, &DiffLine{Kind: `-`, Text: from[i]})
+			changed = true
+			i++
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return collapseContext(all)
+}
+
+func collapseContext(all []*DiffLine) []*DiffLine {
+	keep := make([]bool, len(all))
+	for index, line := range all {
+		if line.Kind == ` ` {
+			continue
+		}
+		for offset := -diffContext; offset <= diffContext; offset++ {
+			if index+offset >= 0 && index+offset < len(all) {
+				keep[index+offset] = true
+			}
+		}
+	}
+	lines := []*DiffLine{}
+	skipped := false
+	for index, line := range all {
+		if !keep[index] {
+			skipped = true
+			continue
+		}
+		if skipped {
+			lines = append(lines, &DiffLine{Kind: `...`})
+			skipped = false
+		}
+		lines = append(lines, line)
+	}
+	if skipped {
+		lines = append(lines, &DiffLine{Kind: `...`})
+	}
+	return lines
+}
@@ -6,6 +6,7 @@ import (
 	h "github.com/tlarsen7572/Golang-Public/helpers"
 	"github.com/tlarsen7572/Golang-Public/ryx/ryxnode"
 	"github.com/tlarsen7572/Golang-Public/txml"
+	"html"
 	"math"
 	"path/filepath"
 	"strconv"
@@ -16,7 +17,17 @@ var gridSize = 12.0
 var horizontalGap = gridSize * 8
 var verticalGap = gridSize * 7
 
+var ErrSelectionHasHole = errors.New(`there is a hole in the selected tools - ExtractMacro cannot continue`)
+
 func (ryxDoc *RyxDoc) ExtractMacro(macroAbsPath string, relativeTo string, toolIds ...int) error {
+	return ryxDoc.ExtractMacroNamed(macroAbsPath, relativeTo, nil, toolIds...)
+}
+
+// ExtractMacroNamed works like ExtractMacro but lets the caller name the generated Macro Input and Macro Output
+// anchors.  Names are keyed by AnchorKey: inputs by the tool and anchor outside the selection that feeds them, and
+// outputs by the selected tool and anchor they are fed from.  Anchors without a name default to Input<id> and
+// Output<id>.
+func (ryxDoc *RyxDoc) ExtractMacroNamed(macroAbsPath string, relativeTo string, anchorNames map[string]string, toolIds ...int) error {
 	macroPath := macroAbsPath
 	var err error
 	if relativeTo != `` {
@@ -25,15 +36,21 @@ func (ryxDoc *RyxDoc) ExtractMacro(macroAbsPath string, relativeTo string, toolI
 			return err
 		}
 	}
+	err = checkAnchorNames(anchorNames)
+	if err != nil {
+		return err
+	}
+	toolIds = ryxDoc.expandContainers(toolIds...)
 	if HasHole(ryxDoc, toolIds...) {
-		return errors.New(`there is a hole in the selected tools - ExtractMacro cannot continue`)
+		return ErrSelectionHasHole
 	}
 
 	left, top, right, _ := ryxDoc.getBoundingBox(toolIds...)
 	newMacroTool := ryxDoc.AddMacroAt(macroPath, left, top)
 	newMacro := generateDocFrom(ryxDoc, toolIds...)
 	adjustX, _ := normalizeToolPositions(newMacro, left, top)
-	generateMacroConnections(newMacro, ryxDoc, newMacroTool, right+adjustX+horizontalGap, toolIds...)
+	generateMacroConnections(newMacro, ryxDoc, newMacroTool, right+adjustX+horizontalGap, anchorNames, toolIds...)
+	newMacro.AutoLayout()
 	ryxDoc.RemoveConnectionsBetween(toolIds...)
 	ryxDoc.RemoveNodes(toolIds...)
 
@@ -44,6 +61,43 @@ func (ryxDoc *RyxDoc) ExtractMacro(macroAbsPath string, relativeTo string, toolI
 	return nil
 }
 
+func AnchorKey(toolId int, anchor string) string {
+	return fmt.Sprintf(`%v.%v`, toolId, anchor)
+}
+
+func checkAnchorNames(anchorNames map[string]string) error {
+	used := map[string]bool{}
+	for key, name := range anchorNames {
+		if name == `` {
+			return errors.New(fmt.Sprintf(`the anchor name for '%v' is blank`, key))
+		}
+		if used[name] {
+			return errors.New(fmt.Sprintf(`the anchor name '%v' is used more than once`, name))
+		}
+		used[name] = true
+	}
+	return nil
+}
+
+// expandContainers adds every tool inside a selected container to the selection.
+func (ryxDoc *RyxDoc) expandContainers(toolIds ...int) []int {
+	expanded := append([]int{}, toolIds...)
+	nodes := ryxDoc.ReadMappedNodes()
+	for _, id := range toolIds {
+		node, ok := nodes[id]
+		if !ok {
+			continue
+		}
+		for _, child := range node.ReadChildren() {
+			childId, err := child.ReadId()
+			if err == nil && !intsContain(expanded, childId) {
+				expanded = append(expanded, childId)
+			}
+		}
+	}
+	return expanded
+}
+
 func HasHole(doc *RyxDoc, toolIds ...int) bool {
 	var unselected []int
 	for toolId := range doc.ReadMappedNodes() {
@@ -52,11 +106,9 @@ func HasHole(doc *RyxDoc, toolIds ...int) bool {
 		}
 	}
 
-	outputConns, inputConns := readIoConns(doc)
 	for _, startingAt := range unselected {
-
-		selectedUpstream := upstreamSelected(inputConns, startingAt, toolIds...)
-		selectedDownstream := downstreamSelected(outputConns, startingAt, toolIds...)
+		selectedUpstream := anySelected(doc.Upstream(startingAt), toolIds...)
+		selectedDownstream := anySelected(doc.Downstream(startingAt), toolIds...)
 		if selectedUpstream && selectedDownstream {
 			return true
 		}
@@ -64,26 +116,11 @@ func HasHole(doc *RyxDoc, toolIds ...int) bool {
 	return false
 }
 
-func upstreamSelected(inputConns map[int][]*RyxConn, startingAt int, toolIds ...int) bool {
-	getter := func(conn *RyxConn) int { return conn.FromId }
-	return directionSelected(inputConns, startingAt, getter, toolIds...)
-}
-
-func downstreamSelected(outputConns map[int][]*RyxConn, startingAt int, toolIds ...int) bool {
-	getter := func(conn *RyxConn) int { return conn.ToId }
-	return directionSelected(outputConns, startingAt, getter, toolIds...)
-}
-
-func directionSelected(connections map[int][]*RyxConn, startingAt int, getId func(*RyxConn) int, toolIds ...int) bool {
-	for _, connection := range connections[startingAt] {
-		id := getId(connection)
+func anySelected(ids []int, toolIds ...int) bool {
+	for _, id := range ids {
 		if intsContain(toolIds, id) {
 			return true
 		}
-		distantlySelected := directionSelected(connections, id, getId, toolIds...)
-		if distantlySelected {
-			return true
-		}
 	}
 	return false
 }
@@ -102,7 +139,7 @@ func normalizeToolPositions(doc *RyxDoc, minLeft float64, minTop float64) (float
 	return adjustX, adjustY
 }
 
-func generateMacroConnections(newMacro *RyxDoc, origDoc *RyxDoc, newMacroTool *ryxnode.RyxNode, outputX float64, toolIds ...int) {
+func generateMacroConnections(newMacro *RyxDoc, origDoc *RyxDoc, newMacroTool *ryxnode.RyxNode, outputX float64, anchorNames map[string]string, toolIds ...int) {
 	newMacroToolId, err := newMacroTool.ReadId()
 	if err != nil {
 		return
@@ -113,46 +150,82 @@ func generateMacroConnections(newMacro *RyxDoc, origDoc *RyxDoc, newMacroTool *r
 	newMacro.Nodes = append(newMacro.Nodes, questionTab) // It is ok to use RyxDoc.Nodes here
 	tab := addTabQuestion(newMacro, questionTabId)
 
-	inputCount := 0.0
-	outputCount := 0.0
+	usedNames := map[string]bool{}
+	for _, name := range anchorNames {
+		usedNames[name] = true
+	}
+	anchorName := func(key string, prefix string, id int) string {
+		if name, ok := anchorNames[key]; ok {
+			return name
+		}
+		name := prefix + strconv.Itoa(id)
+		for suffix := 2; usedNames[name]; suffix++ {
+			name = fmt.Sprintf(`%v%v_%v`, prefix, id, suffix)
+		}
+		usedNames[name] = true
+		return name
+	}
+
+	inputNames := map[string]string{}
+	inputIds := map[string]int{}
+	outputNames := map[string]string{}
+	var keep []*RyxConn
 	for _, connection := range origDoc.Connections {
 		matchesFrom := intsContain(toolIds, connection.FromId)
 		matchesTo := intsContain(toolIds, connection.ToId)
 		if matchesFrom && !matchesTo {
-			y := gridStartPos + (verticalGap * outputCount)
-			outputCount += 1
-			outputId := newMacro.grabNextIdAndIncrement()
-			output := newMacroOutput(outputId, outputX, y)
-			addQuestionToTab(tab, `MacroOutput`, fmt.Sprintf(`Macro Output (%v)`, outputId), outputId)
-			newMacro.Nodes = append(newMacro.Nodes, output) // It is ok to use RyxDoc.Nodes here
-			newMacro.AddConnection(&RyxConn{
-				Name:       connection.Name,
-				FromId:     connection.FromId,
-				FromAnchor: connection.FromAnchor,
-				ToId:       outputId,
-				ToAnchor:   `Input`,
-			})
+			key := AnchorKey(connection.FromId, connection.FromAnchor)
+			name, ok := outputNames[key]
+			if !ok {
+				y := gridStartPos + (verticalGap * float64(len(outputNames)))
+				outputId := newMacro.grabNextIdAndIncrement()
+				name = anchorName(key, `Output`, outputId)
+				outputNames[key] = name
+				output := newMacroOutput(outputId, name, outputX, y)
+				addQuestionToTab(tab, `MacroOutput`, fmt.Sprintf(`Macro Output (%v)`, outputId), outputId)
+				newMacro.Nodes = append(newMacro.Nodes, output) // It is ok to use RyxDoc.Nodes here
+				newMacro.AddConnection(&RyxConn{
+					Name:       connection.Name,
+					FromId:     connection.FromId,
+					FromAnchor: connection.FromAnchor,
+					ToId:       outputId,
+					ToAnchor:   `Input`,
+					Wireless:   connection.Wireless,
+				})
+			}
 			connection.FromId = newMacroToolId
-			connection.FromAnchor = `Output` + strconv.Itoa(outputId)
+			connection.FromAnchor = name
 		}
 		if matchesTo && !matchesFrom {
-			y := gridStartPos + (verticalGap * inputCount)
-			inputCount += 1
-			inputId := newMacro.grabNextIdAndIncrement()
-			input := newMacroInput(inputId, gridStartPos, y)
-			addQuestionToTab(tab, `MacroInput`, fmt.Sprintf(`Macro Input (%v)`, inputId), inputId)
-			newMacro.Nodes = append(newMacro.Nodes, input) // It is ok to use RyxDoc.Nodes here
+			key := AnchorKey(connection.FromId, connection.FromAnchor)
+			name, ok := inputNames[key]
+			if !ok {
+				y := gridStartPos + (verticalGap * float64(len(inputNames)))
+				inputId := newMacro.grabNextIdAndIncrement()
+				name = anchorName(key, `Input`, inputId)
+				inputNames[key] = name
+				inputIds[key] = inputId
+				input := newMacroInput(inputId, name, gridStartPos, y)
+				addQuestionToTab(tab, `MacroInput`, fmt.Sprintf(`Macro Input (%v)`, inputId), inputId)
+				newMacro.Nodes = append(newMacro.Nodes, input) // It is ok to use RyxDoc.Nodes here
+			}
 			newMacro.AddConnection(&RyxConn{
 				Name:       connection.Name,
-				FromId:     inputId,
+				FromId:     inputIds[key],
 				FromAnchor: `Output`,
 				ToId:       connection.ToId,
 				ToAnchor:   connection.ToAnchor,
+				Wireless:   connection.Wireless,
 			})
+			if ok {
+				continue
+			}
 			connection.ToId = newMacroToolId
-			connection.ToAnchor = `Input` + strconv.Itoa(inputId)
+			connection.ToAnchor = name
 		}
+		keep = append(keep, connection)
 	}
+	origDoc.Connections = keep
 }
 
 func readIoConns(doc *RyxDoc) (outputConns map[int][]*RyxConn, inputConns map[int][]*RyxConn) {
@@ -175,14 +248,19 @@ func generateDocFrom(from *RyxDoc, toolIds ...int) *RyxDoc {
 }
 
 func copyNodes(from *RyxDoc, to *RyxDoc, toolIds ...int) {
-	for _, node := range from.Nodes { // It is ok to use RyxDoc.Nodes here
-		for _, id := range toolIds {
-			if checkId, _ := node.ReadId(); checkId == id {
-				to.Nodes = append(to.Nodes, node) // It is ok to use RyxDoc.Nodes here
-				break
-			}
+	to.Nodes = append(to.Nodes, selectNodes(from.Nodes, toolIds...)...) // It is ok to use RyxDoc.Nodes here
+}
+
+func selectNodes(nodes []*ryxnode.RyxNode, toolIds ...int) []*ryxnode.RyxNode {
+	var selected []*ryxnode.RyxNode
+	for _, node := range nodes {
+		if node.MatchesIds(toolIds...) {
+			selected = append(selected, node)
+			continue
 		}
+		selected = append(selected, selectNodes(node.ChildNodes, toolIds...)...)
 	}
+	return selected
 }
 
 func copyConnections(from *RyxDoc, to *RyxDoc, toolIds ...int) {
@@ -288,7 +366,7 @@ func generateQuestion(qType string, description string, name string, toolId int)
 	}
 }
 
-func newMacroInput(id int, x float64, y float64) *ryxnode.RyxNode {
+func newMacroInput(id int, name string, x float64, y float64) *ryxnode.RyxNode {
 	return &ryxnode.RyxNode{
 		ToolId: strconv.Itoa(id),
 		GuiSettings: &txml.Node{
@@ -302,7 +380,7 @@ func newMacroInput(id int, x float64, y float64) *ryxnode.RyxNode {
 			},
 		},
 		Properties: &ryxnode.Properties{
-			Configuration: ryxnode.Configuration{InnerXml: fmt.Sprintf(`<UseFileInput value="False" /><Name>Input%v</Name><Abbrev /><ShowFieldMap value="False" /><Optional value="False" /><TextInput><Configuration><NumRows value="0" /><Fields /><Data /></Configuration></TextInput>`, strconv.Itoa(id))},
+			Configuration: ryxnode.Configuration{InnerXml: fmt.Sprintf(`<UseFileInput value="False" /><Name>%v</Name><Abbrev /><ShowFieldMap value="False" /><Optional value="False" /><TextInput><Configuration><NumRows value="0" /><Fields /><Data /></Configuration></TextInput>`, html.EscapeString(name))},
 		},
 		EngineSettings: &txml.Node{
 			Name: `EngineSettings`,
@@ -314,7 +392,7 @@ func newMacroInput(id int, x float64, y float64) *ryxnode.RyxNode {
 	}
 }
 
-func newMacroOutput(id int, x float64, y float64) *ryxnode.RyxNode {
+func newMacroOutput(id int, name string, x float64, y float64) *ryxnode.RyxNode {
 	return &ryxnode.RyxNode{
 		ToolId: strconv.Itoa(id),
 		GuiSettings: &txml.Node{
@@ -328,7 +406,7 @@ func newMacroOutput(id int, x float64, y float64) *ryxnode.RyxNode {
 			},
 		},
 		Properties: &ryxnode.Properties{
-			Configuration: ryxnode.Configuration{InnerXml: `<Name>Output4</Name><Abbrev />`},
+			Configuration: ryxnode.Configuration{InnerXml: fmt.Sprintf(`<Name>%v</Name><Abbrev />`, html.EscapeString(name))},
 		},
 		EngineSettings: &txml.Node{
 			Name: `EngineSettings`,
@@ -345,7 +423,7 @@ func newQuestionTab(id int) *ryxnode.RyxNode {
 		ToolId: strconv.Itoa(id),
 		GuiSettings: &txml.Node{
 			Name:       `GuiSettings`,
-			Attributes: map[string]string{`Plugin`: `AlteryxGuiToolkit.Questions.Tab.Tab`},
+			Attributes: map[string]string{`Plugin`: questionsTabPlugin},
 			Nodes: []*txml.Node{
 				{
 					Name:       `Position`,
@@ -0,0 +1,189 @@
+package ryxdoc
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// Upstream returns the IDs of every tool that feeds the tool, directly or through other tools.
+func (ryxDoc *RyxDoc) Upstream(toolId int) []int {
+	_, inputConns := readIoConns(ryxDoc)
+	return traverse(inputConns, toolId, func(conn *RyxConn) int { return conn.FromId })
+}
+
+// Downstream returns the IDs of every tool fed by the tool, directly or through other tools.
+func (ryxDoc *RyxDoc) Downstream(toolId int) []int {
+	outputConns, _ := readIoConns(ryxDoc)
+	return traverse(outputConns, toolId, func(conn *RyxConn) int { return conn.ToId })
+}
+
+// TopologicalOrder returns the document's tool IDs in an order in which every tool comes after the tools that feed
+// it.  Ties are broken by tool ID.  An error is returned if the connections contain a cycle.
+func (ryxDoc *RyxDoc) TopologicalOrder() ([]int, error) {
+	outputConns, inputConns := readIoConns(ryxDoc)
+	nodes := ryxDoc.ReadMappedNodes()
+	remaining := map[int]int{}
+	ready := []int{}
+	for _, id := range sortedIds(nodes) {
+		for _, previous := range uniqueIds(inputConns[id], func(conn *RyxConn) int { return conn.FromId }) {
+			if _, ok := nodes[previous]; ok {
+				remaining[id]++
+			}
+		}
+		if remaining[id] == 0 {
+			ready = append(ready, id)
+		}
+	}
+	order := []int{}
+	for len(ready) > 0 {
+		current := ready[0]
+		ready = ready[1:]
+		order = append(order, current)
+		for _, next := range uniqueIds(outputConns[current], func(conn *RyxConn) int { return conn.ToId }) {
+			if _, ok := remaining[next]; !ok {
+				continue
+			}
+			remaining[next]--
+			if remaining[next] == 0 {
+				ready = insertSorted(ready, next)
+			}
+		}
+	}
+	if len(order) < len(nodes) {
+		return nil, errors.New(`the document's connections contain a cycle`)
+	}
+	return order, nil
+}
+
+// Sources returns the IDs of tools with outgoing connections but no incoming connections.
+func (ryxDoc *RyxDoc) Sources() []int {
+	outputConns, inputConns := readIoConns(ryxDoc)
+	sources := []int{}
+	for _, id := range sortedIds(ryxDoc.ReadMappedNodes()) {
+		if len(outputConns[id]) > 0 && len(inputConns[id]) == 0 {
+			sources = append(sources, id)
+		}
+	}
+	return sources
+}
+
+// Sinks returns the IDs of tools with incoming connections but no outgoing connections.
+func (ryxDoc *RyxDoc) Sinks() []int {
+	outputConns, inputConns := readIoConns(ryxDoc)
+	sinks := []int{}
+	for _, id := range sortedIds(ryxDoc.ReadMappedNodes()) {
+		if len(inputConns[id]) > 0 && len(outputConns[id]) == 0 {
+			sinks = append(sinks, id)
+		}
+	}
+	return sinks
+}
+
+// ConnectedComponents groups the document's tools into sets that are joined by connections, ignoring the direction
+// of the connections.  Tools without any connections, such as containers, form a component of their own.
+func (ryxDoc *RyxDoc) ConnectedComponents() [][]int {
+	neighbours := map[int][]int{}
+	for _, conn := range ryxDoc.Connections {
+		neighbours[conn.FromId] = append(neighbours[conn.FromId], conn.ToId)
+		neighbours[conn.ToId] = append(neighbours[conn.ToId], conn.FromId)
+	}
+	nodes := ryxDoc.ReadMappedNodes()
+	visited := map[int]bool{}
+	components := [][]int{}
+	for _, id := range sortedIds(nodes) {
+		if visited[id] {
+			continue
+		}
+		visited[id] = true
+		component := []int{}
+		queue := []int{id}
+		for len(queue) > 0 {
+			current := queue[0]
+			queue = queue[1:]
+			component = append(component, current)
+			for _, next := range neighbours[current] {
+				if _, ok := nodes[next]; ok && !visited[next] {
+					visited[next] = true
+					queue = append(queue, next)
+				}
+			}
+		}
+		sort.Ints(component)
+		components = append(components, component)
+	}
+	return components
+}
+
+// ShortestPath returns the IDs of the tools on the shortest path of connections from one tool to another, including
+// both ends.  Connections are only followed in the direction the data flows.
+func (ryxDoc *RyxDoc) ShortestPath(fromId int, toId int) ([]int, error) {
+	nodes := ryxDoc.ReadMappedNodes()
+	for _, id := range []int{fromId, toId} {
+		if _, ok := nodes[id]; !ok {
+			return nil, errors.New(fmt.Sprintf(`tool %v does not exist`, id))
+		}
+	}
+	outputConns, _ := readIoConns(ryxDoc)
+	previous := map[int]int{fromId: fromId}
+	queue := []int{fromId}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if current == toId {
+			path := []int{toId}
+			for id := toId; id != fromId; {
+				id = previous[id]
+				path = append([]int{id}, path...)
+			}
+			return path, nil
+		}
+		for _, next := range uniqueIds(outputConns[current], func(conn *RyxConn) int { return conn.ToId }) {
+			if _, ok := previous[next]; !ok {
+				previous[next] = current
+				queue = append(queue, next)
+			}
+		}
+	}
+	return nil, errors.New(fmt.Sprintf(`there is no path from tool %v to tool %v`, fromId, toId))
+}
+
+func traverse(connections map[int][]*RyxConn, startingAt int, getId func(*RyxConn) int) []int {
+	visited := map[int]bool{startingAt: true}
+	found := []int{}
+	queue := []int{startingAt}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, connection := range connections[current] {
+			id := getId(connection)
+			if visited[id] {
+				continue
+			}
+			visited[id] = true
+			found = append(found, id)
+			queue = append(queue, id)
+		}
+	}
+	sort.Ints(found)
+	return found
+}
+
+func uniqueIds(connections []*RyxConn, getId func(*RyxConn) int) []int {
+	ids := []int{}
+	for _, connection := range connections {
+		if id := getId(connection); !intsContain(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+func insertSorted(ids []int, id int) []int {
+	index := sort.SearchInts(ids, id)
+	ids = append(ids, 0)
+	copy(ids[index+1:], ids[index:])
+	ids[index] = id
+	return ids
+}
@@ -0,0 +1,243 @@
+package ryxdoc
+
+import (
+	"errors"
+	"fmt"
+	"github.com/tlarsen7572/Golang-Public/ryx/ryxnode"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const macroInputPlugin = `AlteryxBasePluginsGui.MacroInput.MacroInput`
+const macroOutputPlugin = `AlteryxBasePluginsGui.MacroOutput.MacroOutput`
+const questionsTabPlugin = `AlteryxGuiToolkit.Questions.Tab.Tab`
+
+var interfacePluginPrefixes = []string{
+	`AlteryxGuiToolkit.Questions.`,
+	`AlteryxGuiToolkit.Action.`,
+	`AlteryxGuiToolkit.Condition.`,
+}
+
+// InlineMacro replaces a macro tool with the tools inside the macro.  The macro's tools receive new IDs and are
+// positioned where the macro tool was.  Connections into and out of the macro's Macro Input and Macro Output tools
+// are wired directly to the surrounding tools, and the macro's interface tools are dropped.  The macro tool's answers
+// to the interface questions are dropped with it, so the inlined tools run with the macro's own configuration.  The
+// IDs the dropped interface tools had inside the macro are returned so the caller can tell which answers were lost.
+func (ryxDoc *RyxDoc) InlineMacro(macroToolId int, macroPaths ...string) ([]int, error) {
+	macroNode, ok := ryxDoc.ReadMappedNodes()[macroToolId]
+	if !ok {
+		return nil, errors.New(fmt.Sprintf(`tool %v does not exist`, macroToolId))
+	}
+	if macroNode.ReadCategory() != ryxnode.Macro {
+		return nil, errors.New(fmt.Sprintf(`tool %v is not a macro`, macroToolId))
+	}
+	macroPath := macroNode.ReadMacro(macroPaths...)
+	if macroPath.FoundPath == `` {
+		return nil, errors.New(fmt.Sprintf(`the macro '%v' could not be found`, macroPath.StoredPath))
+	}
+	macro, err := ReadFile(macroPath.FoundPath)
+	if err != nil {
+		return nil, err
+	}
+	macro.MakeAllMacrosAbsolute(append([]string{filepath.Dir(macroPath.FoundPath)}, macroPaths...)...)
+
+	inputs := map[string]int{}
+	outputs := map[string]int{}
+	interfaceIds := []int{}
+	dropped := []int{}
+	for id, node := range macro.ReadMappedNodes() {
+		plugin := node.ReadPlugin()
+		switch {
+		case plugin == macroInputPlugin:
+			inputs[readConfigText(node, `Name`)] = id
+		case plugin == macroOutputPlugin:
+			outputs[readConfigText(node, `Name`)] = id
+		case !isInterfacePlugin(plugin):
+			continue
+		default:
+			dropped = append(dropped, id)
+		}
+		interfaceIds = append(interfaceIds, id)
+	}
+	macro.RemoveNodes(interfaceIds...)
+	sort.Ints(dropped)
+
+	newIds := ryxDoc.remapNodeIds(macro)
+	if len(newIds) > 0 {
+		macroPosition, err := macroNode.ReadPosition()
+		if err == nil {
+			left, top, _, _ := macro.getBoundingBox(readNewIds(newIds)...)
+			offsetNodes(macro, macroPosition.X-left, macroPosition.Y-top)
+		}
+	}
+
+	var outerIns []*RyxConn
+	var outerOuts []*RyxConn
+	var keep []*RyxConn
+	for _, conn := range ryxDoc.Connections {
+		switch {
+		case conn.ToId == macroToolId:
+			outerIns = append(outerIns, conn)
+		case conn.FromId == macroToolId:
+			outerOuts = append(outerOuts, conn)
+		default:
+			keep = append(keep, conn)
+		}
+	}
+	ryxDoc.Connections = keep
+
+	outputNames := map[int]string{}
+	for name, id := range outputs {
+		outputNames[id] = name
+	}
+	for _, conn := range macro.Connections {
+		_, fromKept := newIds[conn.FromId]
+		toKept, isKept := newIds[conn.ToId]
+		outputName, toOutput := outputNames[conn.ToId]
+		switch {
+		case fromKept && isKept:
+			ryxDoc.AddConnection(&RyxConn{
+				Name:       conn.Name,
+				FromId:     newIds[conn.FromId],
+				FromAnchor: conn.FromAnchor,
+				ToId:       toKept,
+				ToAnchor:   conn.ToAnchor,
+				Wireless:   conn.Wireless,
+			})
+		case fromKept && toOutput:
+			for _, outerOut := range matchingFromAnchor(outerOuts, outputName) {
+				ryxDoc.AddConnection(&RyxConn{
+					Name:       outerOut.Name,
+					FromId:     newIds[conn.FromId],
+					FromAnchor: conn.FromAnchor,
+					ToId:       outerOut.ToId,
+					ToAnchor:   outerOut.ToAnchor,
+					Wireless:   outerOut.Wireless,
+				})
+			}
+		}
+	}
+	for inputName, inputId := range inputs {
+		for _, outerIn := range matchingToAnchor(outerIns, inputName) {
+			for _, conn := range macro.Connections {
+				if conn.FromId != inputId {
+					continue
+				}
+				if toId, ok := newIds[conn.ToId]; ok {
+					ryxDoc.AddConnection(&RyxConn{
+						Name:       conn.Name,
+						FromId:     outerIn.FromId,
+						FromAnchor: outerIn.FromAnchor,
+						ToId:       toId,
+						ToAnchor:   conn.ToAnchor,
+						Wireless:   outerIn.Wireless,
+					})
+					continue
+				}
+				if outputName, ok := outputNames[conn.ToId]; ok {
+					for _, outerOut := range matchingFromAnchor(outerOuts, outputName) {
+						ryxDoc.AddConnection(&RyxConn{
+							Name:       outerOut.Name,
+							FromId:     outerIn.FromId,
+							FromAnchor: outerIn.FromAnchor,
+							ToId:       outerOut.ToId,
+							ToAnchor:   outerOut.ToAnchor,
+							Wireless:   outerIn.Wireless || outerOut.Wireless,
+						})
+					}
+				}
+			}
+		}
+	}
+
+	parent := ryxDoc.findParent(macroToolId)
+	if parent == nil {
+		ryxDoc.Nodes = append(ryxDoc.Nodes, macro.Nodes...) // It is ok to use RyxDoc.Nodes here
+	} else {
+		parent.ChildNodes = append(parent.ChildNodes, macro.Nodes...) // It is ok to use RyxDoc.Nodes here
+	}
+	ryxDoc.RemoveNodes(macroToolId)
+	return dropped, nil
+}
+
+// remapNodeIds gives every node in the other document a new ID from this document and returns a map of old IDs to
+// new IDs.  Connections in the other document are not changed.
+func (ryxDoc *RyxDoc) remapNodeIds(other *RyxDoc) map[int]int {
+	otherNodes := other.ReadMappedNodes()
+	oldIds := []int{}
+	for id := range otherNodes {
+		oldIds = append(oldIds, id)
+	}
+	sort.Ints(oldIds)
+	newIds := map[int]int{}
+	for _, oldId := range oldIds {
+		newId := ryxDoc.grabNextIdAndIncrement()
+		otherNodes[oldId].ToolId = strconv.Itoa(newId)
+		newIds[oldId] = newId
+	}
+	return newIds
+}
+
+// findParent returns the container holding the tool, or nil if the tool is at the root of the document.
+func (ryxDoc *RyxDoc) findParent(toolId int) *ryxnode.RyxNode {
+	for _, node := range ryxDoc.ReadMappedNodes() {
+		for _, child := range node.ChildNodes {
+			if child.MatchesIds(toolId) {
+				return node
+			}
+		}
+	}
+	return nil
+}
+
+func offsetNodes(doc *RyxDoc, x float64, y float64) {
+	for _, node := range doc.ReadMappedNodes() {
+		position, err := node.ReadPosition()
+		if err == nil {
+			node.SetPosition(position.X+x, position.Y+y)
+		}
+	}
+}
+
+func readNewIds(newIds map[int]int) []int {
+	ids := []int{}
+	for _, newId := range newIds {
+		ids = append(ids, newId)
+	}
+	return ids
+}
+
+func matchingToAnchor(conns []*RyxConn, anchor string) []*RyxConn {
+	var matches []*RyxConn
+	for _, conn := range conns {
+		if conn.ToAnchor == anchor {
+			matches = append(matches, conn)
+		}
+	}
+	return matches
+}
+
+func matchingFromAnchor(conns []*RyxConn, anchor string) []*RyxConn {
+	var matches []*RyxConn
+	for _, conn := range conns {
+		if conn.FromAnchor == anchor {
+			matches = append(matches, conn)
+		}
+	}
+	return matches
+}
+
+func isInterfacePlugin(plugin string) bool {
+	for _, prefix := range interfacePluginPrefixes {
+		if strings.HasPrefix(plugin, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func readConfigText(node *ryxnode.RyxNode, element string) string {
+	return readConfigNode(node).First(element).InnerText
+}
@@ -0,0 +1,212 @@
+package ryxdoc
+
+import (
+	"github.com/tlarsen7572/Golang-Public/ryx/ryxnode"
+	"sort"
+)
+
+var layoutVerticalGap = gridSize * 2
+var containerPadding = gridSize * 2
+var containerHeader = gridSize * 3
+var layoutSweeps = 4
+
+type layoutBlock struct {
+	node     *ryxnode.RyxNode
+	width    float64
+	height   float64
+	order    float64
+	layer    int
+	children []*ryxnode.RyxNode
+}
+
+// AutoLayout arranges the document's tools left to right in layers, so that every connection flows from one layer
+// into a later one.  Tools within a layer are ordered to reduce crossing connections.  Containers are laid out on
+// their own and then treated as a single block in their parent.  Interface tools keep their positions.
+func (ryxDoc *RyxDoc) AutoLayout() {
+	layoutScope(ryxDoc.Nodes, ryxDoc.Connections, gridStartPos, gridStartPos) // It is ok to use RyxDoc.Nodes here
+}
+
+func layoutScope(nodes []*ryxnode.RyxNode, connections []*RyxConn, startX float64, startY float64) (float64, float64) {
+	blocks := []*layoutBlock{}
+	owners := map[int]int{}
+	for _, node := range nodes {
+		if isInterfacePlugin(node.ReadPlugin()) {
+			continue
+		}
+		position, err := node.ReadPosition()
+		if err != nil {
+			continue
+		}
+		block := &layoutBlock{node: node, width: position.Width, height: position.Height, order: position.Y}
+		if len(node.ChildNodes) > 0 {
+			block.children = node.ReadChildren()
+			width, height := layoutScope(node.ChildNodes, connections, 0, 0)
+			block.width = width + containerPadding*2
+			block.height = height + containerHeader + containerPadding
+		}
+		index := len(blocks)
+		blocks = append(blocks, block)
+		if id, err := node.ReadId(); err == nil {
+			owners[id] = index
+		}
+		for _, child := range block.children {
+			if id, err := child.ReadId(); err == nil {
+				owners[id] = index
+			}
+		}
+	}
+	if len(blocks) == 0 {
+		return 0, 0
+	}
+
+	predecessors := make([][]int, len(blocks))
+	successors := make([][]int, len(blocks))
+	for _, conn := range connections {
+		from, fromOk := owners[conn.FromId]
+		to, toOk := owners[conn.ToId]
+		if !fromOk || !toOk || from == to || intsContain(successors[from], to) {
+			continue
+		}
+		successors[from] = append(successors[from], to)
+		predecessors[to] = append(predecessors[to], from)
+	}
+
+	assignLayers(blocks, predecessors, successors)
+	layers := orderLayers(blocks, predecessors, successors)
+
+	x := startX
+	maxY := startY
+	for _, layer := range layers {
+		y := startY
+		layerWidth := 0.0
+		for _, index := range layer {
+			block := blocks[index]
+			placeBlock(block, x, y)
+			y += block.height + layoutVerticalGap
+			if block.width > layerWidth {
+				layerWidth = block.width
+			}
+		}
+		if y-layoutVerticalGap > maxY {
+			maxY = y - layoutVerticalGap
+		}
+		x += layerWidth + horizontalGap
+	}
+	return x - horizontalGap - startX, maxY - startY
+}
+
+// assignLayers places every block one layer to the right of its furthest predecessor.  If the connections contain a
+// cycle, the first unplaced block in the cycle is placed as though the cycle did not exist.
+func assignLayers(blocks []*layoutBlock, predecessors [][]int, successors [][]int) {
+	remaining := make([]int, len(blocks))
+	placed := make([]bool, len(blocks))
+	queue := []int{}
+	for index := range blocks {
+		remaining[index] = len(predecessors[index])
+		if remaining[index] == 0 {
+			queue = append(queue, index)
+		}
+	}
+	for placedCount := 0; placedCount < len(blocks); placedCount++ {
+		if len(queue) == 0 {
+			for index := range blocks {
+				if !placed[index] {
+					queue = append(queue, index)
+					break
+				}
+			}
+		}
+		current := queue[0]
+		queue = queue[1:]
+		placed[current] = true
+		for _, next := range successors[current] {
+			if placed[next] {
+				continue
+			}
+			if blocks[current].layer+1 > blocks[next].layer {
+				blocks[next].layer = blocks[current].layer + 1
+			}
+			remaining[next]--
+			if remaining[next] == 0 {
+				queue = append(queue, next)
+			}
+		}
+	}
+}
+
+// orderLayers groups the blocks by layer and then sweeps back and forth across the layers, sorting each layer by
+// the average position of its neighbours in the previous layer.
+func orderLayers(blocks []*layoutBlock, predecessors [][]int, successors [][]int) [][]int {
+	layerCount := 0
+	for _, block := range blocks {
+		if block.layer+1 > layerCount {
+			layerCount = block.layer + 1
+		}
+	}
+	layers := make([][]int, layerCount)
+	for index, block := range blocks {
+		layers[block.layer] = append(layers[block.layer], index)
+	}
+	positions := make([]float64, len(blocks))
+	for _, layer := range layers {
+		sort.SliceStable(layer, func(i, j int) bool {
+			return blocks[layer[i]].order < blocks[layer[j]].order
+		})
+		setLayerPositions(layer, positions)
+	}
+
+	for sweep := 0; sweep < layoutSweeps; sweep++ {
+		if sweep%2 == 0 {
+			for layer := 1; layer < layerCount; layer++ {
+				sortByBarycenter(layers[layer], predecessors, positions)
+			}
+			continue
+		}
+		for layer := layerCount - 2; layer >= 0; layer-- {
+			sortByBarycenter(layers[layer], successors, positions)
+		}
+	}
+	return layers
+}
+
+func sortByBarycenter(layer []int, neighbours [][]int, positions []float64) {
+	barycenters := map[int]float64{}
+	for _, index := range layer {
+		if len(neighbours[index]) == 0 {
+			barycenters[index] = positions[index]
+			continue
+		}
+		total := 0.0
+		for _, neighbour := range neighbours[index] {
+			total += positions[neighbour]
+		}
+		barycenters[index] = total / float64(len(neighbours[index]))
+	}
+	sort.SliceStable(layer, func(i, j int) bool {
+		return barycenters[layer[i]] < barycenters[layer[j]]
+	})
+	setLayerPositions(layer, positions)
+}
+
+func setLayerPositions(layer []int, positions []float64) {
+	for position, index := range layer {
+		positions[index] = float64(position)
+	}
+}
+
+// placeBlock moves a block to its new position.  The tools inside a container were laid out relative to 0,0 so they
+// are shifted along with the container.
+func placeBlock(block *layoutBlock, x float64, y float64) {
+	block.node.SetPosition(x, y)
+	if block.children == nil {
+		return
+	}
+	block.node.SetSize(block.width, block.height)
+	for _, child := range block.children {
+		position, err := child.ReadPosition()
+		if err != nil {
+			continue
+		}
+		child.SetPosition(position.X+x+containerPadding, position.Y+y+containerHeader)
+	}
+}
@@ -0,0 +1,466 @@
+package ryxdoc
+
+import (
+	"bytes"
+	"encoding/xml"
+	"github.com/tlarsen7572/Golang-Public/ryx/ryxnode"
+	"github.com/tlarsen7572/Golang-Public/txml"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"strings"
+)
+
+// rawDocument remembers the original bytes of a document read in lossless mode.  Each part of the document is
+// stored next to the canonical form it had when it was read.  When the document is saved, any part whose canonical
+// form has not changed is written back exactly as it was read.
+type rawDocument struct {
+	prolog    []byte
+	rootStart []byte
+	rootEnd   []byte
+	closing   []byte
+	tail      []byte
+	yxmdVer   string
+	sections  []*rawSection
+	nodes     map[string]*rawElement
+}
+
+type rawSection struct {
+	name      string
+	leading   []byte
+	start     []byte
+	end       []byte
+	raw       []byte
+	canonical string
+	indent    string
+	closing   string
+	children  []*rawElement
+}
+
+type rawElement struct {
+	raw       []byte
+	canonical string
+}
+
+func ReadFileLossless(path string) (*RyxDoc, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ReadBytesLossless(content)
+}
+
+// ReadBytesLossless reads a document that will be saved with minimal changes.  Tools, connections, and document
+// properties that are not modified are saved byte-for-byte as they were read, as are the XML declaration and any
+// top-level elements ryx does not model.  Modified tools are re-serialized and lose their MetaInfo, which Designer
+// regenerates.
+func ReadBytesLossless(content []byte) (*RyxDoc, error) {
+	doc, err := ReadBytes(content)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := scanRawDocument(content)
+	if err != nil {
+		return nil, err
+	}
+	raw.yxmdVer = doc.YxmdVer
+	doc.raw = raw
+	return doc, nil
+}
+
+func scanRawDocument(content []byte) (*rawDocument, error) {
+	raw := &rawDocument{nodes: map[string]*rawElement{}}
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	var path []string
+	var starts []int64
+	var section *rawSection
+	var sectionStartEnd int64
+	var lastSectionEnd int64
+	var lastChildEnd int64
+	for {
+		offset := decoder.InputOffset()
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch element := token.(type) {
+		case xml.StartElement:
+			path = append(path, element.Name.Local)
+			starts = append(starts, offset)
+			switch len(path) {
+			case 1:
+				raw.prolog = content[:offset]
+				raw.rootStart = content[offset:decoder.InputOffset()]
+				lastSectionEnd = decoder.InputOffset()
+			case 2:
+				section = &rawSection{
+					name:    element.Name.Local,
+					leading: content[lastSectionEnd:offset],
+					start:   content[offset:decoder.InputOffset()],
+				}
+				sectionStartEnd = decoder.InputOffset()
+				lastChildEnd = sectionStartEnd
+			case 3:
+				if section.indent == `` {
+					section.indent = string(content[sectionStartEnd:offset])
+				}
+			}
+		case xml.EndElement:
+			start := starts[len(starts)-1]
+			end := decoder.InputOffset()
+			name := element.Name.Local
+			parent := ``
+			if len(path) > 1 {
+				parent = path[len(path)-2]
+			}
+			if name == `Node` && (parent == `Nodes` || parent == `ChildNodes`) {
+				if id, ok := readToolIdAttr(content[start:end]); ok {
+					raw.nodes[id] = &rawElement{raw: content[start:end]}
+				}
+			}
+			switch len(path) {
+			case 1:
+				raw.closing = content[lastSectionEnd:offset]
+				raw.rootEnd = content[offset:end]
+				raw.tail = content[end:]
+			case 2:
+				section.raw = content[start:end]
+				section.end = content[offset:end]
+				section.closing = string(content[lastChildEnd:offset])
+				raw.sections = append(raw.sections, section)
+				lastSectionEnd = end
+			case 3:
+				section.children = append(section.children, &rawElement{raw: content[start:end]})
+				lastChildEnd = end
+			}
+			path = path[:len(path)-1]
+			starts = starts[:len(starts)-1]
+		}
+	}
+
+	for _, node := range raw.nodes {
+		parsed, err := ryxnode.GenerateNodeFromXml(string(node.raw))
+		if err != nil {
+			return nil, err
+		}
+		node.canonical = marshalCanonical(parsed)
+	}
+	for _, section := range raw.sections {
+		for _, child := range section.children {
+			child.canonical = canonicalChild(section.name, child.raw)
+		}
+		section.canonical = joinCanonicals(section.children)
+	}
+	return raw, nil
+}
+
+func readToolIdAttr(element []byte) (string, bool) {
+	decoder := xml.NewDecoder(bytes.NewReader(element))
+	token, err := decoder.Token()
+	if err != nil {
+		return ``, false
+	}
+	start, ok := token.(xml.StartElement)
+	if !ok {
+		return ``, false
+	}
+	for _, attr := range start.Attr {
+		if attr.Name.Local == `ToolID` {
+			return attr.Value, true
+		}
+	}
+	return ``, false
+}
+
+func canonicalChild(section string, raw []byte) string {
+	switch section {
+	case `Nodes`:
+		node, err := ryxnode.GenerateNodeFromXml(string(raw))
+		if err != nil {
+			return ``
+		}
+		return marshalCanonical(node)
+	case `Connections`:
+		conn := &RyxConn{}
+		if err := xml.Unmarshal(raw, conn); err != nil {
+			return ``
+		}
+		return marshalCanonical(conn)
+	case `Properties`:
+		node, err := txml.Parse(string(raw))
+		if err != nil {
+			return ``
+		}
+		return marshalCanonical(node)
+	default:
+		return string(raw)
+	}
+}
+
+func marshalCanonical(value interface{}) string {
+	data, err := xml.Marshal(value)
+	if err != nil {
+		return ``
+	}
+	return string(data)
+}
+
+func joinCanonicals(elements []*rawElement) string {
+	canonicals := []string{}
+	for _, element := range elements {
+		canonicals = append(canonicals, element.canonical)
+	}
+	return strings.Join(canonicals, "\n")
+}
+
+func (raw *rawDocument) render(doc *RyxDoc) ([]byte, error) {
+	out := &bytes.Buffer{}
+	out.Write(raw.prolog)
+	if doc.YxmdVer == raw.yxmdVer {
+		out.Write(raw.rootStart)
+	} else {
+		out.WriteString(`<AlteryxDocument yxmdVer="` + escapeAttr(doc.YxmdVer) + `">`)
+	}
+
+	rendered := map[string]bool{}
+	for _, section := range raw.sections {
+		out.Write(section.leading)
+		var err error
+		switch section.name {
+		case `Nodes`:
+			err = raw.renderNodes(out, section, doc.Nodes) // It is ok to use RyxDoc.Nodes here
+		case `Connections`:
+			err = raw.renderConnections(out, section, doc.Connections)
+		case `Properties`:
+			err = raw.renderProperties(out, section, doc.Properties)
+		default:
+			out.Write(section.raw)
+		}
+		if err != nil {
+			return nil, err
+		}
+		rendered[section.name] = true
+	}
+
+	missing := &RyxDoc{}
+	if !rendered[`Nodes`] {
+		missing.Nodes = doc.Nodes // It is ok to use RyxDoc.Nodes here
+	}
+	if !rendered[`Connections`] {
+		missing.Connections = doc.Connections
+	}
+	if !rendered[`Properties`] {
+		missing.Properties = doc.Properties
+	}
+	if len(missing.Nodes) > 0 || len(missing.Connections) > 0 || missing.Properties != nil { // It is ok to use RyxDoc.Nodes here
+		data, err := xml.MarshalIndent(missing, ``, `  `)
+		if err != nil {
+			return nil, err
+		}
+		lines := strings.Split(string(data), "\n")
+		if len(lines) > 2 {
+			out.WriteString("\n" + strings.Join(lines[1:len(lines)-1], "\n"))
+		}
+	}
+
+	out.Write(raw.closing)
+	out.Write(raw.rootEnd)
+	out.Write(raw.tail)
+	return out.Bytes(), nil
+}
+
+func (raw *rawDocument) renderNodes(out *bytes.Buffer, section *rawSection, nodes []*ryxnode.RyxNode) error {
+	canonicals := []*rawElement{}
+	for _, node := range nodes {
+		canonicals = append(canonicals, &rawElement{canonical: marshalCanonical(node)})
+	}
+	if joinCanonicals(canonicals) == section.canonical {
+		out.Write(section.raw)
+		return nil
+	}
+	end := openSection(out, section)
+	indent := sectionIndent(section, `    `)
+	for _, node := range nodes {
+		out.WriteString(indent)
+		data, err := raw.renderNode(node, trimNewline(indent))
+		if err != nil {
+			return err
+		}
+		out.Write(data)
+	}
+	out.WriteString(sectionClosing(section, nodes != nil))
+	out.Write(end)
+	return nil
+}
+
+func (raw *rawDocument) renderNode(node *ryxnode.RyxNode, indent string) ([]byte, error) {
+	if original, ok := raw.nodes[node.ToolId]; ok && original.canonical == marshalCanonical(node) {
+		return original.raw, nil
+	}
+	shallow := *node
+	shallow.ChildNodes = nil
+	data, err := marshalIndented(&shallow, indent)
+	if err != nil || len(node.ChildNodes) == 0 {
+		return data, err
+	}
+
+	children := &bytes.Buffer{}
+	children.WriteString("\n" + indent + `  <ChildNodes>`)
+	for _, child := range node.ChildNodes {
+		childData, err := raw.renderNode(child, indent+`    `)
+		if err != nil {
+			return nil, err
+		}
+		children.WriteString("\n" + indent + `    `)
+		children.Write(childData)
+	}
+	children.WriteString("\n" + indent + `  </ChildNodes>`)
+
+	closeTag := []byte("\n" + indent + `</Node>`)
+	if !bytes.HasSuffix(data, closeTag) {
+		closeTag = []byte(`</Node>`)
+	}
+	result := append([]byte{}, data[:len(data)-len(closeTag)]...)
+	result = append(result, children.Bytes()...)
+	return append(result, closeTag...), nil
+}
+
+func (raw *rawDocument) renderConnections(out *bytes.Buffer, section *rawSection, conns []*RyxConn) error {
+	canonicals := []*rawElement{}
+	for _, conn := range conns {
+		canonicals = append(canonicals, &rawElement{canonical: marshalCanonical(conn)})
+	}
+	if joinCanonicals(canonicals) == section.canonical {
+		out.Write(section.raw)
+		return nil
+	}
+	end := openSection(out, section)
+	indent := sectionIndent(section, `    `)
+	available := availableChildren(section)
+	for index, conn := range conns {
+		out.WriteString(indent)
+		if original := takeChild(available, canonicals[index].canonical); original != nil {
+			out.Write(original)
+			continue
+		}
+		data, err := marshalIndented(conn, trimNewline(indent))
+		if err != nil {
+			return err
+		}
+		out.Write(data)
+	}
+	out.WriteString(sectionClosing(section, len(conns) > 0))
+	out.Write(end)
+	return nil
+}
+
+func (raw *rawDocument) renderProperties(out *bytes.Buffer, section *rawSection, properties *txml.Node) error {
+	if properties == nil {
+		return nil
+	}
+	canonicals := []*rawElement{}
+	for _, child := range properties.Nodes {
+		canonicals = append(canonicals, &rawElement{canonical: marshalCanonical(child)})
+	}
+	if joinCanonicals(canonicals) == section.canonical {
+		out.Write(section.raw)
+		return nil
+	}
+	end := openSection(out, section)
+	indent := sectionIndent(section, `    `)
+	available := availableChildren(section)
+	for index, child := range properties.Nodes {
+		out.WriteString(indent)
+		if original := takeChild(available, canonicals[index].canonical); original != nil {
+			out.Write(original)
+			continue
+		}
+		data, err := marshalIndented(child, trimNewline(indent))
+		if err != nil {
+			return err
+		}
+		out.Write(data)
+	}
+	out.WriteString(sectionClosing(section, len(properties.Nodes) > 0))
+	out.Write(end)
+	return nil
+}
+
+// openSection writes the section's start tag.  Sections that were read as empty elements are given a separate end
+// tag so children can be written into them.
+func openSection(out *bytes.Buffer, section *rawSection) []byte {
+	if len(section.end) > 0 {
+		out.Write(section.start)
+		return section.end
+	}
+	out.Write(bytes.TrimRight(bytes.TrimSuffix(section.start, []byte(`/>`)), ` `))
+	out.WriteString(`>`)
+	return []byte(`</` + section.name + `>`)
+}
+
+func availableChildren(section *rawSection) map[string][][]byte {
+	available := map[string][][]byte{}
+	for _, child := range section.children {
+		available[child.canonical] = append(available[child.canonical], child.raw)
+	}
+	return available
+}
+
+func takeChild(available map[string][][]byte, canonical string) []byte {
+	originals := available[canonical]
+	if len(originals) == 0 {
+		return nil
+	}
+	available[canonical] = originals[1:]
+	return originals[0]
+}
+
+func sectionIndent(section *rawSection, fallback string) string {
+	if section.indent != `` {
+		return section.indent
+	}
+	return "\n" + fallback
+}
+
+func sectionClosing(section *rawSection, hasChildren bool) string {
+	if !hasChildren {
+		return ``
+	}
+	if strings.Contains(section.closing, "\n") {
+		return section.closing
+	}
+	return "\n" + trimNewline(string(section.leading))
+}
+
+func trimNewline(whitespace string) string {
+	index := strings.LastIndex(whitespace, "\n")
+	return whitespace[index+1:]
+}
+
+var emptyElement = regexp.MustCompile(`<([^\s<>/]+)((?:[^<>]*[^<>/])?)></([^\s<>/]+)>`)
+
+// marshalIndented serializes a modified part of the document the way Designer does, with empty elements closed
+// as <Element />.
+func marshalIndented(value interface{}, indent string) ([]byte, error) {
+	data, err := xml.MarshalIndent(value, indent, `  `)
+	if err != nil {
+		return nil, err
+	}
+	data = emptyElement.ReplaceAllFunc(data, func(match []byte) []byte {
+		groups := emptyElement.FindSubmatch(match)
+		if !bytes.Equal(groups[1], groups[3]) {
+			return match
+		}
+		return []byte(`<` + string(groups[1]) + string(groups[2]) + ` />`)
+	})
+	return bytes.TrimPrefix(data, []byte(indent)), nil
+}
+
+func escapeAttr(value string) string {
+	buffer := &bytes.Buffer{}
+	_ = xml.EscapeText(buffer, []byte(value))
+	return buffer.String()
+}
@@ -0,0 +1,393 @@
+package ryxdoc
+
+import (
+	"encoding/xml"
+	"fmt"
+	"github.com/tlarsen7572/Golang-Public/ryx/ryxnode"
+	"github.com/tlarsen7572/Golang-Public/txml"
+	"strconv"
+)
+
+const ToolConflict = `Tool`
+const ConnectionConflict = `Connection`
+const PropertyConflict = `Property`
+
+type MergeConflict struct {
+	Kind        string
+	ToolId      int
+	Key         string
+	Description string
+}
+
+// mergeVersions holds the base, ours and theirs values of one part of a document.  Missing parts are blank.
+type mergeVersions struct {
+	base   string
+	ours   string
+	theirs string
+}
+
+// resolve picks the merged value.  If both sides changed the value in different ways, ours is kept and the
+// conflict is reported.
+func (versions mergeVersions) resolve() (string, bool) {
+	switch {
+	case versions.ours == versions.theirs:
+		return versions.ours, true
+	case versions.ours == versions.base:
+		return versions.theirs, true
+	case versions.theirs == versions.base:
+		return versions.ours, true
+	default:
+		return versions.ours, false
+	}
+}
+
+// Merge combines the changes made in ours and theirs since base.  Tools are matched by ToolID and their content,
+// position and container are merged separately, so one side moving a tool while the other edits it is not a
+// conflict.  Connections and the children of the document's Properties are merged the same way.  Wherever both
+// sides made different changes, the merged document keeps ours and a conflict is returned.
+func Merge(base *RyxDoc, ours *RyxDoc, theirs *RyxDoc) (*RyxDoc, []*MergeConflict) {
+	conflicts := []*MergeConflict{}
+	merged := &RyxDoc{YxmdVer: ours.YxmdVer, Properties: ours.Properties, raw: ours.raw}
+	if version, ok := (mergeVersions{base.YxmdVer, ours.YxmdVer, theirs.YxmdVer}).resolve(); ok {
+		merged.YxmdVer = version
+	}
+
+	baseNodes := base.ReadMappedNodes()
+	ourNodes := ours.ReadMappedNodes()
+	theirNodes := theirs.ReadMappedNodes()
+	baseParents := readParents(base)
+	ourParents := readParents(ours)
+	theirParents := readParents(theirs)
+
+	mergedNodes := map[int]*ryxnode.RyxNode{}
+	mergedParents := map[int]int{}
+	for _, id := range mergeIds(ourNodes, theirNodes, baseNodes) {
+		content := mergeVersions{
+			base:   nodeContent(baseNodes[id]),
+			ours:   nodeContent(ourNodes[id]),
+			theirs: nodeContent(theirNodes[id]),
+		}
+		position := mergeVersions{
+			base:   nodePosition(baseNodes[id]),
+			ours:   nodePosition(ourNodes[id]),
+			theirs: nodePosition(theirNodes[id]),
+		}
+		parent := mergeVersions{
+			base:   parentKey(baseNodes[id], baseParents[id]),
+			ours:   parentKey(ourNodes[id], ourParents[id]),
+			theirs: parentKey(theirNodes[id], theirParents[id]),
+		}
+
+		mergedContent, contentOk := content.resolve()
+		if !contentOk {
+			conflicts = append(conflicts, &MergeConflict{Kind: ToolConflict, ToolId: id, Key: `Content`, Description: describeToolConflict(content)})
+		}
+		if mergedContent == `` {
+			continue
+		}
+		mergedPosition, positionOk := position.resolve()
+		mergedParent, parentOk := parent.resolve()
+		if content.ours != `` && content.theirs != `` {
+			if !positionOk {
+				conflicts = append(conflicts, &MergeConflict{Kind: ToolConflict, ToolId: id, Key: `Position`, Description: `the tool was moved to different positions`})
+			}
+			if !parentOk {
+				conflicts = append(conflicts, &MergeConflict{Kind: ToolConflict, ToolId: id, Key: `Container`, Description: `the tool was moved into different containers`})
+			}
+		}
+		if mergedPosition == `` {
+			mergedPosition = firstNonBlank(position.ours, position.theirs, position.base)
+		}
+		if mergedParent == `` {
+			mergedParent = firstNonBlank(parent.ours, parent.theirs, parent.base)
+		}
+
+		node, err := nodeFromContent(mergedContent, mergedPosition)
+		if err != nil {
+			continue
+		}
+		mergedNodes[id] = node
+		mergedParents[id], _ = strconv.Atoi(mergedParent)
+	}
+
+	order := append(rootOrder(ours), rootOrder(theirs)...)
+	added := map[int]bool{}
+	for _, id := range append(order, sortedIds(mergedNodes)...) {
+		node, ok := mergedNodes[id]
+		if !ok || added[id] {
+			continue
+		}
+		added[id] = true
+		parent, hasParent := mergedNodes[mergedParents[id]]
+		if hasParent && mergedParents[id] != id {
+			parent.ChildNodes = append(parent.ChildNodes, node)
+			continue
+		}
+		merged.Nodes = append(merged.Nodes, node) // It is ok to use RyxDoc.Nodes here
+	}
+
+	merged.Connections, conflicts = mergeConnections(base, ours, theirs, mergedNodes, conflicts)
+	merged.Properties, conflicts = mergeProperties(base.Properties, ours.Properties, theirs.Properties, conflicts)
+
+	maxId := 0
+	for id := range mergedNodes {
+		if id > maxId {
+			maxId = id
+		}
+	}
+	merged.nextId = maxId + 1
+	return merged, conflicts
+}
+
+func mergeConnections(base *RyxDoc, ours *RyxDoc, theirs *RyxDoc, mergedNodes map[int]*ryxnode.RyxNode, conflicts []*MergeConflict) ([]*RyxConn, []*MergeConflict) {
+	baseConns := mapConnections(base.Connections)
+	ourConns := mapConnections(ours.Connections)
+	theirConns := mapConnections(theirs.Connections)
+
+	keys := []string{}
+	seen := map[string]bool{}
+	for _, conns := range [][]*RyxConn{ours.Connections, theirs.Connections} {
+		for _, conn := range conns {
+			key := connectionKey(conn)
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+
+	merged := []*RyxConn{}
+	for _, key := range keys {
+		versions := mergeVersions{
+			base:   connectionValue(baseConns[key]),
+			ours:   connectionValue(ourConns[key]),
+			theirs: connectionValue(theirConns[key]),
+		}
+		value, ok := versions.resolve()
+		if !ok {
+			conflicts = append(conflicts, &MergeConflict{Kind: ConnectionConflict, Key: key, Description: `the connection was changed differently on each side`})
+		}
+		if value == `` {
+			continue
+		}
+		conn := ourConns[key]
+		if value != versions.ours {
+			conn = theirConns[key]
+		}
+		_, fromOk := mergedNodes[conn.FromId]
+		_, toOk := mergedNodes[conn.ToId]
+		if !fromOk || !toOk {
+			conflicts = append(conflicts, &MergeConflict{Kind: ConnectionConflict, Key: key, Description: `the connection refers to a tool that was removed`})
+			continue
+		}
+		merged = append(merged, conn)
+	}
+	return merged, conflicts
+}
+
+func mergeProperties(base *txml.Node, ours *txml.Node, theirs *txml.Node, conflicts []*MergeConflict) (*txml.Node, []*MergeConflict) {
+	if ours == nil {
+		ours = theirs
+	}
+	if ours == nil {
+		return nil, conflicts
+	}
+	baseChildren := mapPropertyChildren(base)
+	ourChildren := mapPropertyChildren(ours)
+	theirChildren := mapPropertyChildren(theirs)
+
+	keys := []string{}
+	seen := map[string]bool{}
+	for _, properties := range []*txml.Node{ours, theirs} {
+		if properties == nil {
+			continue
+		}
+		for _, key := range propertyKeys(properties) {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+
+	merged := &txml.Node{Name: ours.Name, Attributes: ours.Attributes, Nodes: []*txml.Node{}}
+	for _, key := range keys {
+		versions := mergeVersions{
+			base:   marshalTxml(baseChildren[key]),
+			ours:   marshalTxml(ourChildren[key]),
+			theirs: marshalTxml(theirChildren[key]),
+		}
+		value, ok := versions.resolve()
+		if !ok {
+			conflicts = append(conflicts, &MergeConflict{Kind: PropertyConflict, Key: key, Description: `the property was changed differently on each side`})
+		}
+		if value == `` {
+			continue
+		}
+		child := ourChildren[key]
+		if value != versions.ours {
+			child = theirChildren[key]
+		}
+		merged.Nodes = append(merged.Nodes, child)
+	}
+	return merged, conflicts
+}
+
+func mergeIds(nodeMaps ...map[int]*ryxnode.RyxNode) []int {
+	all := map[int]*ryxnode.RyxNode{}
+	for _, nodes := range nodeMaps {
+		for id, node := range nodes {
+			all[id] = node
+		}
+	}
+	return sortedIds(all)
+}
+
+// nodeContent serializes everything about a tool except its position and the tools inside it.
+func nodeContent(node *ryxnode.RyxNode) string {
+	if node == nil {
+		return ``
+	}
+	clone := *node
+	clone.ChildNodes = nil
+	if node.GuiSettings != nil {
+		gui := *node.GuiSettings
+		gui.Nodes = []*txml.Node{}
+		for _, setting := range node.GuiSettings.Nodes {
+			if setting.Name != `Position` {
+				gui.Nodes = append(gui.Nodes, setting)
+			}
+		}
+		clone.GuiSettings = &gui
+	}
+	data, err := xml.Marshal(&clone)
+	if err != nil {
+		return ``
+	}
+	return string(data)
+}
+
+func nodePosition(node *ryxnode.RyxNode) string {
+	if node == nil || node.GuiSettings == nil {
+		return ``
+	}
+	return marshalTxml(node.GuiSettings.First(`Position`))
+}
+
+func parentKey(node *ryxnode.RyxNode, parent int) string {
+	if node == nil {
+		return ``
+	}
+	return fmt.Sprintf(`%v`, parent)
+}
+
+func nodeFromContent(content string, position string) (*ryxnode.RyxNode, error) {
+	node, err := ryxnode.GenerateNodeFromXml(content)
+	if err != nil {
+		return nil, err
+	}
+	if position == `` {
+		return node, nil
+	}
+	positionNode, err := txml.Parse(position)
+	if err != nil {
+		return nil, err
+	}
+	node.GuiSettings.Nodes = append([]*txml.Node{positionNode}, node.GuiSettings.Nodes...)
+	return node, nil
+}
+
+func rootOrder(doc *RyxDoc) []int {
+	ids := []int{}
+	for _, node := range doc.Nodes { // It is ok to use RyxDoc.Nodes here
+		if id, err := node.ReadId(); err == nil {
+			ids = append(ids, id)
+		}
+		for _, child := range node.ReadChildren() {
+			if id, err := child.ReadId(); err == nil {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
+
+func describeToolConflict(content mergeVersions) string {
+	switch {
+	case content.ours == ``:
+		return `the tool was removed in ours but changed in theirs`
+	case content.theirs == ``:
+		return `the tool was changed in ours but removed in theirs`
+	case content.base == ``:
+		return `the tool was added differently on each side`
+	default:
+		return `the tool was changed differently on each side`
+	}
+}
+
+func mapConnections(conns []*RyxConn) map[string]*RyxConn {
+	mapped := map[string]*RyxConn{}
+	for _, conn := range conns {
+		mapped[connectionKey(conn)] = conn
+	}
+	return mapped
+}
+
+func connectionKey(conn *RyxConn) string {
+	return fmt.Sprintf(`%v.%v -> %v.%v`, conn.FromId, conn.FromAnchor, conn.ToId, conn.ToAnchor)
+}
+
+func connectionValue(conn *RyxConn) string {
+	if conn == nil {
+		return ``
+	}
+	return fmt.Sprintf(`%v|%v`, conn.Name, conn.Wireless)
+}
+
+// mapPropertyChildren keys each child of the Properties element by its name.  Repeated names are numbered so they
+// stay distinct.
+func mapPropertyChildren(properties *txml.Node) map[string]*txml.Node {
+	children := map[string]*txml.Node{}
+	if properties == nil {
+		return children
+	}
+	keys := propertyKeys(properties)
+	for index, child := range properties.Nodes {
+		children[keys[index]] = child
+	}
+	return children
+}
+
+func propertyKeys(properties *txml.Node) []string {
+	keys := []string{}
+	counts := map[string]int{}
+	for _, child := range properties.Nodes {
+		key := child.Name
+		if counts[child.Name] > 0 {
+			key = fmt.Sprintf(`%v[%v]`, child.Name, counts[child.Name])
+		}
+		counts[child.Name]++
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func marshalTxml(node *txml.Node) string {
+	if node == nil || node.IsNil() {
+		return ``
+	}
+	data, err := xml.Marshal(node)
+	if err != nil {
+		return ``
+	}
+	return string(data)
+}
+
+func firstNonBlank(values ...string) string {
+	for _, value := range values {
+		if value != `` {
+			return value
+		}
+	}
+	return ``
+}
@@ -0,0 +1,73 @@
+package ryxdoc
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"github.com/tlarsen7572/Golang-Public/ryx/ryxnode"
+	"github.com/tlarsen7572/Golang-Public/txml"
+	"strings"
+)
+
+// NormalizedHash hashes the document's content while ignoring MetaInfo, tool positions and XML formatting.  Two
+// documents with the same hash behave the same way, even if Designer has saved them differently.
+func (ryxDoc *RyxDoc) NormalizedHash() (string, error) {
+	data, err := xml.Marshal(ryxDoc)
+	if err != nil {
+		return ``, err
+	}
+	normalized, err := ReadBytes(data)
+	if err != nil {
+		return ``, err
+	}
+	for _, node := range normalized.Nodes { // It is ok to use RyxDoc.Nodes here
+		normalizeNode(node)
+		for _, child := range node.ReadChildren() {
+			normalizeNode(child)
+		}
+	}
+	if normalized.Properties != nil {
+		normalized.Properties.RemoveAll(`MetaInfo`)
+		normalized.Properties.SortAttributes()
+	}
+	data, err = xml.Marshal(normalized)
+	if err != nil {
+		return ``, err
+	}
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+func normalizeNode(node *ryxnode.RyxNode) {
+	if node.GuiSettings != nil {
+		node.GuiSettings.RemoveAll(`Position`)
+		node.GuiSettings.SortAttributes()
+	}
+	if node.EngineSettings != nil {
+		node.EngineSettings.SortAttributes()
+	}
+	if node.Properties != nil {
+		node.Properties.MetaInfo = nil
+		if node.Properties.Annotation != nil {
+			node.Properties.Annotation.SortAttributes()
+		}
+		node.Properties.Configuration.InnerXml = normalizeInnerXml(`Configuration`, node.Properties.Configuration.InnerXml)
+	}
+}
+
+// normalizeInnerXml re-serializes a fragment of XML so that whitespace between elements and attribute order no
+// longer matter.  Fragments that cannot be parsed are returned trimmed but otherwise unchanged.
+func normalizeInnerXml(wrapper string, innerXml string) string {
+	parsed, err := txml.Parse(`<` + wrapper + `>` + innerXml + `</` + wrapper + `>`)
+	if err != nil {
+		return strings.TrimSpace(innerXml)
+	}
+	parsed.SortAttributes()
+	data, err := xml.Marshal(parsed)
+	if err != nil {
+		return strings.TrimSpace(innerXml)
+	}
+	normalized := string(data)
+	normalized = strings.TrimPrefix(normalized, `<`+wrapper+`>`)
+	return strings.TrimSuffix(normalized, `</`+wrapper+`>`)
+}
@@ -0,0 +1,101 @@
+package ryxdoc
+
+import (
+	"errors"
+	"fmt"
+	"github.com/tlarsen7572/Golang-Public/ryx/ryxnode"
+	"github.com/tlarsen7572/Golang-Public/ryx/ryxnode/toolconfig"
+)
+
+const uniquePlugin = `AlteryxBasePluginsGui.Unique.Unique`
+
+var multiOutputPlugins = []string{toolconfig.FilterPlugin, toolconfig.JoinPlugin, uniquePlugin}
+
+// RemoveToolsAndReconnect removes tools that pass data straight through, such as Select and Browse tools, without
+// breaking the data flow.  The connection into each removed tool is wired directly to every tool the removed tool
+// fed.  Tools with more than one incoming connection, or with outgoing connections from more than one anchor, cannot
+// be removed this way.  Neither can containers, whose tools would be removed with them, or tools such as Filters and
+// Joins that split their data across several output anchors, even when only one of the anchors is connected.  Any
+// connections left pointing at tools that no longer exist are also removed.
+func (ryxDoc *RyxDoc) RemoveToolsAndReconnect(toolIds ...int) error {
+	nodes := ryxDoc.ReadMappedNodes()
+	outputConns, inputConns := readIoConns(ryxDoc)
+	for _, id := range toolIds {
+		node, ok := nodes[id]
+		if !ok {
+			return errors.New(fmt.Sprintf(`tool %v does not exist`, id))
+		}
+		if node.ReadCategory() == ryxnode.Container {
+			return errors.New(fmt.Sprintf(`tool %v is a container and cannot be removed without removing its tools`, id))
+		}
+		for _, plugin := range multiOutputPlugins {
+			if node.ReadPlugin() == plugin {
+				return errors.New(fmt.Sprintf(`tool %v has more than one output anchor`, id))
+			}
+		}
+		if len(inputConns[id]) > 1 {
+			return errors.New(fmt.Sprintf(`tool %v has more than one incoming connection`, id))
+		}
+		if len(inputConns[id]) == 0 && len(outputConns[id]) > 0 {
+			return errors.New(fmt.Sprintf(`tool %v has outgoing connections but no incoming connection`, id))
+		}
+		for _, conn := range outputConns[id] {
+			if conn.FromAnchor != outputConns[id][0].FromAnchor {
+				return errors.New(fmt.Sprintf(`tool %v has outgoing connections from more than one anchor`, id))
+			}
+		}
+	}
+
+	for _, id := range toolIds {
+		ryxDoc.bypassTool(id)
+	}
+	ryxDoc.RemoveNodes(toolIds...)
+	ryxDoc.removeDanglingConnections()
+	return nil
+}
+
+func (ryxDoc *RyxDoc) bypassTool(toolId int) {
+	var incoming *RyxConn
+	var outgoing []*RyxConn
+	var keep []*RyxConn
+	for _, conn := range ryxDoc.Connections {
+		switch {
+		case conn.ToId == toolId:
+			incoming = conn
+		case conn.FromId == toolId:
+			outgoing = append(outgoing, conn)
+		default:
+			keep = append(keep, conn)
+		}
+	}
+	ryxDoc.Connections = keep
+	if incoming == nil {
+		return
+	}
+	for _, conn := range outgoing {
+		bypass := &RyxConn{
+			Name:       conn.Name,
+			FromId:     incoming.FromId,
+			FromAnchor: incoming.FromAnchor,
+			ToId:       conn.ToId,
+			ToAnchor:   conn.ToAnchor,
+			Wireless:   incoming.Wireless || conn.Wireless,
+		}
+		if !connectionsContain(ryxDoc.Connections, bypass) {
+			ryxDoc.AddConnection(bypass)
+		}
+	}
+}
+
+func (ryxDoc *RyxDoc) removeDanglingConnections() {
+	nodes := ryxDoc.ReadMappedNodes()
+	var keep []*RyxConn
+	for _, conn := range ryxDoc.Connections {
+		_, fromOk := nodes[conn.FromId]
+		_, toOk := nodes[conn.ToId]
+		if fromOk && toOk {
+			keep = append(keep, conn)
+		}
+	}
+	ryxDoc.Connections = keep
+}
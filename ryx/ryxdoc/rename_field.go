@@ -0,0 +1,269 @@
+package ryxdoc
+
+import (
+	"errors"
+	"fmt"
+	"github.com/tlarsen7572/Golang-Public/ryx/ryxnode"
+	"github.com/tlarsen7572/Golang-Public/ryx/ryxnode/toolconfig"
+	"regexp"
+	"strings"
+)
+
+type FieldIssue struct {
+	ToolId  int
+	Problem string
+}
+
+const browsePlugin = `AlteryxBasePluginsGui.BrowseV2.BrowseV2`
+const unionPlugin = `AlteryxBasePluginsGui.Union.Union`
+
+// RenameField renames a field created by a tool and fixes the references to it in the tools downstream.  The tool
+// must be a Formula, Select, Join, Summarize or Text Input that outputs the field.  Downstream Formula, Filter,
+// Select, Join and Summarize tools are updated, including [Field] references in their expressions.  The rename
+// stops following a branch once the field is dropped or renamed to something else.  Downstream tools that cannot be
+// updated safely, such as unions, macros, macro outputs, output files and unsupported tools, are reported so they
+// can be checked by hand.  Field names are matched without regard to case, as they are in Designer.
+func (ryxDoc *RyxDoc) RenameField(toolId int, oldName string, newName string) ([]*FieldIssue, error) {
+	if oldName == `` || newName == `` {
+		return nil, errors.New(`the old and new field names cannot be blank`)
+	}
+	nodes := ryxDoc.ReadMappedNodes()
+	node, ok := nodes[toolId]
+	if !ok {
+		return nil, errors.New(fmt.Sprintf(`tool %v does not exist`, toolId))
+	}
+	if !renameFieldSource(node, oldName, newName) {
+		return nil, errors.New(fmt.Sprintf(`tool %v does not output a field named '%v' that can be renamed`, toolId, oldName))
+	}
+
+	issues := []*FieldIssue{}
+	outputConns, _ := readIoConns(ryxDoc)
+	queue := append([]*RyxConn{}, outputConns[toolId]...)
+	visited := map[string]bool{}
+	for len(queue) > 0 {
+		conn := queue[0]
+		queue = queue[1:]
+		key := fmt.Sprintf(`%v|%v`, conn.ToId, conn.ToAnchor)
+		next, ok := nodes[conn.ToId]
+		if visited[key] || !ok {
+			continue
+		}
+		visited[key] = true
+		passes, problem := renameFieldReferences(next, conn.ToAnchor, oldName, newName)
+		if problem != `` {
+			issues = append(issues, &FieldIssue{ToolId: conn.ToId, Problem: problem})
+		}
+		if passes {
+			queue = append(queue, outputConns[conn.ToId]...)
+		}
+	}
+	return issues, nil
+}
+
+// renameFieldSource renames the field where the tool creates it.  It returns false if the tool does not output the
+// field.
+func renameFieldSource(node *ryxnode.RyxNode, oldName string, newName string) bool {
+	tool, err := toolconfig.Read(node)
+	if err != nil {
+		return false
+	}
+	switch config := tool.(type) {
+	case *toolconfig.Formula:
+		fields := config.Fields()
+		found := false
+		for index := range fields {
+			if strings.EqualFold(fields[index].Field, oldName) {
+				fields[index].Field = newName
+				found = true
+			}
+			if found {
+				fields[index].Expression = replaceFieldReference(fields[index].Expression, oldName, newName)
+			}
+		}
+		if found {
+			config.SetFields(fields)
+		}
+		return found
+	case *toolconfig.Select:
+		fields, found := renameSelectOutput(config.Fields(), oldName, newName)
+		if found {
+			config.SetFields(fields)
+		}
+		return found
+	case *toolconfig.Join:
+		fields, found := renameSelectOutput(config.SelectFields(), oldName, newName)
+		if found {
+			config.SetSelectFields(fields)
+		}
+		return found
+	case *toolconfig.Summarize:
+		fields := config.Fields()
+		found := false
+		for index, field := range fields {
+			if strings.EqualFold(firstNonBlank(field.Rename, field.Field), oldName) {
+				fields[index].Rename = newName
+				found = true
+			}
+		}
+		if found {
+			config.SetFields(fields)
+		}
+		return found
+	case *toolconfig.TextInput:
+		fields := config.Fields()
+		found := false
+		for index, field := range fields {
+			if strings.EqualFold(field, oldName) {
+				fields[index] = newName
+				found = true
+			}
+		}
+		if found {
+			_ = config.SetData(fields, config.Rows())
+		}
+		return found
+	default:
+		return false
+	}
+}
+
+// renameFieldReferences updates a downstream tool that receives the field on the given anchor.  It returns whether
+// the field, under its new name, continues out of the tool, and a problem if the tool could not be updated safely.
+func renameFieldReferences(node *ryxnode.RyxNode, anchor string, oldName string, newName string) (bool, string) {
+	plugin := node.ReadPlugin()
+	if plugin == browsePlugin {
+		return true, ``
+	}
+	if plugin == unionPlugin {
+		return true, fmt.Sprintf(`the union may output '%v' and '%v' as separate fields, or map '%v' manually, and was not updated`, oldName, newName, oldName)
+	}
+	tool, err := toolconfig.Read(node)
+	if err != nil {
+		if node.ReadCategory() == ryxnode.Macro {
+			return true, fmt.Sprintf(`the macro '%v' may use '%v' and was not updated`, node.ReadMacro().StoredPath, oldName)
+		}
+		return true, fmt.Sprintf(`%v tools are not supported, so references to '%v' were not updated`, plugin, oldName)
+	}
+
+	switch config := tool.(type) {
+	case *toolconfig.Formula:
+		fields := config.Fields()
+		for index := range fields {
+			if strings.EqualFold(fields[index].Field, oldName) {
+				fields[index].Field = newName
+			}
+			fields[index].Expression = replaceFieldReference(fields[index].Expression, oldName, newName)
+		}
+		config.SetFields(fields)
+		return true, ``
+	case *toolconfig.Filter:
+		if strings.EqualFold(config.SimpleField(), oldName) {
+			config.SetSimpleField(newName)
+		}
+		expression := config.Expression()
+		if updated := replaceFieldReference(expression, oldName, newName); updated != expression {
+			mode := config.Mode()
+			config.SetExpression(updated)
+			if mode != `` && mode != `Custom` {
+				config.SetMode(mode)
+			}
+		}
+		return true, ``
+	case *toolconfig.Select:
+		fields, passes := renameSelectInput(config.Fields(), ``, oldName, newName)
+		config.SetFields(fields)
+		return passes, ``
+	case *toolconfig.Join:
+		left, right := config.LeftFields(), config.RightFields()
+		joinFields := left
+		if anchor == `Right` {
+			joinFields = right
+		}
+		for index, field := range joinFields {
+			if strings.EqualFold(field, oldName) {
+				joinFields[index] = newName
+			}
+		}
+		_ = config.SetJoinFields(left, right)
+		fields, passes := renameSelectInput(config.SelectFields(), anchor+`_`, oldName, newName)
+		config.SetSelectFields(fields)
+		return passes, ``
+	case *toolconfig.Summarize:
+		fields := config.Fields()
+		passes := false
+		for index, field := range fields {
+			if !strings.EqualFold(field.Field, oldName) {
+				continue
+			}
+			fields[index].Field = newName
+			if field.Rename == `` || strings.EqualFold(field.Rename, oldName) {
+				fields[index].Rename = newName
+				passes = true
+			}
+		}
+		config.SetFields(fields)
+		return passes, ``
+	case *toolconfig.MacroOutput:
+		return false, fmt.Sprintf(`the macro output passes '%v' to the workflows using this macro, which were not updated`, newName)
+	case *toolconfig.OutputData:
+		return false, fmt.Sprintf(`the output writes '%v' instead of '%v', so anything reading the output was not updated`, newName, oldName)
+	default:
+		return false, ``
+	}
+}
+
+// renameSelectOutput renames the field a Select outputs, leaving the incoming field alone.
+func renameSelectOutput(fields []toolconfig.SelectField, oldName string, newName string) ([]toolconfig.SelectField, bool) {
+	found := false
+	for index, field := range fields {
+		if field.Selected && strings.EqualFold(firstNonBlank(field.Rename, field.Field), oldName) {
+			fields[index].Rename = newName
+			found = true
+		}
+	}
+	return fields, found
+}
+
+// renameSelectInput renames an incoming field in a Select.  Joins prefix fields from one side with the anchor name
+// when both sides share a field name, so the prefixed name is also renamed for fields from that input.  It returns
+// whether the field leaves the Select under its new name.
+func renameSelectInput(fields []toolconfig.SelectField, prefix string, oldName string, newName string) ([]toolconfig.SelectField, bool) {
+	passes := false
+	listed := false
+	unknownSelected := false
+	for index, field := range fields {
+		if field.Field == `*Unknown` {
+			unknownSelected = field.Selected
+			continue
+		}
+		if prefix != `` && field.Input != `` && field.Input != prefix {
+			continue
+		}
+		names := map[string]string{oldName: newName}
+		if prefix != `` {
+			names[prefix+oldName] = prefix + newName
+		}
+		for old, renamed := range names {
+			if !strings.EqualFold(field.Field, old) {
+				continue
+			}
+			listed = true
+			fields[index].Field = renamed
+			if field.Rename == `` || strings.EqualFold(field.Rename, old) {
+				if field.Rename != `` {
+					fields[index].Rename = renamed
+				}
+				passes = passes || field.Selected
+			}
+		}
+	}
+	if !listed {
+		passes = unknownSelected
+	}
+	return fields, passes
+}
+
+func replaceFieldReference(expression string, oldName string, newName string) string {
+	reference := regexp.MustCompile(`(?i)\[` + regexp.QuoteMeta(oldName) + `\]`)
+	return reference.ReplaceAllLiteralString(expression, `[`+newName+`]`)
+}
@@ -0,0 +1,109 @@
+package ryxdoc
+
+import (
+	"errors"
+	"fmt"
+	"github.com/tlarsen7572/Golang-Public/ryx/ryxnode"
+	"github.com/tlarsen7572/Golang-Public/txml"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+type RenumberOrder string
+
+const (
+	RenumberByFlow     RenumberOrder = `Flow`
+	RenumberByPosition RenumberOrder = `Position`
+)
+
+var actionDestination = regexp.MustCompile(`^(\d+)(/.*)?$`)
+
+// RenumberTools gives the tools consecutive IDs starting at 1.  RenumberByFlow numbers the tools in topological
+// order and RenumberByPosition numbers them left to right, then top to bottom.  Connections are updated, as are the
+// tool IDs referenced by interface questions, actions and output tools in the document's RuntimeProperties.  Question
+// names are left alone because batch macro control parameters refer to them.  The map of old IDs to new IDs is
+// returned.
+func (ryxDoc *RyxDoc) RenumberTools(order RenumberOrder) (map[int]int, error) {
+	var ids []int
+	var err error
+	switch order {
+	case RenumberByFlow:
+		ids, err = ryxDoc.TopologicalOrder()
+		if err != nil {
+			return nil, err
+		}
+	case RenumberByPosition:
+		ids = ryxDoc.positionalOrder()
+	default:
+		return nil, errors.New(fmt.Sprintf(`'%v' is not a valid order; use %v or %v`, order, RenumberByFlow, RenumberByPosition))
+	}
+
+	nodes := ryxDoc.ReadMappedNodes()
+	newIds := map[int]int{}
+	for index, id := range ids {
+		newIds[id] = index + 1
+	}
+	for id, node := range nodes {
+		node.ToolId = strconv.Itoa(newIds[id])
+	}
+	for _, conn := range ryxDoc.Connections {
+		if newId, ok := newIds[conn.FromId]; ok {
+			conn.FromId = newId
+		}
+		if newId, ok := newIds[conn.ToId]; ok {
+			conn.ToId = newId
+		}
+	}
+	if ryxDoc.Properties != nil {
+		renumberReferences(ryxDoc.Properties.First(`RuntimeProperties`), newIds)
+	}
+	ryxDoc.nextId = len(ids) + 1
+	return newIds, nil
+}
+
+func (ryxDoc *RyxDoc) positionalOrder() []int {
+	nodes := ryxDoc.ReadMappedNodes()
+	ids := sortedIds(nodes)
+	positions := map[int]ryxnode.Position{}
+	for _, id := range ids {
+		positions[id], _ = nodes[id].ReadPosition()
+	}
+	sort.SliceStable(ids, func(i, j int) bool {
+		left, right := positions[ids[i]], positions[ids[j]]
+		if left.X != right.X {
+			return left.X < right.X
+		}
+		return left.Y < right.Y
+	})
+	return ids
+}
+
+// renumberReferences updates the tool IDs referenced by questions, actions and the output tools an app opens.  Any
+// ToolId attribute is remapped, as are the value of ToolId elements and the tool ID at the start of each action's
+// Destination.
+func renumberReferences(node *txml.Node, newIds map[int]int) {
+	for _, child := range node.Nodes {
+		renumberAttribute(child, `ToolId`, newIds)
+		switch child.Name {
+		case `ToolId`:
+			renumberAttribute(child, `value`, newIds)
+		case `Destination`:
+			if match := actionDestination.FindStringSubmatch(child.InnerText); match != nil {
+				id, _ := strconv.Atoi(match[1])
+				if newId, ok := newIds[id]; ok {
+					child.InnerText = strconv.Itoa(newId) + match[2]
+				}
+			}
+		}
+		renumberReferences(child, newIds)
+	}
+}
+
+func renumberAttribute(node *txml.Node, attribute string, newIds map[int]int) {
+	if id, err := strconv.Atoi(node.Attributes[attribute]); err == nil {
+		if newId, ok := newIds[id]; ok {
+			node.Attributes[attribute] = strconv.Itoa(newId)
+		}
+	}
+}
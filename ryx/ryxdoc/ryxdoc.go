@@ -17,6 +17,7 @@ type RyxDoc struct {
 	Connections []*RyxConn `xml:"Connections>Connection"`
 	Properties  *txml.Node `xml:"Properties"`
 	nextId      int
+	raw         *rawDocument
 }
 
 type RyxConn struct {
@@ -160,13 +161,20 @@ func (ryxDoc *RyxDoc) MakeMacrosRelative(macroAbsPaths []string, relativeTo stri
 }
 
 func (ryxDoc *RyxDoc) Save(path string) error {
-	data, err := xml.MarshalIndent(ryxDoc, ``, `  `)
+	data, err := ryxDoc.ToBytes()
 	if err != nil {
 		return err
 	}
 	return ioutil.WriteFile(path, data, 0644)
 }
 
+func (ryxDoc *RyxDoc) ToBytes() ([]byte, error) {
+	if ryxDoc.raw != nil {
+		return ryxDoc.raw.render(ryxDoc)
+	}
+	return xml.MarshalIndent(ryxDoc, ``, `  `)
+}
+
 func addNodeToMap(node *ryxnode.RyxNode, nodes map[int]*ryxnode.RyxNode) {
 	id, err := node.ReadId()
 	if err != nil {
@@ -1,11 +1,20 @@
 package ryxdoc_test
 
 import (
+	"bytes"
 	"encoding/xml"
+	"fmt"
 	"github.com/tlarsen7572/Golang-Public/ryx/ryxdoc"
+	"github.com/tlarsen7572/Golang-Public/ryx/ryxnode"
 	r "github.com/tlarsen7572/Golang-Public/ryx/testdocbuilder"
+	"github.com/tlarsen7572/Golang-Public/txml"
+	"io/ioutil"
+	"math"
 	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
 	"strings"
 	"testing"
 )
@@ -248,6 +257,332 @@ func TestExtractMacroMultipleConnections(t *testing.T) {
 	}
 }
 
+func TestExtractMacroDeduplicatesInputs(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	newMacroPath, _ := generateAbsPath(`..`, `testdocs`, `new.yxmc`)
+	doc, _ := ryxdoc.ReadFile(yxmd)
+	for _, conn := range doc.Connections {
+		if conn.FromId == 13 && conn.FromAnchor == `False` {
+			conn.FromAnchor = `True`
+		}
+	}
+	err := doc.ExtractMacro(newMacroPath, ``, 14, 15, 16)
+	if err != nil {
+		t.Fatalf(`expected no error but got: %v`, err.Error())
+	}
+	newMacro, _ := ryxdoc.ReadFile(newMacroPath)
+	if count := len(newMacro.ReadMappedNodes()); count != 6 {
+		t.Fatalf(`expected 6 Nodes in new macro but got %v`, count)
+	}
+	if !listHasConnection(newMacro.Connections, 18, `Output`, 14, `Input`) {
+		t.Fatalf(`new macro is missing connection from the macro input to 14`)
+	}
+	if !listHasConnection(newMacro.Connections, 18, `Output`, 15, `Input`) {
+		t.Fatalf(`new macro is missing connection from the macro input to 15`)
+	}
+	toMacro := 0
+	for _, conn := range doc.Connections {
+		if conn.ToId == 24 {
+			toMacro++
+		}
+	}
+	if toMacro != 1 {
+		t.Fatalf(`expected 1 connection into the macro but got %v`, toMacro)
+	}
+}
+
+func TestExtractMacroNamedAnchors(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	newMacroPath, _ := generateAbsPath(`..`, `testdocs`, `new.yxmc`)
+	doc, _ := ryxdoc.ReadFile(yxmd)
+	names := map[string]string{
+		ryxdoc.AnchorKey(13, `True`):   `Passed`,
+		ryxdoc.AnchorKey(13, `False`):  `Failed`,
+		ryxdoc.AnchorKey(16, `Output`): `Combined`,
+	}
+	err := doc.ExtractMacroNamed(newMacroPath, ``, names, 14, 15, 16)
+	if err != nil {
+		t.Fatalf(`expected no error but got: %v`, err.Error())
+	}
+	if !listHasConnection(doc.Connections, 13, `True`, 24, `Passed`) {
+		t.Fatalf(`original doc is missing connection to the Passed input`)
+	}
+	if !listHasConnection(doc.Connections, 13, `False`, 24, `Failed`) {
+		t.Fatalf(`original doc is missing connection to the Failed input`)
+	}
+	if !listHasConnection(doc.Connections, 24, `Combined`, 17, `Input`) {
+		t.Fatalf(`original doc is missing connection from the Combined output`)
+	}
+	newMacro, _ := ryxdoc.ReadFile(newMacroPath)
+	if config := newMacro.ReadMappedNodes()[20].Properties.Configuration.InnerXml; !strings.Contains(config, `<Name>Combined</Name>`) {
+		t.Fatalf(`expected the macro output to be named Combined but got %v`, config)
+	}
+}
+
+func TestExtractMacroDuplicateAnchorNames(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	newMacroPath, _ := generateAbsPath(`..`, `testdocs`, `new.yxmc`)
+	doc, _ := ryxdoc.ReadFile(yxmd)
+	names := map[string]string{
+		ryxdoc.AnchorKey(13, `True`):  `Same`,
+		ryxdoc.AnchorKey(13, `False`): `Same`,
+	}
+	err := doc.ExtractMacroNamed(newMacroPath, ``, names, 14, 15, 16)
+	if err == nil {
+		t.Fatalf(`expected an error but got none`)
+	}
+	if count := len(doc.ReadMappedNodes()); count != 16 {
+		t.Fatalf(`expected the doc to be unchanged but it has %v nodes`, count)
+	}
+}
+
+func TestExtractMacroPreservesWireless(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	newMacroPath, _ := generateAbsPath(`..`, `testdocs`, `new.yxmc`)
+	doc, _ := ryxdoc.ReadFile(yxmd)
+	err := doc.ExtractMacro(newMacroPath, ``, 6)
+	if err != nil {
+		t.Fatalf(`expected no error but got: %v`, err.Error())
+	}
+	for _, conn := range doc.Connections {
+		if conn.FromId == 1 && !conn.Wireless {
+			t.Fatalf(`expected the connection from 1 to remain wireless but it did not`)
+		}
+		if conn.FromId == 4 && conn.Wireless {
+			t.Fatalf(`expected the connection from 4 to remain wired but it was wireless`)
+		}
+	}
+	newMacro, _ := ryxdoc.ReadFile(newMacroPath)
+	for _, conn := range newMacro.Connections {
+		if conn.ToAnchor == `Left` && !conn.Wireless {
+			t.Fatalf(`expected the macro input connection to Left to be wireless but it was not`)
+		}
+	}
+}
+
+func TestExtractMacroWithContainer(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	newMacroPath, _ := generateAbsPath(`..`, `testdocs`, `new.yxmc`)
+	doc, _ := ryxdoc.ReadFile(yxmd)
+	err := doc.ExtractMacro(newMacroPath, ``, 20)
+	if err != nil {
+		t.Fatalf(`expected no error but got: %v`, err.Error())
+	}
+	nodes := doc.ReadMappedNodes()
+	for _, id := range []int{20, 21, 22, 23} {
+		if _, ok := nodes[id]; ok {
+			t.Fatalf(`expected tool %v to be removed from the doc but it was not`, id)
+		}
+	}
+	newMacro, _ := ryxdoc.ReadFile(newMacroPath)
+	macroNodes := newMacro.ReadMappedNodes()
+	for _, id := range []int{20, 21, 22, 23} {
+		if _, ok := macroNodes[id]; !ok {
+			t.Fatalf(`expected tool %v in the new macro but it was missing`, id)
+		}
+	}
+}
+
+func TestAutoLayout(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	doc, _ := ryxdoc.ReadFile(yxmd)
+	doc.AutoLayout()
+	nodes := doc.ReadMappedNodes()
+	for _, conn := range doc.Connections {
+		from, _ := nodes[conn.FromId].ReadPosition()
+		to, _ := nodes[conn.ToId].ReadPosition()
+		if from.X >= to.X {
+			t.Fatalf(`expected tool %v to be left of tool %v but got %v and %v`, conn.FromId, conn.ToId, from.X, to.X)
+		}
+	}
+	container, _ := nodes[20].ReadPosition()
+	for _, id := range []int{21, 22, 23} {
+		child, _ := nodes[id].ReadPosition()
+		if child.X < container.X || child.Y < container.Y || child.X+child.Width > container.X+container.Width || child.Y+child.Height > container.Y+container.Height {
+			t.Fatalf(`expected tool %v to be inside container 20 but it was not`, id)
+		}
+	}
+	inner, _ := nodes[22].ReadPosition()
+	if child, _ := nodes[23].ReadPosition(); child.X < inner.X || child.Y < inner.Y {
+		t.Fatalf(`expected tool 23 to be inside container 22 but it was not`)
+	}
+}
+
+func TestDiffIdenticalDocs(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	from, _ := ryxdoc.ReadFile(yxmd)
+	to, _ := ryxdoc.ReadFile(yxmd)
+	diff := ryxdoc.Diff(from, to)
+	if !diff.IsEmpty() {
+		t.Fatalf(`expected no differences but got: %v`, diff.String())
+	}
+}
+
+func TestDiffIgnoresAttributeOrder(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	content, _ := ioutil.ReadFile(yxmd)
+	reordered := strings.Replace(string(content), `<SelectField field="*Unknown" selected="True" />`, `<SelectField selected="True" field="*Unknown" />`, 1)
+	original := strings.Replace(string(content), `<Memory default="True" />`, `<Memory default="True" limit="1024" />`, 1)
+	reordered = strings.Replace(reordered, `<Memory default="True" />`, `<Memory limit="1024" default="True" />`, 1)
+	if reordered == original {
+		t.Fatalf(`expected the test documents to differ in attribute order`)
+	}
+	from, _ := ryxdoc.ReadBytes([]byte(original))
+	to, _ := ryxdoc.ReadBytes([]byte(reordered))
+	diff := ryxdoc.Diff(from, to)
+	if !diff.IsEmpty() {
+		t.Fatalf(`expected no differences but got: %v`, diff.String())
+	}
+}
+
+func TestDiff(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	from, _ := ryxdoc.ReadFile(yxmd)
+	to, _ := ryxdoc.ReadFile(yxmd)
+	to.RemoveNodes(19)
+	to.RemoveConnectionsBetween(18, 19)
+	to.ReadMappedNodes()[1].SetPosition(10, 20)
+	filter := to.ReadMappedNodes()[13]
+	filter.Properties.Configuration.InnerXml = strings.Replace(filter.Properties.Configuration.InnerXml, `[VALSIGN] = "I"`, `[VALSIGN] = "D"`, 1)
+	to.AddConnection(&ryxdoc.RyxConn{FromId: 4, FromAnchor: `Output`, ToId: 13, ToAnchor: `Input`})
+	to.Properties.First(`GlobalRecordLimit`).Attributes[`value`] = `100`
+
+	diff := ryxdoc.Diff(from, to)
+	if count := len(diff.RemovedTools); count != 1 || diff.RemovedTools[0].ToolId != 19 {
+		t.Fatalf(`expected tool 19 to be removed but got %v removed tools`, count)
+	}
+	if count := len(diff.AddedTools); count != 0 {
+		t.Fatalf(`expected no added tools but got %v`, count)
+	}
+	if count := len(diff.MovedTools); count != 1 || diff.MovedTools[0].ToolId != 1 {
+		t.Fatalf(`expected tool 1 to be moved but got %v moved tools`, count)
+	}
+	if count := len(diff.ChangedTools); count != 1 || diff.ChangedTools[0].ToolId != 13 {
+		t.Fatalf(`expected tool 13 to be changed but got %v changed tools`, count)
+	}
+	if !hasDiffLine(diff.ChangedTools[0].Configuration, `-`, `[VALSIGN] = "I"`) || !hasDiffLine(diff.ChangedTools[0].Configuration, `+`, `[VALSIGN] = "D"`) {
+		t.Fatalf(`expected the filter expression change in the diff but got: %v`, diff.String())
+	}
+	if !listHasConnection(diff.AddedConnections, 4, `Output`, 13, `Input`) {
+		t.Fatalf(`expected the connection from 4 to 13 to be added`)
+	}
+	if !listHasConnection(diff.RemovedConnections, 18, `Output7`, 19, `Input`) {
+		t.Fatalf(`expected the connection from 18 to 19 to be removed`)
+	}
+	if !hasDiffLine(diff.PropertyChanges, `+`, `<GlobalRecordLimit value="100">`) {
+		t.Fatalf(`expected the record limit change in the diff but got: %v`, diff.String())
+	}
+	if text := diff.String(); !strings.Contains(text, `Removed tool 19`) || !strings.Contains(text, `Changed tool 13`) {
+		t.Fatalf(`expected a readable summary but got: %v`, text)
+	}
+}
+
+func TestMergeWithoutChanges(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	base, _ := ryxdoc.ReadFile(yxmd)
+	ours, _ := ryxdoc.ReadFile(yxmd)
+	theirs, _ := ryxdoc.ReadFile(yxmd)
+	merged, conflicts := ryxdoc.Merge(base, ours, theirs)
+	if count := len(conflicts); count != 0 {
+		t.Fatalf(`expected no conflicts but got %v`, count)
+	}
+	if diff := ryxdoc.Diff(base, merged); !diff.IsEmpty() {
+		t.Fatalf(`expected the merged doc to match the base but got: %v`, diff.String())
+	}
+}
+
+func TestMergeNonOverlappingChanges(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	base, _ := ryxdoc.ReadFile(yxmd)
+	ours, _ := ryxdoc.ReadFile(yxmd)
+	theirs, _ := ryxdoc.ReadFile(yxmd)
+	ours.ReadMappedNodes()[13].SetPosition(10, 20)
+	ours.AddConnection(&ryxdoc.RyxConn{FromId: 4, FromAnchor: `Output`, ToId: 13, ToAnchor: `Input`})
+	ours.Properties.First(`GlobalRecordLimit`).Attributes[`value`] = `100`
+	filter := theirs.ReadMappedNodes()[13]
+	filter.Properties.Configuration.InnerXml = strings.Replace(filter.Properties.Configuration.InnerXml, `[VALSIGN] = "I"`, `[VALSIGN] = "D"`, 1)
+	theirs.RemoveNodes(19)
+	theirs.RemoveConnectionsBetween(18, 19)
+
+	merged, conflicts := ryxdoc.Merge(base, ours, theirs)
+	if count := len(conflicts); count != 0 {
+		t.Fatalf(`expected no conflicts but got %v: %v`, count, conflicts[0].Description)
+	}
+	nodes := merged.ReadMappedNodes()
+	if _, ok := nodes[19]; ok {
+		t.Fatalf(`expected tool 19 to be removed`)
+	}
+	if position, _ := nodes[13].ReadPosition(); position.X != 10 || position.Y != 20 {
+		t.Fatalf(`expected tool 13 at 10,20 but got %v,%v`, position.X, position.Y)
+	}
+	if !strings.Contains(nodes[13].Properties.Configuration.InnerXml, `[VALSIGN] = "D"`) {
+		t.Fatalf(`expected the filter expression from theirs but it was not merged`)
+	}
+	if !listHasConnection(merged.Connections, 4, `Output`, 13, `Input`) {
+		t.Fatalf(`expected the connection from ours to be merged`)
+	}
+	if listHasConnection(merged.Connections, 18, `Output7`, 19, `Input`) {
+		t.Fatalf(`expected the connection removed in theirs to be removed`)
+	}
+	if limit := merged.Properties.First(`GlobalRecordLimit`).Attributes[`value`]; limit != `100` {
+		t.Fatalf(`expected a record limit of 100 but got %v`, limit)
+	}
+	if children := nodes[20].ReadChildren(); len(children) != 3 {
+		t.Fatalf(`expected container 20 to keep 3 tools but got %v`, len(children))
+	}
+}
+
+func TestMergeConflicts(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	base, _ := ryxdoc.ReadFile(yxmd)
+	ours, _ := ryxdoc.ReadFile(yxmd)
+	theirs, _ := ryxdoc.ReadFile(yxmd)
+	ourFilter := ours.ReadMappedNodes()[13]
+	ourFilter.Properties.Configuration.InnerXml = strings.Replace(ourFilter.Properties.Configuration.InnerXml, `[VALSIGN] = "I"`, `[VALSIGN] = "D"`, 1)
+	theirFilter := theirs.ReadMappedNodes()[13]
+	theirFilter.Properties.Configuration.InnerXml = strings.Replace(theirFilter.Properties.Configuration.InnerXml, `[VALSIGN] = "I"`, `[VALSIGN] = "X"`, 1)
+	ours.Properties.First(`GlobalRecordLimit`).Attributes[`value`] = `100`
+	theirs.Properties.First(`GlobalRecordLimit`).Attributes[`value`] = `200`
+
+	merged, conflicts := ryxdoc.Merge(base, ours, theirs)
+	if count := len(conflicts); count != 2 {
+		t.Fatalf(`expected 2 conflicts but got %v`, count)
+	}
+	if conflicts[0].Kind != ryxdoc.ToolConflict || conflicts[0].ToolId != 13 {
+		t.Fatalf(`expected a conflict on tool 13 but got %v`, conflicts[0])
+	}
+	if conflicts[1].Kind != ryxdoc.PropertyConflict || conflicts[1].Key != `GlobalRecordLimit` {
+		t.Fatalf(`expected a conflict on GlobalRecordLimit but got %v`, conflicts[1])
+	}
+	if !strings.Contains(merged.ReadMappedNodes()[13].Properties.Configuration.InnerXml, `[VALSIGN] = "D"`) {
+		t.Fatalf(`expected the conflicting tool to keep ours`)
+	}
+}
+
 func TestHiddenConnections(t *testing.T) {
 	r.RebuildTestdocs(baseFolder)
 	defer r.RebuildTestdocs(baseFolder)
@@ -275,15 +610,1164 @@ func TestMarshallIndent(t *testing.T) {
 	t.Logf(string(marshalled))
 }
 
-func listHasConnection(conns []*ryxdoc.RyxConn, fromId int, fromAnchor string, toId int, toAnchor string) bool {
-	connFound := false
-	for _, conn := range conns {
-		if conn.FromId == fromId && conn.FromAnchor == fromAnchor && conn.ToId == toId && conn.ToAnchor == toAnchor {
-			connFound = true
-			break
+func TestNormalizedHashIgnoresPositionsAndMetaInfo(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	macroPath := filepath.Join(baseFolder, `MultiInOut.yxmc`)
+	original, _ := ryxdoc.ReadFile(macroPath)
+	moved, _ := ryxdoc.ReadFile(macroPath)
+	moved.ReadMappedNodes()[2].SetPosition(500, 500)
+	moved.Properties.First(`MetaInfo`).First(`Name`).InnerText = `Something else`
+	originalHash, _ := original.NormalizedHash()
+	movedHash, err := moved.NormalizedHash()
+	if err != nil {
+		t.Fatalf(`expected no error but got: %v`, err.Error())
+	}
+	if originalHash != movedHash {
+		t.Fatalf(`expected identical hashes but got '%v' and '%v'`, originalHash, movedHash)
+	}
+
+	moved.RemoveNodes(5)
+	changedHash, _ := moved.NormalizedHash()
+	if changedHash == originalHash {
+		t.Fatalf(`expected different hashes after removing a tool but they were the same`)
+	}
+}
+
+func TestInlineMacroWithPassthroughs(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	doc, _ := ryxdoc.ReadFile(filepath.Join(baseFolder, `MultiInOut.yxmd`))
+	_, err := doc.InlineMacro(6, baseFolder)
+	if err != nil {
+		t.Fatalf(`expected no error but got: %v`, err.Error())
+	}
+	if _, ok := doc.ReadMappedNodes()[6]; ok {
+		t.Fatalf(`expected the macro tool to be removed but it still exists`)
+	}
+	if count := len(doc.ReadMappedNodes()); count != 4 {
+		t.Fatalf(`expected 4 tools but got %v`, count)
+	}
+	if count := len(doc.Connections); count != 2 {
+		t.Fatalf(`expected 2 connections but got %v`, count)
+	}
+	if !listHasConnection(doc.Connections, 1, `Output`, 4, `Input`) {
+		t.Fatalf(`expected a connection from 1 to 4 but it did not exist`)
+	}
+	if !listHasConnection(doc.Connections, 2, `Output`, 5, `Input`) {
+		t.Fatalf(`expected a connection from 2 to 5 but it did not exist`)
+	}
+}
+
+func TestInlineMacro(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	doc, _ := ryxdoc.ReadFile(yxmd)
+	macroPosition, _ := doc.ReadMappedNodes()[12].ReadPosition()
+	dropped, err := doc.InlineMacro(12, baseFolder)
+	if err != nil {
+		t.Fatalf(`expected no error but got: %v`, err.Error())
+	}
+	if len(dropped) != 1 || dropped[0] != 5 {
+		t.Fatalf(`expected interface tool 5 to be reported as dropped but got %v`, dropped)
+	}
+	nodes := doc.ReadMappedNodes()
+	if count := len(nodes); count != 18 {
+		t.Fatalf(`expected 18 tools but got %v`, count)
+	}
+	for _, id := range []int{24, 25, 26} {
+		node, ok := nodes[id]
+		if !ok {
+			t.Fatalf(`expected tool %v to exist but it did not`, id)
+		}
+		if plugin := node.ReadPlugin(); plugin != `AlteryxBasePluginsGui.Formula.Formula` {
+			t.Fatalf(`expected tool %v to be a formula but got '%v'`, id, plugin)
 		}
 	}
-	return connFound
+	if position, _ := nodes[24].ReadPosition(); position.X != macroPosition.X || position.Y != macroPosition.Y {
+		t.Fatalf(`expected the first formula at %v,%v but got %v,%v`, macroPosition.X, macroPosition.Y, position.X, position.Y)
+	}
+	if !listHasConnection(doc.Connections, 6, `Join`, 24, `Input`) {
+		t.Fatalf(`expected a connection from 6 to 24 but it did not exist`)
+	}
+	if !listHasConnection(doc.Connections, 24, `Output`, 25, `Input`) {
+		t.Fatalf(`expected a connection from 24 to 25 but it did not exist`)
+	}
+	if !listHasConnection(doc.Connections, 26, `Output`, 13, `Input`) {
+		t.Fatalf(`expected a connection from 26 to 13 but it did not exist`)
+	}
+	for _, conn := range doc.Connections {
+		if conn.FromId == 12 || conn.ToId == 12 {
+			t.Fatalf(`expected no connections to the macro tool but found one`)
+		}
+	}
+}
+
+func TestInlineNonMacro(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	doc, _ := ryxdoc.ReadFile(yxmd)
+	_, err := doc.InlineMacro(13, baseFolder)
+	if err == nil {
+		t.Fatalf(`expected an error but got none`)
+	}
+}
+
+func TestLosslessRoundTrip(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	paths, _ := filepath.Glob(filepath.Join(baseFolder, `*.yx*`))
+	if len(paths) == 0 {
+		t.Fatalf(`expected test documents but found none`)
+	}
+	for _, path := range paths {
+		original, _ := ioutil.ReadFile(path)
+		doc, err := ryxdoc.ReadFileLossless(path)
+		if err != nil {
+			t.Fatalf(`expected no error reading '%v' but got: %v`, path, err.Error())
+		}
+		saved, err := doc.ToBytes()
+		if err != nil {
+			t.Fatalf(`expected no error saving '%v' but got: %v`, path, err.Error())
+		}
+		if !bytes.Equal(original, saved) {
+			t.Fatalf(`expected '%v' to be saved byte-for-byte but it changed`, path)
+		}
+	}
+}
+
+func TestLosslessSaveOnlyChangesEditedTools(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	original, _ := ioutil.ReadFile(yxmd)
+	doc, _ := ryxdoc.ReadFileLossless(yxmd)
+	doc.ReadMappedNodes()[13].SetPosition(500, 600)
+	saved, err := doc.ToBytes()
+	if err != nil {
+		t.Fatalf(`expected no error but got: %v`, err.Error())
+	}
+	if !bytes.HasPrefix(saved, []byte(`<?xml version="1.0"?>`)) {
+		t.Fatalf(`expected the XML declaration to be kept`)
+	}
+	originalLines := strings.Split(string(original), "\n")
+	savedLines := strings.Split(string(saved), "\n")
+	if len(originalLines) != len(savedLines) {
+		t.Fatalf(`expected %v lines but got %v`, len(originalLines), len(savedLines))
+	}
+	changed := []string{}
+	for index := range originalLines {
+		if originalLines[index] != savedLines[index] {
+			changed = append(changed, savedLines[index])
+		}
+	}
+	if len(changed) != 1 || !strings.Contains(changed[0], `<Position x="500" y="600"`) {
+		t.Fatalf(`expected only the position of tool 13 to change but got %v`, changed)
+	}
+}
+
+func TestLosslessSaveAfterRemovingTools(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	doc, _ := ryxdoc.ReadFileLossless(yxmd)
+	doc.RemoveNodes(19)
+	saved, err := doc.ToBytes()
+	if err != nil {
+		t.Fatalf(`expected no error but got: %v`, err.Error())
+	}
+	reread, err := ryxdoc.ReadBytes(saved)
+	if err != nil {
+		t.Fatalf(`expected no error but got: %v`, err.Error())
+	}
+	if count := len(reread.ReadMappedNodes()); count != 15 {
+		t.Fatalf(`expected 15 nodes but got %v`, count)
+	}
+	if strings.Contains(string(saved), `<Node ToolID="19">`) {
+		t.Fatalf(`expected tool 19 to be removed from the saved document`)
+	}
+}
+
+func TestUpstreamAndDownstream(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	doc, _ := ryxdoc.ReadFile(yxmd)
+	if upstream := doc.Upstream(13); !reflect.DeepEqual(upstream, []int{1, 4, 6, 12}) {
+		t.Fatalf(`expected upstream [1 4 6 12] but got %v`, upstream)
+	}
+	if downstream := doc.Downstream(13); !reflect.DeepEqual(downstream, []int{14, 15, 16, 17, 18, 19}) {
+		t.Fatalf(`expected downstream [14 15 16 17 18 19] but got %v`, downstream)
+	}
+	if upstream := doc.Upstream(1); len(upstream) != 0 {
+		t.Fatalf(`expected nothing upstream of tool 1 but got %v`, upstream)
+	}
+}
+
+func TestTopologicalOrder(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	doc, _ := ryxdoc.ReadFile(yxmd)
+	order, err := doc.TopologicalOrder()
+	if err != nil {
+		t.Fatalf(`expected no error but got: %v`, err.Error())
+	}
+	if len(order) != 16 {
+		t.Fatalf(`expected 16 tools but got %v`, len(order))
+	}
+	positions := map[int]int{}
+	for index, id := range order {
+		positions[id] = index
+	}
+	for _, conn := range doc.Connections {
+		if positions[conn.FromId] > positions[conn.ToId] {
+			t.Fatalf(`expected tool %v before tool %v but got %v`, conn.FromId, conn.ToId, order)
+		}
+	}
+}
+
+func TestTopologicalOrderWithCycle(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	doc, _ := ryxdoc.ReadFile(yxmd)
+	doc.AddConnection(&ryxdoc.RyxConn{FromId: 17, FromAnchor: `Output`, ToId: 6, ToAnchor: `Left`})
+	_, err := doc.TopologicalOrder()
+	if err == nil {
+		t.Fatalf(`expected an error but got none`)
+	}
+}
+
+func TestSourcesAndSinks(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	doc, _ := ryxdoc.ReadFile(yxmd)
+	if sources := doc.Sources(); !reflect.DeepEqual(sources, []int{1, 4, 5}) {
+		t.Fatalf(`expected sources [1 4 5] but got %v`, sources)
+	}
+	if sinks := doc.Sinks(); !reflect.DeepEqual(sinks, []int{19}) {
+		t.Fatalf(`expected sinks [19] but got %v`, sinks)
+	}
+}
+
+func TestConnectedComponents(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	doc, _ := ryxdoc.ReadFile(yxmd)
+	expected := [][]int{{1, 4, 5, 6, 12, 13, 14, 15, 16, 17, 18, 19}, {20}, {21}, {22}, {23}}
+	if components := doc.ConnectedComponents(); !reflect.DeepEqual(components, expected) {
+		t.Fatalf(`expected components %v but got %v`, expected, components)
+	}
+}
+
+func TestShortestPath(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	doc, _ := ryxdoc.ReadFile(yxmd)
+	path, err := doc.ShortestPath(1, 19)
+	if err != nil {
+		t.Fatalf(`expected no error but got: %v`, err.Error())
+	}
+	expected := []int{1, 6, 12, 13, 14, 16, 17, 18, 19}
+	if !reflect.DeepEqual(path, expected) {
+		t.Fatalf(`expected path %v but got %v`, expected, path)
+	}
+	_, err = doc.ShortestPath(19, 1)
+	if err == nil {
+		t.Fatalf(`expected an error going against the flow but got none`)
+	}
+}
+
+func TestWrapInContainer(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	doc, _ := ryxdoc.ReadFile(yxmd)
+	before, _ := doc.ReadMappedNodes()[14].ReadPosition()
+	container, err := doc.WrapInContainer([]int{14, 15}, `Branches`, ``)
+	if err != nil {
+		t.Fatalf(`expected no error but got: %v`, err.Error())
+	}
+	if id, _ := container.ReadId(); id != 24 {
+		t.Fatalf(`expected container ID 24 but got %v`, id)
+	}
+	if count := len(container.ChildNodes); count != 2 {
+		t.Fatalf(`expected 2 tools in the container but got %v`, count)
+	}
+	position, _ := container.ReadPosition()
+	if position.X >= before.X || position.Y >= before.Y {
+		t.Fatalf(`expected the container at %v to surround tool 14 at %v`, position, before)
+	}
+	if after, _ := doc.ReadMappedNodes()[14].ReadPosition(); after != before {
+		t.Fatalf(`expected tool 14 to stay at %v but it moved to %v`, before, after)
+	}
+
+	saved, _ := doc.ToBytes()
+	reread, _ := ryxdoc.ReadBytes(saved)
+	if count := len(reread.ReadMappedNodes()); count != 17 {
+		t.Fatalf(`expected 17 nodes but got %v`, count)
+	}
+	if count := len(reread.ReadMappedNodes()[24].ChildNodes); count != 2 {
+		t.Fatalf(`expected 2 tools in the saved container but got %v`, count)
+	}
+}
+
+func TestWrapToolsInDifferentContainers(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	doc, _ := ryxdoc.ReadFile(yxmd)
+	_, err := doc.WrapInContainer([]int{14, 21}, `Mixed`, ``)
+	if err == nil {
+		t.Fatalf(`expected an error but got none`)
+	}
+}
+
+func TestWrapContainerWithItsChildren(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	doc, _ := ryxdoc.ReadFile(yxmd)
+	container, err := doc.WrapInContainer([]int{22, 23}, `Outer`, `#0000ff`)
+	if err != nil {
+		t.Fatalf(`expected no error but got: %v`, err.Error())
+	}
+	if count := len(container.ChildNodes); count != 1 {
+		t.Fatalf(`expected 1 tool in the container but got %v`, count)
+	}
+	if count := len(container.ReadChildren()); count != 2 {
+		t.Fatalf(`expected 2 nested tools but got %v`, count)
+	}
+}
+
+func TestUnwrap(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	doc, _ := ryxdoc.ReadFile(yxmd)
+	err := doc.Unwrap(20)
+	if err != nil {
+		t.Fatalf(`expected no error but got: %v`, err.Error())
+	}
+	nodes := doc.ReadMappedNodes()
+	if _, ok := nodes[20]; ok {
+		t.Fatalf(`expected container 20 to be removed`)
+	}
+	if count := len(nodes); count != 15 {
+		t.Fatalf(`expected 15 nodes but got %v`, count)
+	}
+	if count := len(nodes[22].ChildNodes); count != 1 {
+		t.Fatalf(`expected container 22 to keep its tool but got %v`, count)
+	}
+}
+
+func TestUnwrapNonContainer(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	doc, _ := ryxdoc.ReadFile(yxmd)
+	err := doc.Unwrap(13)
+	if err == nil {
+		t.Fatalf(`expected an error but got none`)
+	}
+}
+
+func TestRemoveToolsAndReconnect(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	doc, _ := ryxdoc.ReadFile(yxmd)
+	err := doc.RemoveToolsAndReconnect(12, 17, 19)
+	if err != nil {
+		t.Fatalf(`expected no error but got: %v`, err.Error())
+	}
+	if count := len(doc.ReadMappedNodes()); count != 13 {
+		t.Fatalf(`expected 13 nodes but got %v`, count)
+	}
+	if !listHasConnection(doc.Connections, 6, `Join`, 13, `Input`) {
+		t.Fatalf(`expected a connection from 6.Join to 13.Input`)
+	}
+	if !listHasConnection(doc.Connections, 16, `Output`, 18, `Control`) {
+		t.Fatalf(`expected a connection from 16.Output to 18.Control`)
+	}
+	for _, conn := range doc.Connections {
+		if intsContain([]int{12, 17, 19}, conn.FromId) || intsContain([]int{12, 17, 19}, conn.ToId) {
+			t.Fatalf(`expected no connections to removed tools but got %v -> %v`, conn.FromId, conn.ToId)
+		}
+	}
+	if count := len(doc.Connections); count != 9 {
+		t.Fatalf(`expected 9 connections but got %v`, count)
+	}
+}
+
+func TestRemoveToolsAndReconnectMultipleOutputAnchors(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	doc, _ := ryxdoc.ReadFile(yxmd)
+	var keep []*ryxdoc.RyxConn
+	for _, conn := range doc.Connections {
+		if conn.FromId != 13 || conn.FromAnchor != `False` {
+			keep = append(keep, conn)
+		}
+	}
+	doc.Connections = keep
+	err := doc.RemoveToolsAndReconnect(13)
+	if err == nil {
+		t.Fatalf(`expected an error but got none`)
+	}
+	if _, ok := doc.ReadMappedNodes()[13]; !ok {
+		t.Fatalf(`expected the filter to be kept but it was removed`)
+	}
+}
+
+func TestRemoveToolsAndReconnectContainer(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	doc, _ := ryxdoc.ReadFile(yxmd)
+	count := len(doc.ReadMappedNodes())
+	err := doc.RemoveToolsAndReconnect(20)
+	if err == nil {
+		t.Fatalf(`expected an error but got none`)
+	}
+	if newCount := len(doc.ReadMappedNodes()); newCount != count {
+		t.Fatalf(`expected %v nodes but got %v`, count, newCount)
+	}
+}
+
+func TestRemoveToolsAndReconnectChain(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	doc, _ := ryxdoc.ReadFile(yxmd)
+	err := doc.RemoveToolsAndReconnect(17, 12)
+	if err != nil {
+		t.Fatalf(`expected no error but got: %v`, err.Error())
+	}
+	err = doc.RemoveToolsAndReconnect(14)
+	if err != nil {
+		t.Fatalf(`expected no error but got: %v`, err.Error())
+	}
+	if !listHasConnection(doc.Connections, 13, `True`, 16, `Input`) {
+		t.Fatalf(`expected a connection from 13.True to 16.Input`)
+	}
+}
+
+func TestRemoveToolsAndReconnectInvalidTools(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	doc, _ := ryxdoc.ReadFile(yxmd)
+	for _, id := range []int{6, 13, 16, 1, 100} {
+		if err := doc.RemoveToolsAndReconnect(id); err == nil {
+			t.Fatalf(`expected an error removing tool %v but got none`, id)
+		}
+	}
+	if count := len(doc.ReadMappedNodes()); count != 16 {
+		t.Fatalf(`expected no tools to be removed but got %v nodes`, count)
+	}
+}
+
+func TestValidateValidDocument(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	doc, _ := ryxdoc.ReadFile(yxmd)
+	if issues := doc.Validate(nil); len(issues) != 0 {
+		t.Fatalf(`expected no issues but got %v: %v`, len(issues), issues[0].Problem)
+	}
+}
+
+func TestValidateStructuralProblems(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	doc, _ := ryxdoc.ReadFile(yxmd)
+	nodes := doc.ReadMappedNodes()
+	nodes[14].ToolId = `abc`
+	nodes[23].ToolId = `15`
+	nodes[16].GuiSettings.Nodes = nil
+	doc.AddConnection(&ryxdoc.RyxConn{FromId: 100, FromAnchor: `Output`, ToId: 13, ToAnchor: `Input`})
+
+	issues := doc.Validate(nil)
+	expected := map[string]string{
+		`abc`: `the tool ID is not a number`,
+		`15`:  `the tool ID is used by 2 tools`,
+		`16`:  `the tool does not have a position`,
+		`100`: `connection 100.Output -> 13.Input refers to a tool that does not exist`,
+	}
+	for toolId, problem := range expected {
+		if !hasIssue(issues, toolId, problem) {
+			t.Fatalf(`expected issue '%v' for tool %v but it was not reported`, problem, toolId)
+		}
+	}
+}
+
+func TestValidateMacroAnchors(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	doc, _ := ryxdoc.ReadFile(yxmd)
+	readCount := 0
+	readAnchors := func(macroPath string) ([]string, []string, error) {
+		readCount++
+		return []string{`Input1`}, []string{`Output7`}, nil
+	}
+	issues := doc.Validate(readAnchors, baseFolder)
+	if readCount == 0 {
+		t.Fatalf(`expected the macro anchors to be read`)
+	}
+	if !hasIssue(issues, `18`, `connection 17.Output -> 18.Control goes into anchor 'Control', which the macro does not have`) {
+		t.Fatalf(`expected an issue for the Control anchor but got %v issues`, len(issues))
+	}
+}
+
+func TestRenumberToolsByFlow(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	doc, _ := ryxdoc.ReadFile(yxmd)
+	newIds, err := doc.RenumberTools(ryxdoc.RenumberByFlow)
+	if err != nil {
+		t.Fatalf(`expected no error but got: %v`, err.Error())
+	}
+	if !reflect.DeepEqual(sortedKeys(doc.ReadMappedNodes()), []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}) {
+		t.Fatalf(`expected tool IDs 1 to 16 but got %v`, sortedKeys(doc.ReadMappedNodes()))
+	}
+	for _, conn := range doc.Connections {
+		if conn.FromId >= conn.ToId {
+			t.Fatalf(`expected connections to flow to higher IDs but got %v -> %v`, conn.FromId, conn.ToId)
+		}
+	}
+	if !listHasConnection(doc.Connections, newIds[6], `Join`, newIds[12], `Input1`) {
+		t.Fatalf(`expected the connection from 6 to 12 to be renumbered`)
+	}
+	saved, _ := doc.ToBytes()
+	reread, _ := ryxdoc.ReadBytes(saved)
+	if issues := reread.Validate(nil); len(issues) != 0 {
+		t.Fatalf(`expected no issues but got %v: %v`, len(issues), issues[0].Problem)
+	}
+	if added := reread.AddMacroAt(`macro.yxmc`, 0, 0); added.ToolId != `17` {
+		t.Fatalf(`expected the next tool ID to be 17 but got %v`, added.ToolId)
+	}
+}
+
+func TestRenumberToolsByPosition(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	doc, _ := ryxdoc.ReadFile(yxmd)
+	_, err := doc.RenumberTools(ryxdoc.RenumberByPosition)
+	if err != nil {
+		t.Fatalf(`expected no error but got: %v`, err.Error())
+	}
+	nodes := doc.ReadMappedNodes()
+	previous, _ := nodes[1].ReadPosition()
+	for id := 2; id <= 16; id++ {
+		position, _ := nodes[id].ReadPosition()
+		if position.X < previous.X || (position.X == previous.X && position.Y < previous.Y) {
+			t.Fatalf(`expected tool %v at %v to come after %v`, id, position, previous)
+		}
+		previous = position
+	}
+}
+
+func TestRenumberToolsUpdatesQuestionsAndActions(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	doc, _ := ryxdoc.ReadFile(filepath.Join(baseFolder, `Interface.yxmc`))
+	newIds, err := doc.RenumberTools(ryxdoc.RenumberByFlow)
+	if err != nil {
+		t.Fatalf(`expected no error but got: %v`, err.Error())
+	}
+	saved, _ := doc.ToBytes()
+	reread, _ := ryxdoc.ReadBytes(saved)
+	runtime := reread.Properties.First(`RuntimeProperties`)
+	checkBox := runtime.First(`Questions`).First(`Question`).First(`Questions`).First(`Question`)
+	if id := checkBox.First(`ToolId`).Attributes[`value`]; id != strconv.Itoa(newIds[12]) {
+		t.Fatalf(`expected the check box question to refer to tool %v but got %v`, newIds[12], id)
+	}
+	action := runtime.First(`Actions`).First(`NoCondition`).First(`True`).First(`Action`)
+	if id := action.First(`ToolId`).Attributes[`value`]; id != strconv.Itoa(newIds[25]) {
+		t.Fatalf(`expected the action to refer to tool %v but got %v`, newIds[25], id)
+	}
+	expectedDestination := fmt.Sprintf(`%v/Data/r[1]/c[1]`, newIds[27])
+	if destination := action.First(`Destination`).InnerText; destination != expectedDestination {
+		t.Fatalf(`expected destination '%v' but got '%v'`, expectedDestination, destination)
+	}
+}
+
+func TestRenumberToolsUpdatesOpenOutputTools(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	doc, _ := ryxdoc.ReadFile(filepath.Join(baseFolder, `Calculate Filter Expression.yxmc`))
+	doc.RemoveNodes(5)
+	newIds, err := doc.RenumberTools(ryxdoc.RenumberByFlow)
+	if err != nil {
+		t.Fatalf(`expected no error but got: %v`, err.Error())
+	}
+	if newIds[6] == 6 {
+		t.Fatalf(`expected the macro output to get a new ID`)
+	}
+	saved, _ := doc.ToBytes()
+	reread, _ := ryxdoc.ReadBytes(saved)
+	tool := reread.Properties.First(`RuntimeProperties`).First(`Wiz_OpenOutputTools`).First(`Tool`)
+	if id := tool.Attributes[`ToolId`]; id != strconv.Itoa(newIds[6]) {
+		t.Fatalf(`expected the open output tool to refer to tool %v but got %v`, newIds[6], id)
+	}
+	if plugin := reread.ReadMappedNodes()[newIds[6]].ReadPlugin(); plugin != `AlteryxBasePluginsGui.MacroOutput.MacroOutput` {
+		t.Fatalf(`expected the open output tool to be the macro output but got %v`, plugin)
+	}
+}
+
+func TestRenumberToolsInvalidOrder(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	doc, _ := ryxdoc.ReadFile(yxmd)
+	_, err := doc.RenumberTools(`Alphabetical`)
+	if err == nil {
+		t.Fatalf(`expected an error but got none`)
+	}
+}
+
+func TestConvertWorkflowToMacro(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	doc, _ := ryxdoc.ReadFile(yxmd)
+	anchors := map[string][][]string{
+		`AlteryxBasePluginsGui.Join.Join`:         {{`Left`, `Right`}, {`Left`, `Join`, `Right`}},
+		`AlteryxBasePluginsGui.Filter.Filter`:     {{`Input`}, {`True`, `False`}},
+		`AlteryxBasePluginsGui.BrowseV2.BrowseV2`: {{`Input`}, {}},
+	}
+	readAnchors := func(node *ryxnode.RyxNode) ([]string, []string, bool) {
+		tool, ok := anchors[node.ReadPlugin()]
+		if !ok {
+			return nil, nil, false
+		}
+		return tool[0], tool[1], true
+	}
+	err := doc.ConvertDocumentType(ryxdoc.Macro, readAnchors)
+	if err != nil {
+		t.Fatalf(`expected no error but got: %v`, err.Error())
+	}
+	if docType := doc.ReadDocumentType(); docType != ryxdoc.Macro {
+		t.Fatalf(`expected a macro but got %v`, docType)
+	}
+	if count := len(doc.ReadMappedNodes()); count != 19 {
+		t.Fatalf(`expected 19 nodes but got %v`, count)
+	}
+	if !listHasConnection(doc.Connections, 6, `Left`, 25, `Input`) || !listHasConnection(doc.Connections, 6, `Right`, 26, `Input`) {
+		t.Fatalf(`expected Macro Outputs connected to the unused Join anchors`)
+	}
+	questions := doc.Properties.First(`RuntimeProperties`).First(`Questions`).First(`Question`).First(`Questions`)
+	if count := len(questions.Nodes); count != 2 {
+		t.Fatalf(`expected 2 questions but got %v`, count)
+	}
+}
+
+func TestConvertMacroToWorkflow(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	doc, _ := ryxdoc.ReadFile(filepath.Join(baseFolder, `MultiInOut.yxmc`))
+	err := doc.ConvertDocumentType(ryxdoc.Workflow, nil)
+	if err != nil {
+		t.Fatalf(`expected no error but got: %v`, err.Error())
+	}
+	if docType := doc.ReadDocumentType(); docType != ryxdoc.Workflow {
+		t.Fatalf(`expected a workflow but got %v`, docType)
+	}
+	runtime := doc.Properties.First(`RuntimeProperties`)
+	if runtime.First(`ModuleType`).Name != `` {
+		t.Fatalf(`expected the ModuleType to be removed`)
+	}
+	for _, node := range doc.ReadMappedNodes() {
+		if plugin := node.ReadPlugin(); strings.HasPrefix(plugin, `AlteryxBasePluginsGui.Macro`) {
+			t.Fatalf(`expected macro interface tools to be removed but found %v`, plugin)
+		}
+	}
+	if issues := doc.Validate(nil); len(issues) != 0 {
+		t.Fatalf(`expected no issues but got %v: %v`, len(issues), issues[0].Problem)
+	}
+	if count := len(runtime.First(`Questions`).First(`Question`).First(`Questions`).Nodes); count != 0 {
+		t.Fatalf(`expected the macro questions to be removed but got %v`, count)
+	}
+}
+
+func TestConvertToInvalidDocumentType(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	doc, _ := ryxdoc.ReadFile(yxmd)
+	err := doc.ConvertDocumentType(`Report`, nil)
+	if err == nil {
+		t.Fatalf(`expected an error but got none`)
+	}
+}
+
+func TestCopyTools(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	from, _ := ryxdoc.ReadFile(yxmd)
+	to, _ := ryxdoc.ReadFile(filepath.Join(baseFolder, `MultiInOut.yxmd`))
+	newIds, err := ryxdoc.CopyTools(from, []int{13, 14, 15, 16}, to, 500, 600, baseFolder, baseFolder)
+	if err != nil {
+		t.Fatalf(`expected no error but got: %v`, err.Error())
+	}
+	if count := len(to.ReadMappedNodes()); count != 9 {
+		t.Fatalf(`expected 9 nodes but got %v`, count)
+	}
+	if newIds[13] != 7 || newIds[16] != 10 {
+		t.Fatalf(`expected tools 13-16 to become 7-10 but got %v`, newIds)
+	}
+	if count := len(to.Connections); count != 8 {
+		t.Fatalf(`expected 8 connections but got %v`, count)
+	}
+	if !listHasConnection(to.Connections, 7, `True`, 8, `Input`) || !listHasConnection(to.Connections, 9, `Output`, 10, `Input`) {
+		t.Fatalf(`expected the connections between the copied tools to be remapped`)
+	}
+	left, top := math.MaxFloat64, math.MaxFloat64
+	for _, id := range newIds {
+		position, _ := to.ReadMappedNodes()[id].ReadPosition()
+		left = math.Min(left, position.X)
+		top = math.Min(top, position.Y)
+	}
+	if left != 500 || top != 600 {
+		t.Fatalf(`expected the copied tools to start at 500,600 but got %v,%v`, left, top)
+	}
+	if count := len(from.ReadMappedNodes()); count != 16 {
+		t.Fatalf(`expected the source document to be unchanged`)
+	}
+}
+
+func TestCopyContainer(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	doc, _ := ryxdoc.ReadFile(yxmd)
+	newIds, err := ryxdoc.CopyTools(doc, []int{20}, doc, 0, 0, baseFolder, baseFolder)
+	if err != nil {
+		t.Fatalf(`expected no error but got: %v`, err.Error())
+	}
+	if count := len(newIds); count != 4 {
+		t.Fatalf(`expected 4 copied tools but got %v`, count)
+	}
+	if count := len(doc.ReadMappedNodes()); count != 20 {
+		t.Fatalf(`expected 20 nodes but got %v`, count)
+	}
+	container := doc.ReadMappedNodes()[newIds[20]]
+	if count := len(container.ReadChildren()); count != 3 {
+		t.Fatalf(`expected the copied container to have 3 children but got %v`, count)
+	}
+}
+
+func TestCopyToolsRewritesMacroPaths(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	from, _ := ryxdoc.ReadFile(yxmd)
+	macroFolder := filepath.Join(baseFolder, `macros`)
+	to, _ := ryxdoc.ReadFile(filepath.Join(macroFolder, `Tag with Sets.yxmc`))
+	newIds, err := ryxdoc.CopyTools(from, []int{18}, to, 0, 0, baseFolder, macroFolder)
+	if err != nil {
+		t.Fatalf(`expected no error but got: %v`, err.Error())
+	}
+	macro := to.ReadMappedNodes()[newIds[18]].ReadMacro(macroFolder)
+	if macro.StoredPath != `Tag with Sets.yxmc` {
+		t.Fatalf(`expected stored path 'Tag with Sets.yxmc' but got '%v'`, macro.StoredPath)
+	}
+	if macro.FoundPath == `` {
+		t.Fatalf(`expected the macro to be found from the destination folder`)
+	}
+}
+
+func TestCopyMissingTools(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	from, _ := ryxdoc.ReadFile(yxmd)
+	to, _ := ryxdoc.ReadFile(filepath.Join(baseFolder, `MultiInOut.yxmd`))
+	_, err := ryxdoc.CopyTools(from, []int{13, 99}, to, 0, 0, baseFolder, baseFolder)
+	if err == nil {
+		t.Fatalf(`expected an error but got none`)
+	}
+	if count := len(to.ReadMappedNodes()); count != 5 {
+		t.Fatalf(`expected the destination to be unchanged but got %v nodes`, count)
+	}
+}
+
+func TestRenderSvg(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	doc, _ := ryxdoc.ReadFile(yxmd)
+	readGraphic := func(node *ryxnode.RyxNode) (string, []string, []string, bool) {
+		if node.ReadPlugin() == `AlteryxBasePluginsGui.Filter.Filter` {
+			return `aWNvbg==`, []string{`Input`}, []string{`True`, `False`}, true
+		}
+		return ``, nil, nil, false
+	}
+	image := doc.RenderSvg(readGraphic)
+	svg, err := txml.Parse(string(image))
+	if err != nil {
+		t.Fatalf(`expected valid xml but got: %v`, err.Error())
+	}
+	counts := map[string]int{}
+	texts := []string{}
+	for _, element := range svg.Nodes {
+		counts[element.Name]++
+		if element.Name == `text` {
+			texts = append(texts, element.InnerText)
+		}
+	}
+	if counts[`path`] != 12 {
+		t.Fatalf(`expected 12 connections but got %v`, counts[`path`])
+	}
+	if counts[`image`] != 1 {
+		t.Fatalf(`expected 1 icon but got %v`, counts[`image`])
+	}
+	for _, expected := range []string{`SETLEAF`, `Container 20`, `One level in`, `Join`, `Calculate Filter Expression`} {
+		if !stringsContain(texts, expected) {
+			t.Fatalf(`expected a label '%v' but it was missing from %v`, expected, texts)
+		}
+	}
+	if !strings.Contains(string(image), `stroke-dasharray`) {
+		t.Fatalf(`expected the wireless connection to be dashed`)
+	}
+}
+
+func TestRenderEmptySvg(t *testing.T) {
+	doc, _ := ryxdoc.ReadBytes([]byte(`<AlteryxDocument yxmdVer="2020.1"><Nodes /><Connections /><Properties /></AlteryxDocument>`))
+	image := doc.RenderSvg(nil)
+	if _, err := txml.Parse(string(image)); err != nil {
+		t.Fatalf(`expected valid xml but got: %v`, err.Error())
+	}
+}
+
+func TestRenameJoinField(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	doc, _ := ryxdoc.ReadFile(yxmd)
+	issues, err := doc.RenameField(4, `setname`, `SET_NAME`)
+	if err != nil {
+		t.Fatalf(`expected no error but got: %v`, err.Error())
+	}
+	if len(issues) != 0 {
+		t.Fatalf(`expected no issues but got %v`, issues)
+	}
+	nodes := doc.ReadMappedNodes()
+	textInput := nodes[4].Properties.Configuration.InnerXml
+	if !strings.Contains(textInput, `<Field name="SET_NAME"></Field>`) {
+		t.Fatalf(`expected the text input field to be renamed but got %v`, textInput)
+	}
+	join := nodes[6].Properties.Configuration.InnerXml
+	if strings.Count(join, `<Field field="SET_NAME"></Field>`) != 1 || strings.Count(join, `<Field field="SETNAME"></Field>`) != 1 {
+		t.Fatalf(`expected only the right join field to be renamed but got %v`, join)
+	}
+	if !strings.Contains(join, `field="Right_SET_NAME"`) || !strings.Contains(join, `rename="Right_SET_NAME"`) {
+		t.Fatalf(`expected the right select field to be renamed but got %v`, join)
+	}
+	if !strings.Contains(nodes[14].Properties.Configuration.InnerXml, `field="SETNAME"`) {
+		t.Fatalf(`expected the rename to stop at the deselected join field`)
+	}
+}
+
+func TestRenameSummarizeField(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	doc, _ := ryxdoc.ReadFile(yxmd)
+	issues, err := doc.RenameField(14, `Filter`, `Filters`)
+	if err != nil {
+		t.Fatalf(`expected no error but got: %v`, err.Error())
+	}
+	if len(issues) != 2 || issues[0].ToolId != 16 || issues[1].ToolId != 18 {
+		t.Fatalf(`expected issues for the union at tool 16 and the macro at tool 18 but got %v`, issues)
+	}
+	nodes := doc.ReadMappedNodes()
+	if !strings.Contains(nodes[14].Properties.Configuration.InnerXml, `field="Filter" action="Concat" rename="Filters"`) {
+		t.Fatalf(`expected the summarize output to be renamed but got %v`, nodes[14].Properties.Configuration.InnerXml)
+	}
+	summarize := nodes[17].Properties.Configuration.InnerXml
+	if !strings.Contains(summarize, `field="Filters" action="Concat" rename="Filters"`) || !strings.Contains(summarize, `<![CDATA[ && ]]>`) {
+		t.Fatalf(`expected the downstream summarize to be renamed through the union but got %v`, summarize)
+	}
+	if !strings.Contains(nodes[15].Properties.Configuration.InnerXml, `rename="Filter"`) {
+		t.Fatalf(`expected the other branch to be unchanged`)
+	}
+}
+
+func TestRenameFieldInExpressions(t *testing.T) {
+	doc, _ := ryxdoc.ReadBytes([]byte(`<AlteryxDocument yxmdVer="2020.1"><Nodes>
+<Node ToolID="1"><GuiSettings Plugin="AlteryxBasePluginsGui.Formula.Formula"><Position x="0" y="0" /></GuiSettings><Properties><Configuration><FormulaFields><FormulaField expression="1" field="Amount" size="8" type="Double" /></FormulaFields></Configuration><Annotation DisplayMode="0"><Name /><DefaultAnnotationText /><Left value="False" /></Annotation></Properties><EngineSettings EngineDll="AlteryxBasePluginsEngine.dll" EngineDllEntryPoint="AlteryxFormula" /></Node>
+<Node ToolID="2"><GuiSettings Plugin="AlteryxBasePluginsGui.Filter.Filter"><Position x="96" y="0" /></GuiSettings><Properties><Configuration><Expression>[amount] &gt; 1 &amp;&amp; [Amounts] &gt; 1</Expression><Mode>Custom</Mode></Configuration><Annotation DisplayMode="0"><Name /><DefaultAnnotationText /><Left value="False" /></Annotation></Properties><EngineSettings EngineDll="AlteryxBasePluginsEngine.dll" EngineDllEntryPoint="AlteryxFilter" /></Node>
+<Node ToolID="3"><GuiSettings Plugin="AlteryxBasePluginsGui.Formula.Formula"><Position x="192" y="0" /></GuiSettings><Properties><Configuration><FormulaFields><FormulaField expression="[Amount] * 2" field="Double" size="8" type="Double" /></FormulaFields></Configuration><Annotation DisplayMode="0"><Name /><DefaultAnnotationText /><Left value="False" /></Annotation></Properties><EngineSettings EngineDll="AlteryxBasePluginsEngine.dll" EngineDllEntryPoint="AlteryxFormula" /></Node>
+<Node ToolID="4"><GuiSettings Plugin="AlteryxBasePluginsGui.Sort.Sort"><Position x="192" y="96" /></GuiSettings><Properties><Configuration /><Annotation DisplayMode="0"><Name /><DefaultAnnotationText /><Left value="False" /></Annotation></Properties><EngineSettings EngineDll="AlteryxBasePluginsEngine.dll" EngineDllEntryPoint="AlteryxSort" /></Node>
+</Nodes><Connections>
+<Connection><Origin ToolID="1" Connection="Output" /><Destination ToolID="2" Connection="Input" /></Connection>
+<Connection><Origin ToolID="2" Connection="True" /><Destination ToolID="3" Connection="Input" /></Connection>
+<Connection><Origin ToolID="2" Connection="False" /><Destination ToolID="4" Connection="Input" /></Connection>
+</Connections><Properties /></AlteryxDocument>`))
+	issues, err := doc.RenameField(1, `Amount`, `Total`)
+	if err != nil {
+		t.Fatalf(`expected no error but got: %v`, err.Error())
+	}
+	if len(issues) != 1 || issues[0].ToolId != 4 {
+		t.Fatalf(`expected an issue for the unsupported sort tool but got %v`, issues)
+	}
+	nodes := doc.ReadMappedNodes()
+	filter := nodes[2].Properties.Configuration.InnerXml
+	if !strings.Contains(filter, `[Total] &gt; 1 &amp;&amp; [Amounts] &gt; 1`) {
+		t.Fatalf(`expected only the [Amount] reference to be renamed but got %v`, filter)
+	}
+	if formula := nodes[3].Properties.Configuration.InnerXml; !strings.Contains(formula, `expression="[Total] * 2"`) {
+		t.Fatalf(`expected the downstream formula to be renamed but got %v`, formula)
+	}
+}
+
+func TestRenameFieldReportsOutputs(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	macro, _ := ryxdoc.ReadFile(filepath.Join(baseFolder, `Calculate Filter Expression.yxmc`))
+	issues, err := macro.RenameField(2, `Filter`, `Expression`)
+	if err != nil {
+		t.Fatalf(`expected no error but got: %v`, err.Error())
+	}
+	if len(issues) != 1 || issues[0].ToolId != 6 {
+		t.Fatalf(`expected an issue for the macro output at tool 6 but got %v`, issues)
+	}
+
+	doc, _ := ryxdoc.ReadBytes([]byte(`<AlteryxDocument yxmdVer="2020.1"><Nodes>
+<Node ToolID="1"><GuiSettings Plugin="AlteryxBasePluginsGui.Formula.Formula"><Position x="0" y="0" /></GuiSettings><Properties><Configuration><FormulaFields><FormulaField expression="1" field="Amount" size="8" type="Double" /></FormulaFields></Configuration><Annotation DisplayMode="0"><Name /><DefaultAnnotationText /><Left value="False" /></Annotation></Properties><EngineSettings EngineDll="AlteryxBasePluginsEngine.dll" EngineDllEntryPoint="AlteryxFormula" /></Node>
+<Node ToolID="2"><GuiSettings Plugin="AlteryxBasePluginsGui.Union.Union"><Position x="96" y="0" /></GuiSettings><Properties><Configuration><ByName_ErrorMode>Warning</ByName_ErrorMode><ByName_OutputMode>All</ByName_OutputMode><Mode>ByName</Mode></Configuration><Annotation DisplayMode="0"><Name /><DefaultAnnotationText /><Left value="False" /></Annotation></Properties><EngineSettings EngineDll="AlteryxBasePluginsEngine.dll" EngineDllEntryPoint="AlteryxUnion" /></Node>
+<Node ToolID="3"><GuiSettings Plugin="AlteryxBasePluginsGui.DbFileOutput.DbFileOutput"><Position x="192" y="0" /></GuiSettings><Properties><Configuration><File FileFormat="0">Amounts.csv</File></Configuration><Annotation DisplayMode="0"><Name /><DefaultAnnotationText /><Left value="False" /></Annotation></Properties><EngineSettings EngineDll="AlteryxBasePluginsEngine.dll" EngineDllEntryPoint="AlteryxDbFileOutput" /></Node>
+</Nodes><Connections>
+<Connection><Origin ToolID="1" Connection="Output" /><Destination ToolID="2" Connection="Input1" /></Connection>
+<Connection><Origin ToolID="2" Connection="Output" /><Destination ToolID="3" Connection="Input" /></Connection>
+</Connections><Properties /></AlteryxDocument>`))
+	issues, err = doc.RenameField(1, `Amount`, `Total`)
+	if err != nil {
+		t.Fatalf(`expected no error but got: %v`, err.Error())
+	}
+	if len(issues) != 2 || issues[0].ToolId != 2 || issues[1].ToolId != 3 {
+		t.Fatalf(`expected issues for the union at tool 2 and the output at tool 3 but got %v`, issues)
+	}
+}
+
+func TestRenameFieldEscapesNewName(t *testing.T) {
+	doc, _ := ryxdoc.ReadBytes([]byte(`<AlteryxDocument yxmdVer="2020.1"><Nodes>
+<Node ToolID="1"><GuiSettings Plugin="AlteryxBasePluginsGui.Formula.Formula"><Position x="0" y="0" /></GuiSettings><Properties><Configuration><FormulaFields><FormulaField expression="1" field="Amount" size="8" type="Double" /></FormulaFields></Configuration><Annotation DisplayMode="0"><Name /><DefaultAnnotationText /><Left value="False" /></Annotation></Properties><EngineSettings EngineDll="AlteryxBasePluginsEngine.dll" EngineDllEntryPoint="AlteryxFormula" /></Node>
+<Node ToolID="2"><GuiSettings Plugin="AlteryxBasePluginsGui.Filter.Filter"><Position x="96" y="0" /></GuiSettings><Properties><Configuration><Expression>[Amount] &gt; 1</Expression><Mode>Simple</Mode><Simple><Operator>&gt;</Operator><Field>Amount</Field><Operands><Operand>1</Operand></Operands></Simple></Configuration><Annotation DisplayMode="0"><Name /><DefaultAnnotationText /><Left value="False" /></Annotation></Properties><EngineSettings EngineDll="AlteryxBasePluginsEngine.dll" EngineDllEntryPoint="AlteryxFilter" /></Node>
+</Nodes><Connections>
+<Connection><Origin ToolID="1" Connection="Output" /><Destination ToolID="2" Connection="Input" /></Connection>
+</Connections><Properties /></AlteryxDocument>`))
+	_, err := doc.RenameField(1, `Amount`, `Profit & <Loss>`)
+	if err != nil {
+		t.Fatalf(`expected no error but got: %v`, err.Error())
+	}
+	saved, err := doc.ToBytes()
+	if err != nil {
+		t.Fatalf(`expected no error but got: %v`, err.Error())
+	}
+	reread, err := ryxdoc.ReadBytes(saved)
+	if err != nil {
+		t.Fatalf(`expected the saved document to be readable but got: %v`, err.Error())
+	}
+	filter := reread.ReadMappedNodes()[2].Properties.Configuration.InnerXml
+	if !strings.Contains(filter, `<Field>Profit &amp; &lt;Loss&gt;</Field>`) || !strings.Contains(filter, `<Mode>Simple</Mode>`) {
+		t.Fatalf(`expected the simple field to be escaped and the mode kept but got %v`, filter)
+	}
+	if !strings.Contains(filter, `[Profit &amp; &lt;Loss&gt;] &gt; 1`) {
+		t.Fatalf(`expected the expression to be escaped but got %v`, filter)
+	}
+}
+
+func TestRenameMissingField(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	doc, _ := ryxdoc.ReadFile(yxmd)
+	if _, err := doc.RenameField(4, `Missing`, `Other`); err == nil {
+		t.Fatalf(`expected an error for a field the tool does not output but got none`)
+	}
+	if _, err := doc.RenameField(99, `SETNAME`, `Other`); err == nil {
+		t.Fatalf(`expected an error for a missing tool but got none`)
+	}
+	if _, err := doc.RenameField(4, `SETNAME`, ``); err == nil {
+		t.Fatalf(`expected an error for a blank name but got none`)
+	}
+}
+
+func TestTraceJoinedField(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	doc, _ := ryxdoc.ReadFile(yxmd)
+	trace, err := doc.TraceField(6, `SETNAME`)
+	if err != nil {
+		t.Fatalf(`expected no error but got: %v`, err.Error())
+	}
+	if !reflect.DeepEqual(trace.Origins, []int{1}) {
+		t.Fatalf(`expected the field to come from tool 1 but got %v`, trace.Origins)
+	}
+	if len(trace.Upstream) != 2 || trace.Upstream[0].Action != ryxdoc.FieldJoined {
+		t.Fatalf(`expected the join and the left text input upstream but got %v`, trace.Upstream)
+	}
+	if len(trace.Downstream) != 1 || trace.Downstream[0].ToolId != 12 || trace.Downstream[0].Action != ryxdoc.FieldMacro {
+		t.Fatalf(`expected the trace to stop at the macro at tool 12 but got %v`, trace.Downstream)
+	}
+}
+
+func TestTraceFieldThroughUnion(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	doc, _ := ryxdoc.ReadFile(yxmd)
+	trace, err := doc.TraceField(14, `Filter`)
+	if err != nil {
+		t.Fatalf(`expected no error but got: %v`, err.Error())
+	}
+	actions := []ryxdoc.FieldAction{}
+	for _, step := range trace.Downstream {
+		actions = append(actions, step.Action)
+	}
+	expected := []ryxdoc.FieldAction{ryxdoc.FieldUnioned, ryxdoc.FieldSummarized, ryxdoc.FieldMacro}
+	if !reflect.DeepEqual(actions, expected) {
+		t.Fatalf(`expected %v but got %v`, expected, actions)
+	}
+	last := trace.Upstream[len(trace.Upstream)-1]
+	if last.ToolId != 12 || last.Action != ryxdoc.FieldMacro || len(trace.Origins) != 0 {
+		t.Fatalf(`expected the upstream trace to stop at the macro at tool 12 but got %v`, trace.Upstream)
+	}
+}
+
+func TestTraceRenamedAndDerivedField(t *testing.T) {
+	doc, _ := ryxdoc.ReadBytes([]byte(`<AlteryxDocument yxmdVer="2020.1"><Nodes>
+<Node ToolID="1"><GuiSettings Plugin="AlteryxBasePluginsGui.TextInput.TextInput"><Position x="0" y="0" /></GuiSettings><Properties><Configuration><NumRows value="1" /><Fields><Field name="A" /><Field name="B" /></Fields><Data><r><c>1</c><c>2</c></r></Data></Configuration><Annotation DisplayMode="0"><Name /><DefaultAnnotationText /><Left value="False" /></Annotation></Properties><EngineSettings EngineDll="AlteryxBasePluginsEngine.dll" EngineDllEntryPoint="AlteryxTextInput" /></Node>
+<Node ToolID="2"><GuiSettings Plugin="AlteryxBasePluginsGui.Formula.Formula"><Position x="96" y="0" /></GuiSettings><Properties><Configuration><FormulaFields><FormulaField expression="[a] * 2" field="Half" size="8" type="Double" /><FormulaField expression="[Half] + 1" field="C" size="8" type="Double" /></FormulaFields></Configuration><Annotation DisplayMode="0"><Name /><DefaultAnnotationText /><Left value="False" /></Annotation></Properties><EngineSettings EngineDll="AlteryxBasePluginsEngine.dll" EngineDllEntryPoint="AlteryxFormula" /></Node>
+<Node ToolID="3"><GuiSettings Plugin="AlteryxBasePluginsGui.AlteryxSelect.AlteryxSelect"><Position x="192" y="0" /></GuiSettings><Properties><Configuration><OrderChanged value="False" /><SelectFields><SelectField field="C" selected="True" rename="D" /><SelectField field="Half" selected="False" /><SelectField field="*Unknown" selected="True" /></SelectFields></Configuration><Annotation DisplayMode="0"><Name /><DefaultAnnotationText /><Left value="False" /></Annotation></Properties><EngineSettings EngineDll="AlteryxBasePluginsEngine.dll" EngineDllEntryPoint="AlteryxSelect" /></Node>
+<Node ToolID="4"><GuiSettings Plugin="AlteryxBasePluginsGui.DbFileOutput.DbFileOutput"><Position x="288" y="0" /></GuiSettings><Properties><Configuration><File FileFormat="0">C:\out.csv</File></Configuration><Annotation DisplayMode="0"><Name /><DefaultAnnotationText /><Left value="False" /></Annotation></Properties><EngineSettings EngineDll="AlteryxBasePluginsEngine.dll" EngineDllEntryPoint="AlteryxDbFileOutput" /></Node>
+</Nodes><Connections>
+<Connection><Origin ToolID="1" Connection="Output" /><Destination ToolID="2" Connection="Input" /></Connection>
+<Connection><Origin ToolID="2" Connection="Output" /><Destination ToolID="3" Connection="Input" /></Connection>
+<Connection><Origin ToolID="3" Connection="Output" /><Destination ToolID="4" Connection="Input" /></Connection>
+</Connections><Properties /></AlteryxDocument>`))
+	trace, err := doc.TraceField(3, `D`)
+	if err != nil {
+		t.Fatalf(`expected no error but got: %v`, err.Error())
+	}
+	actions := []ryxdoc.FieldAction{}
+	for _, step := range trace.Upstream {
+		actions = append(actions, step.Action)
+	}
+	expected := []ryxdoc.FieldAction{ryxdoc.FieldRenamed, ryxdoc.FieldDerived, ryxdoc.FieldCreated}
+	if !reflect.DeepEqual(actions, expected) {
+		t.Fatalf(`expected %v but got %v`, expected, actions)
+	}
+	if uses := trace.Upstream[1].Uses; !reflect.DeepEqual(uses, []string{`a`}) {
+		t.Fatalf(`expected C to be derived from a but got %v`, uses)
+	}
+	if !reflect.DeepEqual(trace.Origins, []int{1}) || !reflect.DeepEqual(trace.Outputs, []int{4}) {
+		t.Fatalf(`expected origin 1 and output 4 but got %v and %v`, trace.Origins, trace.Outputs)
+	}
+
+	trace, _ = doc.TraceField(1, `A`)
+	fields := map[int][]string{}
+	for _, step := range trace.Downstream {
+		fields[step.ToolId] = append(fields[step.ToolId], step.Field)
+	}
+	if !reflect.DeepEqual(fields[2], []string{`A`, `Half`, `C`}) {
+		t.Fatalf(`expected A to pass through the formula and derive Half and C but got %v`, fields[2])
+	}
+	if !reflect.DeepEqual(fields[3], []string{`A`, `Half`, `D`}) {
+		t.Fatalf(`expected A to pass, Half to be dropped and C renamed to D but got %v`, fields[3])
+	}
+}
+
+func TestTraceMissingField(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	doc, _ := ryxdoc.ReadFile(yxmd)
+	if _, err := doc.TraceField(4, `Missing`); err == nil {
+		t.Fatalf(`expected an error for a field the tool does not output but got none`)
+	}
+	if _, err := doc.TraceField(99, `SETNAME`); err == nil {
+		t.Fatalf(`expected an error for a missing tool but got none`)
+	}
+}
+
+func stringsContain(values []string, check string) bool {
+	for _, value := range values {
+		if value == check {
+			return true
+		}
+	}
+	return false
+}
+
+func listHasConnection(conns []*ryxdoc.RyxConn, fromId int, fromAnchor string, toId int, toAnchor string) bool {
+	connFound := false
+	for _, conn := range conns {
+		if conn.FromId == fromId && conn.FromAnchor == fromAnchor && conn.ToId == toId && conn.ToAnchor == toAnchor {
+			connFound = true
+			break
+		}
+	}
+	return connFound
+}
+
+func intsContain(values []int, check int) bool {
+	for _, value := range values {
+		if value == check {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys(nodes map[int]*ryxnode.RyxNode) []int {
+	keys := []int{}
+	for key := range nodes {
+		keys = append(keys, key)
+	}
+	sort.Ints(keys)
+	return keys
+}
+
+func hasIssue(issues []*ryxdoc.ValidationIssue, toolId string, problem string) bool {
+	for _, issue := range issues {
+		if issue.ToolId == toolId && issue.Problem == problem {
+			return true
+		}
+	}
+	return false
+}
+
+func hasDiffLine(lines []*ryxdoc.DiffLine, kind string, contains string) bool {
+	for _, line := range lines {
+		if line.Kind == kind && strings.Contains(line.Text, contains) {
+			return true
+		}
+	}
+	return false
 }
 
 func generateAbsPath(path ...string) (string, error) {
@@ -0,0 +1,210 @@
+package ryxdoc
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	h "github.com/tlarsen7572/Golang-Public/helpers"
+	"github.com/tlarsen7572/Golang-Public/ryx/ryxnode"
+	"github.com/tlarsen7572/Golang-Public/txml"
+	"math"
+	"strings"
+)
+
+var svgMargin = gridSize * 2
+var svgAnchorSize = 6.0
+var svgFontSize = 11.0
+
+// ToolGraphic returns a tool's icon as a base64 encoded PNG along with the names of its input and output anchors.
+// The last value is false if nothing is known about the tool.
+type ToolGraphic func(node *ryxnode.RyxNode) (icon string, inputs []string, outputs []string, ok bool)
+
+type svgTool struct {
+	position ryxnode.Position
+	inputs   []string
+	outputs  []string
+}
+
+// RenderSvg draws the document's canvas as an SVG image.  Tools are drawn at their positions using the icons from
+// readGraphic, or as labelled boxes when no icon is known.  Containers are drawn as boxes behind their tools,
+// connections are drawn as curves between anchors, and annotations are drawn as labels beneath their tools.
+func (ryxDoc *RyxDoc) RenderSvg(readGraphic ToolGraphic) []byte {
+	if readGraphic == nil {
+		readGraphic = func(*ryxnode.RyxNode) (string, []string, []string, bool) { return ``, nil, nil, false }
+	}
+	nodes := ryxDoc.ReadMappedNodes()
+	tools := map[int]*svgTool{}
+	icons := map[int]string{}
+	left, top, right, bottom := math.MaxFloat64, math.MaxFloat64, 0.0, 0.0
+	for id, node := range nodes {
+		if node.ReadPlugin() == questionsTabPlugin {
+			continue
+		}
+		position, err := node.ReadPosition()
+		if err != nil {
+			continue
+		}
+		icon, inputs, outputs, _ := readGraphic(node)
+		tools[id] = &svgTool{position: position, inputs: inputs, outputs: outputs}
+		icons[id] = icon
+		left = math.Min(left, position.X)
+		top = math.Min(top, position.Y)
+		right = math.Max(right, position.X+position.Width)
+		bottom = math.Max(bottom, position.Y+position.Height+svgFontSize*2)
+	}
+	if len(tools) == 0 {
+		left, top = 0, 0
+	}
+
+	out := &bytes.Buffer{}
+	width := right - left + svgMargin*2
+	height := bottom - top + svgMargin*2
+	out.WriteString(fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%v" height="%v" viewBox="%v %v %v %v" font-family="Arial" font-size="%v">`, svgNum(width), svgNum(height), svgNum(left-svgMargin), svgNum(top-svgMargin), svgNum(width), svgNum(height), svgNum(svgFontSize)))
+	out.WriteString("\n")
+
+	var drawContainers func(nodes []*ryxnode.RyxNode)
+	drawContainers = func(nodes []*ryxnode.RyxNode) {
+		for _, node := range nodes {
+			if node.ReadCategory() != ryxnode.Container {
+				continue
+			}
+			id, _ := node.ReadId()
+			if tool, ok := tools[id]; ok {
+				writeSvgContainer(out, node, tool.position)
+			}
+			drawContainers(node.ChildNodes)
+		}
+	}
+	drawContainers(ryxDoc.Nodes) // It is ok to use RyxDoc.Nodes here
+
+	for _, conn := range ryxDoc.Connections {
+		from, fromOk := tools[conn.FromId]
+		to, toOk := tools[conn.ToId]
+		if !fromOk || !toOk {
+			continue
+		}
+		x1, y1 := anchorPoint(from.position, from.outputs, conn.FromAnchor, true)
+		x2, y2 := anchorPoint(to.position, to.inputs, conn.ToAnchor, false)
+		bend := math.Max(math.Abs(x2-x1)/2, gridSize*3)
+		dash := ``
+		if conn.Wireless {
+			dash = ` stroke-dasharray="4 4"`
+		}
+		out.WriteString(fmt.Sprintf(`  <path d="M %v %v C %v %v, %v %v, %v %v" fill="none" stroke="#7a7a7a" stroke-width="1.5"%v />`, svgNum(x1), svgNum(y1), svgNum(x1+bend), svgNum(y1), svgNum(x2-bend), svgNum(y2), svgNum(x2), svgNum(y2), dash))
+		out.WriteString("\n")
+	}
+
+	for _, id := range sortedIds(nodes) {
+		tool, ok := tools[id]
+		node := nodes[id]
+		if !ok || node.ReadCategory() == ryxnode.Container {
+			continue
+		}
+		if node.ReadCategory() == ryxnode.Cosmetic {
+			writeSvgTextBox(out, node, tool.position)
+			continue
+		}
+		writeSvgTool(out, node, tool, icons[id])
+	}
+	out.WriteString("</svg>\n")
+	return out.Bytes()
+}
+
+func writeSvgContainer(out *bytes.Buffer, node *ryxnode.RyxNode, position ryxnode.Position) {
+	style := readConfigNode(node).First(`Style`)
+	fill := firstNonBlank(style.Attributes[`FillColor`], `#ecf2f2`)
+	border := firstNonBlank(style.Attributes[`BorderColor`], `#314c4a`)
+	text := firstNonBlank(style.Attributes[`TextColor`], border)
+	out.WriteString(fmt.Sprintf(`  <rect x="%v" y="%v" width="%v" height="%v" fill="%v" stroke="%v" />`, svgNum(position.X), svgNum(position.Y), svgNum(position.Width), svgNum(position.Height), escapeSvg(fill), escapeSvg(border)))
+	out.WriteString("\n")
+	out.WriteString(fmt.Sprintf(`  <text x="%v" y="%v" fill="%v" font-weight="bold">%v</text>`, svgNum(position.X+gridSize/2), svgNum(position.Y+svgFontSize+gridSize/2), escapeSvg(text), escapeSvg(readConfigText(node, `Caption`))))
+	out.WriteString("\n")
+}
+
+func writeSvgTextBox(out *bytes.Buffer, node *ryxnode.RyxNode, position ryxnode.Position) {
+	out.WriteString(fmt.Sprintf(`  <rect x="%v" y="%v" width="%v" height="%v" fill="white" stroke="#cccccc" />`, svgNum(position.X), svgNum(position.Y), svgNum(position.Width), svgNum(position.Height)))
+	out.WriteString("\n")
+	out.WriteString(fmt.Sprintf(`  <text x="%v" y="%v" text-anchor="middle">%v</text>`, svgNum(position.X+position.Width/2), svgNum(position.Y+position.Height/2+svgFontSize/3), escapeSvg(readConfigText(node, `Text`))))
+	out.WriteString("\n")
+}
+
+func writeSvgTool(out *bytes.Buffer, node *ryxnode.RyxNode, tool *svgTool, icon string) {
+	position := tool.position
+	if icon != `` {
+		out.WriteString(fmt.Sprintf(`  <image x="%v" y="%v" width="%v" height="%v" href="data:image/png;base64,%v" />`, svgNum(position.X), svgNum(position.Y), svgNum(position.Width), svgNum(position.Height), icon))
+	} else {
+		out.WriteString(fmt.Sprintf(`  <rect x="%v" y="%v" width="%v" height="%v" rx="6" fill="#f2f2f2" stroke="#7a7a7a" />`, svgNum(position.X), svgNum(position.Y), svgNum(position.Width), svgNum(position.Height)))
+		out.WriteString("\n")
+		out.WriteString(fmt.Sprintf(`  <text x="%v" y="%v" text-anchor="middle">%v</text>`, svgNum(position.X+position.Width/2), svgNum(position.Y+position.Height/2+svgFontSize/3), escapeSvg(shortToolName(node))))
+	}
+	out.WriteString("\n")
+	for index := range tool.inputs {
+		_, y := anchorPoint(position, tool.inputs, tool.inputs[index], false)
+		out.WriteString(fmt.Sprintf(`  <rect x="%v" y="%v" width="%v" height="%v" fill="#7a7a7a" />`, svgNum(position.X-svgAnchorSize), svgNum(y-svgAnchorSize/2), svgNum(svgAnchorSize), svgNum(svgAnchorSize)))
+		out.WriteString("\n")
+	}
+	for index := range tool.outputs {
+		_, y := anchorPoint(position, tool.outputs, tool.outputs[index], true)
+		out.WriteString(fmt.Sprintf(`  <rect x="%v" y="%v" width="%v" height="%v" fill="#7a7a7a" />`, svgNum(position.X+position.Width), svgNum(y-svgAnchorSize/2), svgNum(svgAnchorSize), svgNum(svgAnchorSize)))
+		out.WriteString("\n")
+	}
+	if annotation := readAnnotation(node); annotation != `` {
+		out.WriteString(fmt.Sprintf(`  <text x="%v" y="%v" text-anchor="middle">%v</text>`, svgNum(position.X+position.Width/2), svgNum(position.Y+position.Height+svgFontSize*1.5), escapeSvg(annotation)))
+		out.WriteString("\n")
+	}
+}
+
+// anchorPoint spreads a tool's anchors evenly down its left side for inputs and its right side for outputs.  Anchors
+// that are not in the list are placed in the middle of the side.
+func anchorPoint(position ryxnode.Position, anchors []string, anchor string, isOutput bool) (float64, float64) {
+	x := position.X - svgAnchorSize/2
+	if isOutput {
+		x = position.X + position.Width + svgAnchorSize/2
+	}
+	for index, name := range anchors {
+		if name == anchor {
+			return x, position.Y + position.Height*float64(index+1)/float64(len(anchors)+1)
+		}
+	}
+	return x, position.Y + position.Height/2
+}
+
+func readAnnotation(node *ryxnode.RyxNode) string {
+	if node.Properties == nil || node.Properties.Annotation == nil {
+		return ``
+	}
+	annotation := node.Properties.Annotation
+	return firstNonBlank(annotation.First(`AnnotationText`).InnerText, annotation.First(`DefaultAnnotationText`).InnerText)
+}
+
+func readConfigNode(node *ryxnode.RyxNode) *txml.Node {
+	if node.Properties == nil {
+		return &txml.Node{}
+	}
+	config, err := txml.Parse(`<Configuration>` + node.Properties.Configuration.InnerXml + `</Configuration>`)
+	if err != nil {
+		return &txml.Node{}
+	}
+	return config
+}
+
+// shortToolName returns the last part of a tool's plugin, or the file name of a macro.
+func shortToolName(node *ryxnode.RyxNode) string {
+	if node.ReadCategory() == ryxnode.Macro {
+		stored := strings.Replace(node.ReadMacro().StoredPath, `\`, `/`, -1)
+		name := stored[strings.LastIndex(stored, `/`)+1:]
+		return strings.TrimSuffix(name, `.yxmc`)
+	}
+	plugin := node.ReadPlugin()
+	return plugin[strings.LastIndex(plugin, `.`)+1:]
+}
+
+func escapeSvg(value string) string {
+	buffer := &bytes.Buffer{}
+	_ = xml.EscapeText(buffer, []byte(value))
+	return buffer.String()
+}
+
+func svgNum(value float64) string {
+	return h.DblToStr(math.Round(value*100)/100, -1)
+}
@@ -0,0 +1,427 @@
+package ryxdoc
+
+import (
+	"errors"
+	"fmt"
+	"github.com/tlarsen7572/Golang-Public/ryx/ryxnode"
+	"github.com/tlarsen7572/Golang-Public/ryx/ryxnode/toolconfig"
+	"regexp"
+	"strings"
+)
+
+type FieldAction string
+
+const (
+	FieldCreated     FieldAction = `Created`
+	FieldPassed      FieldAction = `Passed`
+	FieldRenamed     FieldAction = `Renamed`
+	FieldDerived     FieldAction = `Derived`
+	FieldJoined      FieldAction = `Joined`
+	FieldUnioned     FieldAction = `Unioned`
+	FieldSummarized  FieldAction = `Summarized`
+	FieldDropped     FieldAction = `Dropped`
+	FieldOutput      FieldAction = `Output`
+	FieldMacro       FieldAction = `Macro`
+	FieldUnsupported FieldAction = `Unsupported`
+	FieldNotFound    FieldAction = `NotFound`
+)
+
+// FieldStep is one tool in a field's lineage.  Field is the name of the field as it leaves the tool, or as it arrives
+// for tools the field does not leave, and Uses lists the incoming fields it was made from.
+type FieldStep struct {
+	ToolId int
+	Field  string
+	Action FieldAction
+	Uses   []string
+}
+
+type FieldTrace struct {
+	Upstream   []*FieldStep
+	Downstream []*FieldStep
+	Origins    []int
+	Outputs    []int
+}
+
+type fieldRef struct {
+	anchor string
+	field  string
+	action FieldAction
+}
+
+type fieldAt struct {
+	toolId int
+	anchor string
+	field  string
+}
+
+var bracketReference = regexp.MustCompile(`\[([^\[\]]+)\]`)
+
+// TraceField follows a field leaving a tool back to the tools that created it and forward to every tool that
+// receives it.  Renames in Select, Join and Summarize tools are followed, as are Formula fields derived from the field.
+// Fields leaving a Join without being listed in its select configuration are traced back through both inputs.  Macros
+// are opaque, so the trace stops at them, and tools without a configuration model are assumed to pass the field
+// through unchanged.  Field names are matched without regard to case.
+func (ryxDoc *RyxDoc) TraceField(toolId int, field string) (*FieldTrace, error) {
+	if field == `` {
+		return nil, errors.New(`the field name cannot be blank`)
+	}
+	nodes := ryxDoc.ReadMappedNodes()
+	if _, ok := nodes[toolId]; !ok {
+		return nil, errors.New(fmt.Sprintf(`tool %v does not exist`, toolId))
+	}
+	outputConns, inputConns := readIoConns(ryxDoc)
+	trace := &FieldTrace{Upstream: []*FieldStep{}, Downstream: []*FieldStep{}, Origins: []int{}, Outputs: []int{}}
+
+	queue := []fieldAt{{toolId: toolId, field: field}}
+	visited := map[string]bool{}
+	for len(queue) > 0 {
+		item := queue[0]
+		queue = queue[1:]
+		key := fmt.Sprintf(`%v|%v|%v`, item.toolId, item.anchor, strings.ToLower(item.field))
+		node, ok := nodes[item.toolId]
+		if visited[key] || !ok {
+			continue
+		}
+		visited[key] = true
+		action, sources := traceFieldSource(node, item.anchor, item.field)
+		if item.toolId == toolId && action == FieldNotFound {
+			return nil, errors.New(fmt.Sprintf(`tool %v does not output a field named '%v'`, toolId, field))
+		}
+		step := &FieldStep{ToolId: item.toolId, Field: item.field, Action: action, Uses: []string{}}
+		for _, source := range sources {
+			step.Uses = append(step.Uses, source.field)
+			for _, conn := range inputConns[item.toolId] {
+				if source.anchor != `` && conn.ToAnchor != source.anchor {
+					continue
+				}
+				queue = append(queue, fieldAt{toolId: conn.FromId, anchor: conn.FromAnchor, field: source.field})
+			}
+		}
+		trace.Upstream = append(trace.Upstream, step)
+		if action == FieldCreated && !intsContain(trace.Origins, item.toolId) {
+			trace.Origins = append(trace.Origins, item.toolId)
+		}
+	}
+
+	queue = []fieldAt{}
+	for _, conn := range outputConns[toolId] {
+		queue = append(queue, fieldAt{toolId: conn.ToId, anchor: conn.ToAnchor, field: field})
+	}
+	visited = map[string]bool{}
+	for len(queue) > 0 {
+		item := queue[0]
+		queue = queue[1:]
+		key := fmt.Sprintf(`%v|%v|%v`, item.toolId, item.anchor, strings.ToLower(item.field))
+		node, ok := nodes[item.toolId]
+		if visited[key] || !ok {
+			continue
+		}
+		visited[key] = true
+		action, targets := traceFieldTargets(node, item.anchor, item.field)
+		if len(targets) == 0 {
+			trace.Downstream = append(trace.Downstream, &FieldStep{ToolId: item.toolId, Field: item.field, Action: action, Uses: []string{item.field}})
+		}
+		for _, target := range targets {
+			trace.Downstream = append(trace.Downstream, &FieldStep{ToolId: item.toolId, Field: target.field, Action: target.action, Uses: []string{item.field}})
+			for _, conn := range outputConns[item.toolId] {
+				if target.anchor != `` && conn.FromAnchor != target.anchor {
+					continue
+				}
+				queue = append(queue, fieldAt{toolId: conn.ToId, anchor: conn.ToAnchor, field: target.field})
+			}
+		}
+		if action == FieldOutput && !intsContain(trace.Outputs, item.toolId) {
+			trace.Outputs = append(trace.Outputs, item.toolId)
+		}
+	}
+	return trace, nil
+}
+
+// traceFieldSource finds the incoming fields a field leaving the tool on the given anchor was made from.  A blank
+// anchor on a source means the field is read from every input.
+func traceFieldSource(node *ryxnode.RyxNode, anchor string, field string) (FieldAction, []fieldRef) {
+	if node.ReadCategory() == ryxnode.Macro {
+		return FieldMacro, nil
+	}
+	tool, err := toolconfig.Read(node)
+	if err != nil {
+		if node.ReadPlugin() == unionPlugin {
+			return FieldUnioned, []fieldRef{{field: field}}
+		}
+		if node.ReadPlugin() == browsePlugin {
+			return FieldPassed, []fieldRef{{field: field}}
+		}
+		return FieldUnsupported, []fieldRef{{field: field}}
+	}
+
+	switch config := tool.(type) {
+	case *toolconfig.InputData, *toolconfig.MacroInput:
+		return FieldCreated, nil
+	case *toolconfig.TextInput:
+		if stringsContainFold(config.Fields(), field) {
+			return FieldCreated, nil
+		}
+		return FieldNotFound, nil
+	case *toolconfig.Formula:
+		fields := config.Fields()
+		for index := len(fields) - 1; index >= 0; index-- {
+			if !strings.EqualFold(fields[index].Field, field) {
+				continue
+			}
+			uses := formulaInputs(fields[:index], fields[index].Expression)
+			if len(uses) == 0 {
+				return FieldCreated, nil
+			}
+			sources := []fieldRef{}
+			for _, use := range uses {
+				sources = append(sources, fieldRef{field: use})
+			}
+			return FieldDerived, sources
+		}
+		return FieldPassed, []fieldRef{{field: field}}
+	case *toolconfig.Select:
+		return traceSelectSource(config.Fields(), false, field)
+	case *toolconfig.Join:
+		if anchor == `Left` || anchor == `Right` {
+			return FieldPassed, []fieldRef{{anchor: anchor, field: field}}
+		}
+		return traceSelectSource(config.SelectFields(), true, field)
+	case *toolconfig.Summarize:
+		for _, entry := range config.Fields() {
+			if strings.EqualFold(firstNonBlank(entry.Rename, entry.Field), field) {
+				return FieldSummarized, []fieldRef{{field: entry.Field}}
+			}
+		}
+		return FieldNotFound, nil
+	case *toolconfig.Filter, *toolconfig.OutputData, *toolconfig.MacroOutput:
+		return FieldPassed, []fieldRef{{field: field}}
+	default:
+		return FieldUnsupported, []fieldRef{{field: field}}
+	}
+}
+
+// traceSelectSource finds the incoming field of a Select, or of a Join's select configuration.  Join fields are
+// listed with the prefix of the input they come from.
+func traceSelectSource(fields []toolconfig.SelectField, join bool, field string) (FieldAction, []fieldRef) {
+	passed := FieldPassed
+	if join {
+		passed = FieldJoined
+	}
+	listed := map[string]bool{}
+	unknown := false
+	for _, entry := range fields {
+		if entry.Field == `*Unknown` {
+			unknown = entry.Selected
+			continue
+		}
+		side, incoming := readSelectSide(entry, join)
+		if strings.EqualFold(incoming, field) {
+			listed[side] = true
+		}
+		if !entry.Selected || !strings.EqualFold(firstNonBlank(entry.Rename, entry.Field), field) {
+			continue
+		}
+		action := passed
+		if entry.Rename != `` && !strings.EqualFold(entry.Rename, entry.Field) {
+			action = FieldRenamed
+		}
+		if !join {
+			return action, []fieldRef{{field: entry.Field}}
+		}
+		if side == `` {
+			return action, []fieldRef{{anchor: `Left`, field: incoming}, {anchor: `Right`, field: incoming}}
+		}
+		return action, []fieldRef{{anchor: side, field: incoming}}
+	}
+	if !unknown {
+		return FieldNotFound, nil
+	}
+	if !join {
+		if listed[``] {
+			return FieldNotFound, nil
+		}
+		return passed, []fieldRef{{field: field}}
+	}
+	sources := []fieldRef{}
+	for _, side := range []string{`Left`, `Right`} {
+		if !listed[side] && !listed[``] {
+			sources = append(sources, fieldRef{anchor: side, field: field})
+		}
+	}
+	if len(field) > len(`Right_`) && strings.EqualFold(field[:len(`Right_`)], `Right_`) {
+		sources = append(sources, fieldRef{anchor: `Right`, field: field[len(`Right_`):]})
+	}
+	if len(sources) == 0 {
+		return FieldNotFound, nil
+	}
+	return passed, sources
+}
+
+// readSelectSide returns the Join input a select field comes from and its name on that input.  The side is blank
+// for Select tools and for Join fields that do not say which input they come from.
+func readSelectSide(entry toolconfig.SelectField, join bool) (string, string) {
+	if !join {
+		return ``, entry.Field
+	}
+	prefix := entry.Input
+	if prefix == `` {
+		for _, side := range []string{`Left_`, `Right_`} {
+			if len(entry.Field) > len(side) && strings.EqualFold(entry.Field[:len(side)], side) {
+				prefix = side
+			}
+		}
+	}
+	if prefix == `` {
+		return ``, entry.Field
+	}
+	incoming := entry.Field
+	if len(incoming) > len(prefix) && strings.EqualFold(incoming[:len(prefix)], prefix) {
+		incoming = incoming[len(prefix):]
+	}
+	return strings.TrimSuffix(prefix, `_`), incoming
+}
+
+// traceFieldTargets finds the fields a tool outputs from a field arriving on the given anchor.  A blank anchor on a
+// target means the field leaves on every output.
+func traceFieldTargets(node *ryxnode.RyxNode, anchor string, field string) (FieldAction, []fieldRef) {
+	if node.ReadCategory() == ryxnode.Macro {
+		return FieldMacro, nil
+	}
+	tool, err := toolconfig.Read(node)
+	if err != nil {
+		if node.ReadPlugin() == unionPlugin {
+			return FieldUnioned, []fieldRef{{field: field, action: FieldUnioned}}
+		}
+		if node.ReadPlugin() == browsePlugin {
+			return FieldOutput, nil
+		}
+		return FieldUnsupported, []fieldRef{{field: field, action: FieldUnsupported}}
+	}
+
+	switch config := tool.(type) {
+	case *toolconfig.OutputData, *toolconfig.MacroOutput:
+		return FieldOutput, nil
+	case *toolconfig.Formula:
+		targets := []fieldRef{}
+		replaced := false
+		fields := config.Fields()
+		for index, formula := range fields {
+			if stringsContainFold(formulaInputs(fields[:index], formula.Expression), field) {
+				targets = append(targets, fieldRef{field: formula.Field, action: FieldDerived})
+				continue
+			}
+			if strings.EqualFold(formula.Field, field) {
+				replaced = true
+			}
+		}
+		if !replaced && !refsContainFold(targets, field) {
+			targets = append([]fieldRef{{field: field, action: FieldPassed}}, targets...)
+		}
+		if len(targets) == 0 {
+			return FieldDropped, nil
+		}
+		return FieldPassed, targets
+	case *toolconfig.Filter:
+		return FieldPassed, []fieldRef{{field: field, action: FieldPassed}}
+	case *toolconfig.Select:
+		return traceSelectTargets(config.Fields(), ``, field)
+	case *toolconfig.Join:
+		targets := []fieldRef{{anchor: anchor, field: field, action: FieldPassed}}
+		_, joined := traceSelectTargets(config.SelectFields(), anchor, field)
+		for _, target := range joined {
+			target.anchor = `Join`
+			targets = append(targets, target)
+		}
+		return FieldJoined, targets
+	case *toolconfig.Summarize:
+		targets := []fieldRef{}
+		for _, entry := range config.Fields() {
+			if strings.EqualFold(entry.Field, field) {
+				targets = append(targets, fieldRef{field: firstNonBlank(entry.Rename, entry.Field), action: FieldSummarized})
+			}
+		}
+		if len(targets) == 0 {
+			return FieldDropped, nil
+		}
+		return FieldSummarized, targets
+	default:
+		return FieldUnsupported, []fieldRef{{field: field, action: FieldUnsupported}}
+	}
+}
+
+// traceSelectTargets finds the fields a Select outputs from an incoming field.  For Joins, side is the input the
+// field arrives on.
+func traceSelectTargets(fields []toolconfig.SelectField, side string, field string) (FieldAction, []fieldRef) {
+	passed := FieldPassed
+	if side != `` {
+		passed = FieldJoined
+	}
+	targets := []fieldRef{}
+	listed := false
+	unknown := false
+	for _, entry := range fields {
+		if entry.Field == `*Unknown` {
+			unknown = entry.Selected
+			continue
+		}
+		entrySide, incoming := readSelectSide(entry, side != ``)
+		if entrySide != `` && entrySide != side || !strings.EqualFold(incoming, field) {
+			continue
+		}
+		listed = true
+		if !entry.Selected {
+			continue
+		}
+		action := passed
+		if entry.Rename != `` && !strings.EqualFold(entry.Rename, entry.Field) {
+			action = FieldRenamed
+		}
+		targets = append(targets, fieldRef{field: firstNonBlank(entry.Rename, entry.Field), action: action})
+	}
+	if !listed && unknown {
+		targets = append(targets, fieldRef{field: field, action: passed})
+	}
+	if len(targets) == 0 {
+		return FieldDropped, nil
+	}
+	return passed, targets
+}
+
+// formulaInputs returns the incoming fields an expression reads.  References to fields calculated earlier in the same
+// Formula tool are followed back to the fields those were calculated from.
+func formulaInputs(earlier []toolconfig.FormulaField, expression string) []string {
+	inputs := []string{}
+	for _, match := range bracketReference.FindAllStringSubmatch(expression, -1) {
+		uses := []string{match[1]}
+		for index := len(earlier) - 1; index >= 0; index-- {
+			if strings.EqualFold(earlier[index].Field, match[1]) {
+				uses = formulaInputs(earlier[:index], earlier[index].Expression)
+				break
+			}
+		}
+		for _, use := range uses {
+			if !stringsContainFold(inputs, use) {
+				inputs = append(inputs, use)
+			}
+		}
+	}
+	return inputs
+}
+
+func stringsContainFold(values []string, check string) bool {
+	for _, value := range values {
+		if strings.EqualFold(value, check) {
+			return true
+		}
+	}
+	return false
+}
+
+func refsContainFold(refs []fieldRef, check string) bool {
+	for _, ref := range refs {
+		if strings.EqualFold(ref.field, check) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,125 @@
+package ryxdoc
+
+import (
+	"fmt"
+	"github.com/tlarsen7572/Golang-Public/ryx/ryxnode"
+	"sort"
+	"strconv"
+)
+
+type ValidationIssue struct {
+	ToolId  string
+	Problem string
+}
+
+// AnchorReader returns the names of a macro's input and output anchors.  It is passed to Validate so the macro can
+// be read with tool_data_loader, which already depends on this package.
+type AnchorReader func(macroPath string) (inputs []string, outputs []string, err error)
+
+// Validate reports structural problems in the document: missing or duplicated tool IDs, tool IDs that are not
+// numbers, tools without a position, and connections to tools that do not exist.  If readAnchors is provided, the
+// connections into and out of macro tools are also checked against the anchors of the macro.
+func (ryxDoc *RyxDoc) Validate(readAnchors AnchorReader, macroPaths ...string) []*ValidationIssue {
+	issues := []*ValidationIssue{}
+	counts := map[string]int{}
+	var walk func(nodes []*ryxnode.RyxNode)
+	walk = func(nodes []*ryxnode.RyxNode) {
+		for _, node := range nodes {
+			counts[node.ToolId]++
+			issues = append(issues, validateNode(node)...)
+			walk(node.ChildNodes)
+		}
+	}
+	walk(ryxDoc.Nodes) // It is ok to use RyxDoc.Nodes here
+
+	duplicates := []string{}
+	for toolId, count := range counts {
+		if count > 1 {
+			duplicates = append(duplicates, toolId)
+		}
+	}
+	sort.Strings(duplicates)
+	for _, toolId := range duplicates {
+		issues = append(issues, &ValidationIssue{ToolId: toolId, Problem: fmt.Sprintf(`the tool ID is used by %v tools`, counts[toolId])})
+	}
+
+	nodes := ryxDoc.ReadMappedNodes()
+	for _, conn := range ryxDoc.Connections {
+		for _, id := range []int{conn.FromId, conn.ToId} {
+			if _, ok := nodes[id]; !ok {
+				issues = append(issues, &ValidationIssue{
+					ToolId:  strconv.Itoa(id),
+					Problem: fmt.Sprintf(`connection %v refers to a tool that does not exist`, describeConnection(conn)),
+				})
+			}
+		}
+	}
+
+	if readAnchors != nil {
+		issues = append(issues, ryxDoc.validateMacroAnchors(nodes, readAnchors, macroPaths...)...)
+	}
+	return issues
+}
+
+func validateNode(node *ryxnode.RyxNode) []*ValidationIssue {
+	issues := []*ValidationIssue{}
+	if node.ToolId == `` {
+		issues = append(issues, &ValidationIssue{Problem: `a tool does not have a tool ID`})
+	} else if _, err := node.ReadId(); err != nil {
+		issues = append(issues, &ValidationIssue{ToolId: node.ToolId, Problem: `the tool ID is not a number`})
+	}
+	if node.GuiSettings == nil || node.GuiSettings.IsNil() {
+		issues = append(issues, &ValidationIssue{ToolId: node.ToolId, Problem: `the tool does not have GuiSettings`})
+		return issues
+	}
+	if node.GuiSettings.First(`Position`).Name == `` {
+		issues = append(issues, &ValidationIssue{ToolId: node.ToolId, Problem: `the tool does not have a position`})
+	} else if _, err := node.ReadPosition(); err != nil {
+		issues = append(issues, &ValidationIssue{ToolId: node.ToolId, Problem: `the tool's position is not valid`})
+	}
+	return issues
+}
+
+func (ryxDoc *RyxDoc) validateMacroAnchors(nodes map[int]*ryxnode.RyxNode, readAnchors AnchorReader, macroPaths ...string) []*ValidationIssue {
+	issues := []*ValidationIssue{}
+	for _, id := range sortedIds(nodes) {
+		node := nodes[id]
+		if node.ReadCategory() != ryxnode.Macro {
+			continue
+		}
+		macro := node.ReadMacro(macroPaths...)
+		if macro.FoundPath == `` {
+			issues = append(issues, &ValidationIssue{ToolId: node.ToolId, Problem: fmt.Sprintf(`the macro '%v' could not be found`, macro.StoredPath)})
+			continue
+		}
+		inputs, outputs, err := readAnchors(macro.FoundPath)
+		if err != nil {
+			issues = append(issues, &ValidationIssue{ToolId: node.ToolId, Problem: fmt.Sprintf(`the macro '%v' could not be read: %v`, macro.FoundPath, err.Error())})
+			continue
+		}
+		for _, conn := range ryxDoc.Connections {
+			if conn.ToId == id && !stringsContain(inputs, conn.ToAnchor) {
+				issues = append(issues, &ValidationIssue{
+					ToolId:  node.ToolId,
+					Problem: fmt.Sprintf(`connection %v goes into anchor '%v', which the macro does not have`, describeConnection(conn), conn.ToAnchor),
+				})
+			}
+			if conn.FromId == id && !stringsContain(outputs, conn.FromAnchor) {
+				issues = append(issues, &ValidationIssue{
+					ToolId:  node.ToolId,
+					Problem: fmt.Sprintf(`connection %v comes out of anchor '%v', which the macro does not have`, describeConnection(conn), conn.FromAnchor),
+				})
+			}
+		}
+	}
+	return issues
+}
+
+func stringsContain(values []string, check string) bool {
+	for _, value := range values {
+		if value == check {
+			return true
+		}
+	}
+	return false
+}
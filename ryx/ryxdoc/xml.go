@@ -7,8 +7,8 @@ import (
 
 type _RyxConnXml struct {
 	XMLName  xml.Name `xml:"Connection"`
-	Name     string   `xml:"name,attr"`
-	Wireless bool     `xml:"Wireless,attr"`
+	Name     string   `xml:"name,attr,omitempty"`
+	Wireless string   `xml:"Wireless,attr,omitempty"`
 	Origin   struct {
 		ToolId     string `xml:"ToolID,attr"`
 		Connection string `xml:"Connection,attr"`
@@ -21,9 +21,13 @@ type _RyxConnXml struct {
 
 func (ryxConn *RyxConn) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
 	start.Name.Local = `Connection`
+	wireless := ``
+	if ryxConn.Wireless {
+		wireless = `True`
+	}
 	container := _RyxConnXml{
 		Name:     ryxConn.Name,
-		Wireless: ryxConn.Wireless,
+		Wireless: wireless,
 		Origin: struct {
 			ToolId     string `xml:"ToolID,attr"`
 			Connection string `xml:"Connection,attr"`
@@ -58,7 +62,7 @@ func (ryxConn *RyxConn) UnmarshalXML(d *xml.Decoder, start xml.StartElement) err
 	}
 
 	ryxConn.Name = container.Name
-	ryxConn.Wireless = container.Wireless
+	ryxConn.Wireless, _ = strconv.ParseBool(container.Wireless)
 	ryxConn.FromId = fromId
 	ryxConn.FromAnchor = container.Origin.Connection
 	ryxConn.ToId = toId
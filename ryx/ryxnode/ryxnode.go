@@ -1,8 +1,10 @@
 package ryxnode
 
 import (
+	"bytes"
 	"encoding/xml"
 	"errors"
+	"fmt"
 	h "github.com/tlarsen7572/Golang-Public/helpers"
 	"github.com/tlarsen7572/Golang-Public/txml"
 	"os"
@@ -97,7 +99,20 @@ func (ryxNode *RyxNode) ReadPlugin() string {
 
 func (ryxNode *RyxNode) SetPosition(x float64, y float64) {
 	setting := ryxNode.GuiSettings.First(`Position`)
-	setting.Attributes = map[string]string{`x`: h.DblToStr(x, 0), `y`: h.DblToStr(y, 0)}
+	if setting.Attributes == nil {
+		setting.Attributes = map[string]string{}
+	}
+	setting.Attributes[`x`] = h.DblToStr(x, 0)
+	setting.Attributes[`y`] = h.DblToStr(y, 0)
+}
+
+func (ryxNode *RyxNode) SetSize(width float64, height float64) {
+	setting := ryxNode.GuiSettings.First(`Position`)
+	if setting.Attributes == nil {
+		setting.Attributes = map[string]string{}
+	}
+	setting.Attributes[`width`] = h.DblToStr(width, 0)
+	setting.Attributes[`height`] = h.DblToStr(height, 0)
 }
 
 func (ryxNode *RyxNode) ReadMacro(macroPaths ...string) MacroPath {
@@ -197,6 +212,7 @@ func (cat Category) String() string {
 }
 
 func NewMacro(id int, path string, x float64, y float64) *RyxNode {
+	winPath := strings.Replace(path, string(os.PathSeparator), `\`, -1)
 	return &RyxNode{
 		ToolId: strconv.Itoa(id),
 		GuiSettings: &txml.Node{
@@ -226,12 +242,74 @@ func NewMacro(id int, path string, x float64, y float64) *RyxNode {
 		EngineSettings: &txml.Node{
 			Name: `EngineSettings`,
 			Attributes: map[string]string{
-				`Macro`: path,
+				`Macro`: winPath,
 			},
 		},
 	}
 }
 
+// NewContainer creates a Tool Container.  The color is used for the caption and border, and a lighter shade of it
+// fills the container.  Colors are given as #rrggbb.
+func NewContainer(id int, caption string, color string, x float64, y float64, width float64, height float64) (*RyxNode, error) {
+	fill, err := lightenColor(color)
+	if err != nil {
+		return nil, err
+	}
+	buffer := &bytes.Buffer{}
+	_ = xml.EscapeText(buffer, []byte(caption))
+	config := `<Caption>` + buffer.String() + `</Caption>` +
+		`<Style TextColor="` + color + `" FillColor="` + fill + `" BorderColor="` + color + `" Transparency="25" Margin="25" />` +
+		`<Disabled value="False" />` +
+		`<Folded value="False" />`
+	return &RyxNode{
+		ToolId: strconv.Itoa(id),
+		GuiSettings: &txml.Node{
+			Name:       "GuiSettings",
+			Attributes: map[string]string{`Plugin`: `AlteryxGuiToolkit.ToolContainer.ToolContainer`},
+			Nodes: []*txml.Node{
+				{
+					Name: `Position`,
+					Attributes: map[string]string{
+						`x`:      h.DblToStr(x, 0),
+						`y`:      h.DblToStr(y, 0),
+						`width`:  h.DblToStr(width, 0),
+						`height`: h.DblToStr(height, 0),
+					},
+				},
+			},
+		},
+		Properties: &Properties{
+			Configuration: Configuration{InnerXml: config},
+			Annotation: &txml.Node{
+				Name:       `Annotation`,
+				Attributes: map[string]string{`DisplayMode`: `0`},
+				Nodes: []*txml.Node{
+					{Name: `Name`},
+					{Name: `DefaultAnnotationText`},
+					{Name: `Left`, Attributes: map[string]string{`value`: `False`}},
+				},
+			},
+		},
+		EngineSettings: txml.NilNode(),
+	}, nil
+}
+
+func lightenColor(color string) (string, error) {
+	if len(color) != 7 || color[0] != '#' {
+		return ``, errors.New(`colors must be in the format #rrggbb`)
+	}
+	rgb, err := strconv.ParseUint(color[1:], 16, 32)
+	if err != nil {
+		return ``, errors.New(`colors must be in the format #rrggbb`)
+	}
+	fill := `#`
+	for shift := 16; shift >= 0; shift -= 8 {
+		channel := (rgb >> uint(shift)) & 0xff
+		fill += fmt.Sprintf(`%02x`, channel+(0xff-channel)*9/10)
+	}
+	return fill, nil
+}
+
 func GenerateNodeFromXml(xmlString string) (*RyxNode, error) {
 	ayxNode := &RyxNode{}
 	err := xml.Unmarshal([]byte(xmlString), ayxNode)
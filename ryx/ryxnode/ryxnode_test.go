@@ -261,6 +261,30 @@ func TestSetPosition(t *testing.T) {
 	}
 }
 
+func TestSetPositionKeepsSize(t *testing.T) {
+	node, _ := ryxnode.GenerateNodeFromXml(container)
+	node.SetPosition(2, 4)
+	pos, _ := node.ReadPosition()
+	if pos.X != 2 || pos.Y != 4 {
+		t.Fatalf(`expected position of 2,4 but got %v,%v`, pos.X, pos.Y)
+	}
+	if pos.Width != 405.0632 || pos.Height != 188 {
+		t.Fatalf(`expected size of 405.0632,188 but got %v,%v`, pos.Width, pos.Height)
+	}
+}
+
+func TestSetSize(t *testing.T) {
+	node, _ := ryxnode.GenerateNodeFromXml(container)
+	node.SetSize(100, 50)
+	pos, _ := node.ReadPosition()
+	if pos.Width != 100 || pos.Height != 50 {
+		t.Fatalf(`expected size of 100,50 but got %v,%v`, pos.Width, pos.Height)
+	}
+	if pos.X != 269 || pos.Y != 292 {
+		t.Fatalf(`expected position of 269,292 but got %v,%v`, pos.X, pos.Y)
+	}
+}
+
 func TestSetPositionOfInvalidRyxNode(t *testing.T) {
 	node, _ := ryxnode.GenerateNodeFromXml(`<Node ToolID="1"></Node>`)
 	node.SetPosition(2, 4)
@@ -334,6 +358,34 @@ func TestNewMacroXmlNode(t *testing.T) {
 	}
 }
 
+func TestNewContainer(t *testing.T) {
+	container, err := ryxnode.NewContainer(5, `My <Container>`, `#314c4a`, 10, 20, 300, 200)
+	if err != nil {
+		t.Fatalf(`expected no error but got: %v`, err.Error())
+	}
+	if category := container.ReadCategory(); category != ryxnode.Container {
+		t.Fatalf(`expected a container but got %v`, category)
+	}
+	position, _ := container.ReadPosition()
+	if position.X != 10 || position.Y != 20 || position.Width != 300 || position.Height != 200 {
+		t.Fatalf(`expected position 10,20,300,200 but got %v`, position)
+	}
+	config := container.Properties.Configuration.InnerXml
+	if !strings.Contains(config, `<Caption>My &lt;Container&gt;</Caption>`) {
+		t.Fatalf(`expected an escaped caption but got %v`, config)
+	}
+	if !strings.Contains(config, `FillColor="#eaedec"`) {
+		t.Fatalf(`expected a lighter fill color but got %v`, config)
+	}
+}
+
+func TestNewContainerWithInvalidColor(t *testing.T) {
+	_, err := ryxnode.NewContainer(5, `Caption`, `blue`, 10, 20, 300, 200)
+	if err == nil {
+		t.Fatalf(`expected an error but got none`)
+	}
+}
+
 func TestRemoveChildren(t *testing.T) {
 	node, _ := ryxnode.GenerateNodeFromXml(container)
 	node.RemoveChildren(21, 23)
@@ -0,0 +1,195 @@
+package toolconfig
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"github.com/tlarsen7572/Golang-Public/ryx/ryxnode"
+	"github.com/tlarsen7572/Golang-Public/txml"
+	"strings"
+)
+
+const InputDataPlugin = `AlteryxBasePluginsGui.DbFileInput.DbFileInput`
+const OutputDataPlugin = `AlteryxBasePluginsGui.DbFileOutput.DbFileOutput`
+const FormulaPlugin = `AlteryxBasePluginsGui.Formula.Formula`
+const FilterPlugin = `AlteryxBasePluginsGui.Filter.Filter`
+const SelectPlugin = `AlteryxBasePluginsGui.AlteryxSelect.AlteryxSelect`
+const JoinPlugin = `AlteryxBasePluginsGui.Join.Join`
+const SummarizePlugin = `AlteryxSpatialPluginsGui.Summarize.Summarize`
+const TextInputPlugin = `AlteryxBasePluginsGui.TextInput.TextInput`
+const MacroInputPlugin = `AlteryxBasePluginsGui.MacroInput.MacroInput`
+const MacroOutputPlugin = `AlteryxBasePluginsGui.MacroOutput.MacroOutput`
+const ControlParameterPlugin = `AlteryxGuiToolkit.Questions.ControlParam.ControlParam`
+
+// Config is a tool's parsed Configuration element.  The typed models built on it read and write the elements they
+// know about and leave everything else in the configuration untouched.  Every change is written straight back to the
+// node.
+type Config struct {
+	node *ryxnode.RyxNode
+	Xml  *txml.Node
+}
+
+// Read returns the typed model for the node's plugin: *InputData, *OutputData, *Formula, *Filter, *Select, *Join,
+// *Summarize, *TextInput, *MacroInput, *MacroOutput or *ControlParameter.  An error is returned for any other plugin.
+func Read(node *ryxnode.RyxNode) (interface{}, error) {
+	config, err := ReadConfig(node)
+	if err != nil {
+		return nil, err
+	}
+	switch node.ReadPlugin() {
+	case InputDataPlugin:
+		return &InputData{config}, nil
+	case OutputDataPlugin:
+		return &OutputData{config}, nil
+	case FormulaPlugin:
+		return &Formula{config}, nil
+	case FilterPlugin:
+		return &Filter{config}, nil
+	case SelectPlugin:
+		return &Select{config}, nil
+	case JoinPlugin:
+		return &Join{config}, nil
+	case SummarizePlugin:
+		return &Summarize{config}, nil
+	case TextInputPlugin:
+		return &TextInput{config}, nil
+	case MacroInputPlugin:
+		return &MacroInput{config}, nil
+	case MacroOutputPlugin:
+		return &MacroOutput{config}, nil
+	case ControlParameterPlugin:
+		return &ControlParameter{config}, nil
+	default:
+		return nil, errors.New(fmt.Sprintf(`there is no configuration model for plugin '%v'`, node.ReadPlugin()))
+	}
+}
+
+// ReadConfig parses the node's configuration without choosing a model.
+func ReadConfig(node *ryxnode.RyxNode) (*Config, error) {
+	if node.Properties == nil {
+		return nil, errors.New(`the tool does not have any properties`)
+	}
+	parsed, err := txml.Parse(`<Configuration>` + node.Properties.Configuration.InnerXml + `</Configuration>`)
+	if err != nil {
+		return nil, err
+	}
+	return &Config{node: node, Xml: parsed}, nil
+}
+
+// Save writes the parsed configuration back to the node.  The typed models call it after every change, so it is only
+// needed after editing Xml directly.
+func (config *Config) Save() {
+	buffer := &bytes.Buffer{}
+	for _, child := range config.Xml.Nodes {
+		content, err := xml.Marshal(child)
+		if err != nil {
+			continue
+		}
+		buffer.Write(content)
+	}
+	config.node.Properties.Configuration.InnerXml = buffer.String()
+}
+
+func (config *Config) readText(path ...string) string {
+	return ReadText(find(config.Xml, path...))
+}
+
+func (config *Config) setText(value string, path ...string) {
+	element := findOrAdd(config.Xml, path...)
+	element.Nodes = nil
+	element.InnerText = escapeText(value)
+	config.Save()
+}
+
+func (config *Config) readValue(path ...string) bool {
+	return strings.EqualFold(find(config.Xml, path...).Attributes[`value`], `True`)
+}
+
+func (config *Config) setValue(value bool, path ...string) {
+	setAttr(findOrAdd(config.Xml, path...), `value`, boolText(value))
+	config.Save()
+}
+
+// ReadText returns the element's text with XML entities and CDATA sections decoded.
+func ReadText(element *txml.Node) string {
+	if element.InnerText == `` {
+		return ``
+	}
+	decoded := &struct {
+		Text string `xml:",chardata"`
+	}{}
+	err := xml.Unmarshal([]byte(`<Text>`+element.InnerText+`</Text>`), decoded)
+	if err != nil {
+		return element.InnerText
+	}
+	return decoded.Text
+}
+
+func escapeText(value string) string {
+	buffer := &bytes.Buffer{}
+	_ = xml.EscapeText(buffer, []byte(value))
+	return buffer.String()
+}
+
+func find(element *txml.Node, path ...string) *txml.Node {
+	for _, name := range path {
+		element = element.First(name)
+	}
+	return element
+}
+
+func findOrAdd(element *txml.Node, path ...string) *txml.Node {
+	for _, name := range path {
+		child := element.First(name)
+		if child.Name == `` {
+			child = &txml.Node{Name: name, Attributes: map[string]string{}}
+			element.Nodes = append(element.Nodes, child)
+		}
+		element = child
+	}
+	return element
+}
+
+// resizeList makes the list element hold exactly count items and returns them.  Existing items are reused in order
+// so that any attributes or children the models do not know about are kept.
+func resizeList(list *txml.Node, itemName string, count int) []*txml.Node {
+	items := list.AllNodes(itemName)
+	for len(items) < count {
+		item := &txml.Node{Name: itemName, Attributes: map[string]string{}}
+		items = append(items, item)
+	}
+	items = items[:count]
+	kept := []*txml.Node{}
+	added := false
+	for _, child := range list.Nodes {
+		if child.Name != itemName {
+			kept = append(kept, child)
+			continue
+		}
+		if !added {
+			kept = append(kept, items...)
+			added = true
+		}
+	}
+	if !added {
+		kept = append(kept, items...)
+	}
+	list.Nodes = kept
+	list.InnerText = ``
+	return items
+}
+
+func setAttr(element *txml.Node, name string, value string) {
+	if element.Attributes == nil {
+		element.Attributes = map[string]string{}
+	}
+	element.Attributes[name] = value
+}
+
+func boolText(value bool) string {
+	if value {
+		return `True`
+	}
+	return `False`
+}
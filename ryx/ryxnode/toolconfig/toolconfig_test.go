@@ -0,0 +1,230 @@
+package toolconfig_test
+
+import (
+	"github.com/tlarsen7572/Golang-Public/ryx/ryxnode"
+	"github.com/tlarsen7572/Golang-Public/ryx/ryxnode/toolconfig"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func generateTool(plugin string, config string) *ryxnode.RyxNode {
+	node, err := ryxnode.GenerateNodeFromXml(`<Node ToolID="1">
+	<GuiSettings Plugin="` + plugin + `">
+		<Position x="54" y="54" />
+	</GuiSettings>
+	<Properties>
+		<Configuration>` + config + `</Configuration>
+		<Annotation DisplayMode="0">
+			<Name />
+			<DefaultAnnotationText />
+			<Left value="False" />
+		</Annotation>
+	</Properties>
+	<EngineSettings EngineDll="AlteryxBasePluginsEngine.dll" EngineDllEntryPoint="Test" />
+</Node>`)
+	if err != nil {
+		panic(err.Error())
+	}
+	return node
+}
+
+func reread(node *ryxnode.RyxNode) interface{} {
+	tool, err := toolconfig.Read(node)
+	if err != nil {
+		panic(err.Error())
+	}
+	return tool
+}
+
+func TestReadInputData(t *testing.T) {
+	node := generateTool(toolconfig.InputDataPlugin, `<Passwords /><File OutputFileName="" RecordLimit="" FileFormat="0">C:\Data\Sales &amp; Costs.csv</File>`)
+	tool := reread(node).(*toolconfig.InputData)
+	if file := tool.File(); file != `C:\Data\Sales & Costs.csv` {
+		t.Fatalf(`expected 'C:\Data\Sales & Costs.csv' but got '%v'`, file)
+	}
+	tool.SetFile(`D:\Other & More.csv`)
+	if file := reread(node).(*toolconfig.InputData).File(); file != `D:\Other & More.csv` {
+		t.Fatalf(`expected 'D:\Other & More.csv' but got '%v'`, file)
+	}
+	config := node.Properties.Configuration.InnerXml
+	if !strings.Contains(config, `<Passwords></Passwords>`) || !strings.Contains(config, `RecordLimit=""`) {
+		t.Fatalf(`expected the unknown elements and attributes to be kept but got %v`, config)
+	}
+}
+
+func TestFormulaFields(t *testing.T) {
+	node := generateTool(toolconfig.FormulaPlugin, `<FormulaFields><FormulaField expression="[A] &gt; 1" field="Big" size="16" type="Bool" /></FormulaFields><Unknown value="x" />`)
+	tool := reread(node).(*toolconfig.Formula)
+	expected := []toolconfig.FormulaField{{Field: `Big`, Expression: `[A] > 1`, Type: `Bool`, Size: `16`}}
+	if fields := tool.Fields(); !reflect.DeepEqual(fields, expected) {
+		t.Fatalf(`expected %v but got %v`, expected, fields)
+	}
+	expected = append(expected, toolconfig.FormulaField{Field: `Label`, Expression: `"a" + "b"`, Type: `V_WString`, Size: `100`})
+	tool.SetFields(expected)
+	if fields := reread(node).(*toolconfig.Formula).Fields(); !reflect.DeepEqual(fields, expected) {
+		t.Fatalf(`expected %v but got %v`, expected, fields)
+	}
+	if !strings.Contains(node.Properties.Configuration.InnerXml, `<Unknown value="x"></Unknown>`) {
+		t.Fatalf(`expected the unknown element to be kept but got %v`, node.Properties.Configuration.InnerXml)
+	}
+}
+
+func TestFilterExpression(t *testing.T) {
+	node := generateTool(toolconfig.FilterPlugin, `<Mode>Simple</Mode><Simple><Operator>=</Operator><Field>A</Field></Simple><Expression>[A] = 1</Expression>`)
+	tool := reread(node).(*toolconfig.Filter)
+	if mode := tool.Mode(); mode != `Simple` {
+		t.Fatalf(`expected Simple mode but got %v`, mode)
+	}
+	tool.SetExpression(`[A] < 1 && [B] = "x"`)
+	tool = reread(node).(*toolconfig.Filter)
+	if expression := tool.Expression(); expression != `[A] < 1 && [B] = "x"` {
+		t.Fatalf(`expected the new expression but got '%v'`, expression)
+	}
+	if mode := tool.Mode(); mode != `Custom` {
+		t.Fatalf(`expected Custom mode but got %v`, mode)
+	}
+	if !strings.Contains(node.Properties.Configuration.InnerXml, `<Field>A</Field>`) {
+		t.Fatalf(`expected the simple settings to be kept but got %v`, node.Properties.Configuration.InnerXml)
+	}
+	tool.SetSimpleField(`A & B`)
+	if field := reread(node).(*toolconfig.Filter).SimpleField(); field != `A & B` {
+		t.Fatalf(`expected 'A & B' but got '%v'`, field)
+	}
+	if !strings.Contains(node.Properties.Configuration.InnerXml, `<Field>A &amp; B</Field>`) {
+		t.Fatalf(`expected the simple field to be escaped but got %v`, node.Properties.Configuration.InnerXml)
+	}
+}
+
+func TestSelectFields(t *testing.T) {
+	node := generateTool(toolconfig.SelectPlugin, `<OrderChanged value="False" /><SelectFields><SelectField field="A" selected="True" rename="B" description="keep me" /><SelectField field="*Unknown" selected="True" /></SelectFields>`)
+	tool := reread(node).(*toolconfig.Select)
+	fields := tool.Fields()
+	if len(fields) != 2 || fields[0].Rename != `B` || !fields[1].Selected {
+		t.Fatalf(`expected 2 fields with A renamed to B but got %v`, fields)
+	}
+	fields[0].Rename = ``
+	fields[0].Selected = false
+	tool.SetFields(fields)
+	config := node.Properties.Configuration.InnerXml
+	if strings.Contains(config, `rename=`) || !strings.Contains(config, `selected="False"`) || !strings.Contains(config, `description="keep me"`) {
+		t.Fatalf(`expected the rename to be removed and the description kept but got %v`, config)
+	}
+}
+
+func TestJoinFields(t *testing.T) {
+	node := generateTool(toolconfig.JoinPlugin, `<JoinInfo connection="Left"><Field field="A" /></JoinInfo><JoinInfo connection="Right"><Field field="B" /></JoinInfo><SelectConfiguration><Configuration outputConnection="Join"><OrderChanged value="False" /><SelectFields><SelectField field="Right_B" selected="False" input="Right_" /><SelectField field="*Unknown" selected="True" /></SelectFields></Configuration></SelectConfiguration>`)
+	tool := reread(node).(*toolconfig.Join)
+	if left, right := tool.LeftFields(), tool.RightFields(); !reflect.DeepEqual(left, []string{`A`}) || !reflect.DeepEqual(right, []string{`B`}) {
+		t.Fatalf(`expected A joined to B but got %v and %v`, left, right)
+	}
+	if count := len(tool.SelectFields()); count != 2 {
+		t.Fatalf(`expected 2 select fields but got %v`, count)
+	}
+	err := tool.SetJoinFields([]string{`A`, `C`}, []string{`B`})
+	if err == nil {
+		t.Fatalf(`expected an error for mismatched join fields but got none`)
+	}
+	err = tool.SetJoinFields([]string{`A`, `C`}, []string{`B`, `D`})
+	if err != nil {
+		t.Fatalf(`expected no error but got: %v`, err.Error())
+	}
+	tool = reread(node).(*toolconfig.Join)
+	if right := tool.RightFields(); !reflect.DeepEqual(right, []string{`B`, `D`}) {
+		t.Fatalf(`expected right fields B and D but got %v`, right)
+	}
+	if fields := tool.SelectFields(); len(fields) != 2 || fields[0].Field != `Right_B` {
+		t.Fatalf(`expected the select fields to be kept but got %v`, fields)
+	}
+	if !strings.Contains(node.Properties.Configuration.InnerXml, `input="Right_"`) {
+		t.Fatalf(`expected unknown select attributes to be kept but got %v`, node.Properties.Configuration.InnerXml)
+	}
+}
+
+func TestSummarizeFields(t *testing.T) {
+	node := generateTool(toolconfig.SummarizePlugin, `<SummarizeFields><SummarizeField field="A" action="GroupBy" rename="A" /><SummarizeField field="Filter" action="Concat" rename="Filter"><Concat_Start>(</Concat_Start><Separator><![CDATA[ || ]]></Separator><Concat_End>)</Concat_End></SummarizeField></SummarizeFields>`)
+	tool := reread(node).(*toolconfig.Summarize)
+	fields := tool.Fields()
+	if len(fields) != 2 || fields[1].Action != `Concat` {
+		t.Fatalf(`expected 2 fields ending with a Concat but got %v`, fields)
+	}
+	fields[1].Rename = `Filters`
+	tool.SetFields(fields)
+	if fields := reread(node).(*toolconfig.Summarize).Fields(); fields[1].Rename != `Filters` {
+		t.Fatalf(`expected the rename to be Filters but got %v`, fields[1].Rename)
+	}
+	if !strings.Contains(node.Properties.Configuration.InnerXml, `<![CDATA[ || ]]>`) {
+		t.Fatalf(`expected the concat settings to be kept but got %v`, node.Properties.Configuration.InnerXml)
+	}
+}
+
+func TestTextInputData(t *testing.T) {
+	node := generateTool(toolconfig.TextInputPlugin, `<NumRows value="1" /><Fields><Field name="A" /><Field name="B" /></Fields><Data><r><c>1</c><c>x &amp; y</c></r></Data>`)
+	tool := reread(node).(*toolconfig.TextInput)
+	if fields := tool.Fields(); !reflect.DeepEqual(fields, []string{`A`, `B`}) {
+		t.Fatalf(`expected fields A and B but got %v`, fields)
+	}
+	if rows := tool.Rows(); !reflect.DeepEqual(rows, [][]string{{`1`, `x & y`}}) {
+		t.Fatalf(`expected one row but got %v`, rows)
+	}
+	err := tool.SetData([]string{`C`}, [][]string{{`1`, `2`}})
+	if err == nil {
+		t.Fatalf(`expected an error for a row with too many values but got none`)
+	}
+	err = tool.SetData([]string{`C`}, [][]string{{`<1>`}, {`2`}})
+	if err != nil {
+		t.Fatalf(`expected no error but got: %v`, err.Error())
+	}
+	tool = reread(node).(*toolconfig.TextInput)
+	if rows := tool.Rows(); !reflect.DeepEqual(rows, [][]string{{`<1>`}, {`2`}}) {
+		t.Fatalf(`expected two rows but got %v`, rows)
+	}
+	if !strings.Contains(node.Properties.Configuration.InnerXml, `<NumRows value="2">`) {
+		t.Fatalf(`expected NumRows to be updated but got %v`, node.Properties.Configuration.InnerXml)
+	}
+}
+
+func TestMacroInputAndOutput(t *testing.T) {
+	node := generateTool(toolconfig.MacroInputPlugin, `<UseFileInput value="False" /><Name>Input2</Name><Abbrev /><ShowFieldMap value="False" /><Optional value="False" />`)
+	input := reread(node).(*toolconfig.MacroInput)
+	if name := input.Name(); name != `Input2` {
+		t.Fatalf(`expected Input2 but got %v`, name)
+	}
+	input.SetName(`Data`)
+	input.SetAbbrev(`D`)
+	input.SetOptional(true)
+	input = reread(node).(*toolconfig.MacroInput)
+	if input.Name() != `Data` || input.Abbrev() != `D` || !input.Optional() {
+		t.Fatalf(`expected Data, D and optional but got %v, %v and %v`, input.Name(), input.Abbrev(), input.Optional())
+	}
+
+	node = generateTool(toolconfig.MacroOutputPlugin, `<Name>Output</Name><Abbrev />`)
+	output := reread(node).(*toolconfig.MacroOutput)
+	output.SetName(`Result`)
+	if name := reread(node).(*toolconfig.MacroOutput).Name(); name != `Result` {
+		t.Fatalf(`expected Result but got %v`, name)
+	}
+}
+
+func TestControlParameterLabel(t *testing.T) {
+	node := generateTool(toolconfig.ControlParameterPlugin, ``)
+	tool := reread(node).(*toolconfig.ControlParameter)
+	if label := tool.Label(); label != `` {
+		t.Fatalf(`expected no label but got %v`, label)
+	}
+	tool.SetLabel(`Set Name`)
+	if label := reread(node).(*toolconfig.ControlParameter).Label(); label != `Set Name` {
+		t.Fatalf(`expected 'Set Name' but got '%v'`, label)
+	}
+}
+
+func TestReadUnknownPlugin(t *testing.T) {
+	node := generateTool(`AlteryxBasePluginsGui.BrowseV2.BrowseV2`, ``)
+	_, err := toolconfig.Read(node)
+	if err == nil {
+		t.Fatalf(`expected an error but got none`)
+	}
+	if _, err := toolconfig.ReadConfig(node); err != nil {
+		t.Fatalf(`expected the raw configuration to be readable but got: %v`, err.Error())
+	}
+}
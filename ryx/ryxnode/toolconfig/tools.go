@@ -0,0 +1,345 @@
+package toolconfig
+
+import (
+	"errors"
+	"fmt"
+	"github.com/tlarsen7572/Golang-Public/txml"
+	"strconv"
+	"strings"
+)
+
+type InputData struct{ *Config }
+
+type OutputData struct{ *Config }
+
+type Formula struct{ *Config }
+
+type Filter struct{ *Config }
+
+type Select struct{ *Config }
+
+type Join struct{ *Config }
+
+type Summarize struct{ *Config }
+
+type TextInput struct{ *Config }
+
+type MacroInput struct{ *Config }
+
+type MacroOutput struct{ *Config }
+
+type ControlParameter struct{ *Config }
+
+type FormulaField struct {
+	Field      string
+	Expression string
+	Type       string
+	Size       string
+}
+
+type SelectField struct {
+	Field    string
+	Selected bool
+	Rename   string
+	Type     string
+	Size     string
+	Input    string
+}
+
+type SummarizeField struct {
+	Field  string
+	Action string
+	Rename string
+}
+
+// File returns the stored file.  Database connections keep the table after a '|||' separator.
+func (tool *InputData) File() string {
+	return tool.readText(`File`)
+}
+
+func (tool *InputData) SetFile(file string) {
+	tool.setText(file, `File`)
+}
+
+func (tool *OutputData) File() string {
+	return tool.readText(`File`)
+}
+
+func (tool *OutputData) SetFile(file string) {
+	tool.setText(file, `File`)
+}
+
+func (tool *Formula) Fields() []FormulaField {
+	fields := []FormulaField{}
+	for _, item := range tool.Xml.First(`FormulaFields`).AllNodes(`FormulaField`) {
+		fields = append(fields, FormulaField{
+			Field:      item.Attributes[`field`],
+			Expression: item.Attributes[`expression`],
+			Type:       item.Attributes[`type`],
+			Size:       item.Attributes[`size`],
+		})
+	}
+	return fields
+}
+
+func (tool *Formula) SetFields(fields []FormulaField) {
+	items := resizeList(findOrAdd(tool.Xml, `FormulaFields`), `FormulaField`, len(fields))
+	for index, field := range fields {
+		setAttr(items[index], `expression`, field.Expression)
+		setAttr(items[index], `field`, field.Field)
+		setAttr(items[index], `size`, field.Size)
+		setAttr(items[index], `type`, field.Type)
+	}
+	tool.Save()
+}
+
+// Mode is Simple when the filter was built from the drop downs and Custom when it uses an expression.
+func (tool *Filter) Mode() string {
+	return tool.readText(`Mode`)
+}
+
+func (tool *Filter) SetMode(mode string) {
+	tool.setText(mode, `Mode`)
+}
+
+func (tool *Filter) Expression() string {
+	return tool.readText(`Expression`)
+}
+
+// SimpleField is the field a Simple mode filter tests.
+func (tool *Filter) SimpleField() string {
+	return tool.readText(`Simple`, `Field`)
+}
+
+func (tool *Filter) SetSimpleField(field string) {
+	tool.setText(field, `Simple`, `Field`)
+}
+
+// SetExpression also switches the filter to Custom mode so the expression is used.
+func (tool *Filter) SetExpression(expression string) {
+	tool.setText(expression, `Expression`)
+	tool.setText(`Custom`, `Mode`)
+}
+
+func (tool *Select) Fields() []SelectField {
+	return readSelectFields(tool.Xml.First(`SelectFields`))
+}
+
+func (tool *Select) SetFields(fields []SelectField) {
+	writeSelectFields(findOrAdd(tool.Xml, `SelectFields`), fields)
+	tool.Save()
+}
+
+func (tool *Join) LeftFields() []string {
+	return readJoinFields(tool.Xml, `Left`)
+}
+
+func (tool *Join) RightFields() []string {
+	return readJoinFields(tool.Xml, `Right`)
+}
+
+// SetJoinFields sets the fields to join on.  The left and right fields are paired by position, so there must be the
+// same number of each.
+func (tool *Join) SetJoinFields(left []string, right []string) error {
+	if len(left) != len(right) {
+		return errors.New(fmt.Sprintf(`there are %v left join fields but %v right join fields`, len(left), len(right)))
+	}
+	writeJoinFields(tool.Xml, `Left`, left)
+	writeJoinFields(tool.Xml, `Right`, right)
+	tool.Save()
+	return nil
+}
+
+func (tool *Join) SelectFields() []SelectField {
+	return readSelectFields(find(tool.Xml, `SelectConfiguration`, `Configuration`, `SelectFields`))
+}
+
+func (tool *Join) SetSelectFields(fields []SelectField) {
+	writeSelectFields(findOrAdd(tool.Xml, `SelectConfiguration`, `Configuration`, `SelectFields`), fields)
+	tool.Save()
+}
+
+func (tool *Summarize) Fields() []SummarizeField {
+	fields := []SummarizeField{}
+	for _, item := range tool.Xml.First(`SummarizeFields`).AllNodes(`SummarizeField`) {
+		fields = append(fields, SummarizeField{
+			Field:  item.Attributes[`field`],
+			Action: item.Attributes[`action`],
+			Rename: item.Attributes[`rename`],
+		})
+	}
+	return fields
+}
+
+func (tool *Summarize) SetFields(fields []SummarizeField) {
+	items := resizeList(findOrAdd(tool.Xml, `SummarizeFields`), `SummarizeField`, len(fields))
+	for index, field := range fields {
+		setAttr(items[index], `field`, field.Field)
+		setAttr(items[index], `action`, field.Action)
+		setAttr(items[index], `rename`, field.Rename)
+	}
+	tool.Save()
+}
+
+func (tool *TextInput) Fields() []string {
+	fields := []string{}
+	for _, item := range tool.Xml.First(`Fields`).AllNodes(`Field`) {
+		fields = append(fields, item.Attributes[`name`])
+	}
+	return fields
+}
+
+func (tool *TextInput) Rows() [][]string {
+	rows := [][]string{}
+	for _, row := range tool.Xml.First(`Data`).AllNodes(`r`) {
+		values := []string{}
+		for _, cell := range row.AllNodes(`c`) {
+			values = append(values, ReadText(cell))
+		}
+		rows = append(rows, values)
+	}
+	return rows
+}
+
+// SetData replaces the fields and rows of the text input.  Every row must have a value for every field.
+func (tool *TextInput) SetData(fields []string, rows [][]string) error {
+	for index, row := range rows {
+		if len(row) != len(fields) {
+			return errors.New(fmt.Sprintf(`row %v has %v values but there are %v fields`, index+1, len(row), len(fields)))
+		}
+	}
+	setAttr(findOrAdd(tool.Xml, `NumRows`), `value`, strconv.Itoa(len(rows)))
+	items := resizeList(findOrAdd(tool.Xml, `Fields`), `Field`, len(fields))
+	for index, field := range fields {
+		setAttr(items[index], `name`, field)
+	}
+	data := findOrAdd(tool.Xml, `Data`)
+	data.Nodes = nil
+	for _, row := range rows {
+		rowXml := &txml.Node{Name: `r`, Attributes: map[string]string{}}
+		for _, value := range row {
+			rowXml.Nodes = append(rowXml.Nodes, &txml.Node{Name: `c`, Attributes: map[string]string{}, InnerText: escapeText(value)})
+		}
+		data.Nodes = append(data.Nodes, rowXml)
+	}
+	tool.Save()
+	return nil
+}
+
+func (tool *MacroInput) Name() string {
+	return tool.readText(`Name`)
+}
+
+func (tool *MacroInput) SetName(name string) {
+	tool.setText(name, `Name`)
+}
+
+func (tool *MacroInput) Abbrev() string {
+	return tool.readText(`Abbrev`)
+}
+
+func (tool *MacroInput) SetAbbrev(abbrev string) {
+	tool.setText(abbrev, `Abbrev`)
+}
+
+func (tool *MacroInput) Optional() bool {
+	return tool.readValue(`Optional`)
+}
+
+func (tool *MacroInput) SetOptional(optional bool) {
+	tool.setValue(optional, `Optional`)
+}
+
+func (tool *MacroOutput) Name() string {
+	return tool.readText(`Name`)
+}
+
+func (tool *MacroOutput) SetName(name string) {
+	tool.setText(name, `Name`)
+}
+
+func (tool *MacroOutput) Abbrev() string {
+	return tool.readText(`Abbrev`)
+}
+
+func (tool *MacroOutput) SetAbbrev(abbrev string) {
+	tool.setText(abbrev, `Abbrev`)
+}
+
+func (tool *ControlParameter) Label() string {
+	return tool.readText(`Label`)
+}
+
+func (tool *ControlParameter) SetLabel(label string) {
+	tool.setText(label, `Label`)
+}
+
+func readSelectFields(list *txml.Node) []SelectField {
+	fields := []SelectField{}
+	for _, item := range list.AllNodes(`SelectField`) {
+		fields = append(fields, SelectField{
+			Field:    item.Attributes[`field`],
+			Selected: strings.EqualFold(item.Attributes[`selected`], `True`),
+			Rename:   item.Attributes[`rename`],
+			Type:     item.Attributes[`type`],
+			Size:     item.Attributes[`size`],
+			Input:    item.Attributes[`input`],
+		})
+	}
+	return fields
+}
+
+// writeSelectFields only writes the rename, type, size and input attributes when they are set, because Designer
+// leaves them out for fields that keep their incoming name and type.
+func writeSelectFields(list *txml.Node, fields []SelectField) {
+	items := resizeList(list, `SelectField`, len(fields))
+	for index, field := range fields {
+		item := items[index]
+		setAttr(item, `field`, field.Field)
+		setAttr(item, `selected`, boolText(field.Selected))
+		for name, value := range map[string]string{`rename`: field.Rename, `type`: field.Type, `size`: field.Size, `input`: field.Input} {
+			if value == `` {
+				delete(item.Attributes, name)
+				continue
+			}
+			setAttr(item, name, value)
+		}
+	}
+}
+
+func readJoinFields(config *txml.Node, connection string) []string {
+	fields := []string{}
+	for _, info := range config.AllNodes(`JoinInfo`) {
+		if info.Attributes[`connection`] != connection {
+			continue
+		}
+		for _, field := range info.AllNodes(`Field`) {
+			fields = append(fields, field.Attributes[`field`])
+		}
+	}
+	return fields
+}
+
+func writeJoinFields(config *txml.Node, connection string, fields []string) {
+	var info *txml.Node
+	for _, existing := range config.AllNodes(`JoinInfo`) {
+		if existing.Attributes[`connection`] == connection {
+			info = existing
+			break
+		}
+	}
+	if info == nil {
+		info = &txml.Node{Name: `JoinInfo`, Attributes: map[string]string{`connection`: connection}}
+		insertAt := 0
+		for index, child := range config.Nodes {
+			if child.Name == `JoinInfo` {
+				insertAt = index + 1
+			}
+		}
+		config.Nodes = append(config.Nodes[:insertAt], append([]*txml.Node{info}, config.Nodes[insertAt:]...)...)
+	}
+	items := resizeList(info, `Field`, len(fields))
+	for index, field := range fields {
+		setAttr(items[index], `field`, field)
+	}
+}
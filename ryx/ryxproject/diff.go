@@ -0,0 +1,60 @@
+package ryxproject
+
+import (
+	"errors"
+	"fmt"
+	"github.com/tlarsen7572/Golang-Public/ryx/ryxdoc"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+func (ryxProject *RyxProject) DiffDocuments(fromPath string, toPath string) (*ryxdoc.DocDiff, error) {
+	from, err := ryxProject.RetrieveDocument(fromPath)
+	if err != nil {
+		return nil, err
+	}
+	to, err := ryxProject.RetrieveDocument(toPath)
+	if err != nil {
+		return nil, err
+	}
+	return ryxdoc.Diff(from, to), nil
+}
+
+// DiffRevision compares a document as it was at a git revision with the document as it is on disk.  The project
+// must be inside a git repository and git must be on the path.
+func (ryxProject *RyxProject) DiffRevision(path string, revision string) (*ryxdoc.DocDiff, error) {
+	to, err := ryxProject.RetrieveDocument(path)
+	if err != nil {
+		return nil, err
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	content, err := readGitRevision(absPath, revision)
+	if err != nil {
+		return nil, err
+	}
+	from, err := ryxdoc.ReadBytes(content)
+	if err != nil {
+		return nil, err
+	}
+	return ryxdoc.Diff(from, to), nil
+}
+
+func readGitRevision(absPath string, revision string) ([]byte, error) {
+	if revision == `` || strings.HasPrefix(revision, `-`) {
+		return nil, errors.New(fmt.Sprintf(`'%v' is not a valid revision`, revision))
+	}
+	cmd := exec.Command(`git`, `show`, fmt.Sprintf(`%v:./%v`, revision, filepath.Base(absPath)))
+	cmd.Dir = filepath.Dir(absPath)
+	output, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return nil, errors.New(fmt.Sprintf(`git could not read revision '%v': %v`, revision, strings.TrimSpace(string(exitErr.Stderr))))
+		}
+		return nil, err
+	}
+	return output, nil
+}
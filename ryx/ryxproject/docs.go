@@ -0,0 +1,305 @@
+package ryxproject
+
+import (
+	"bytes"
+	"github.com/tlarsen7572/Golang-Public/ryx/ryxdoc"
+	"github.com/tlarsen7572/Golang-Public/ryx/ryxnode"
+	"github.com/tlarsen7572/Golang-Public/ryx/tool_data_loader"
+	"github.com/tlarsen7572/Golang-Public/txml"
+	"html/template"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+type docPage struct {
+	Title       string
+	Index       string
+	Type        ryxdoc.DocumentType
+	Description string
+	Inputs      []docAnchor
+	Outputs     []docAnchor
+	Tools       []docToolCount
+	Macros      []docLink
+	WhereUsed   []docLink
+	Canvas      template.HTML
+}
+
+type docAnchor struct {
+	ToolId int
+	Kind   string
+	Detail string
+}
+
+type docToolCount struct {
+	Tool  string
+	Count int
+}
+
+type docLink struct {
+	Name string
+	Href string
+}
+
+type docIndex struct {
+	Title string
+	Docs  []docIndexEntry
+}
+
+type docIndexEntry struct {
+	docLink
+	Type        ryxdoc.DocumentType
+	Description string
+}
+
+var dataInputPlugins = map[string]string{
+	`AlteryxBasePluginsGui.DbFileInput.DbFileInput`: `Input Data`,
+	`AlteryxBasePluginsGui.TextInput.TextInput`:     `Text Input`,
+	`AlteryxBasePluginsGui.MacroInput.MacroInput`:   `Macro Input`,
+}
+
+var dataOutputPlugins = map[string]string{
+	`AlteryxBasePluginsGui.DbFileOutput.DbFileOutput`: `Output Data`,
+	`AlteryxBasePluginsGui.MacroOutput.MacroOutput`:   `Macro Output`,
+}
+
+// GenerateDocs writes an HTML page for every document in the project to outputFolder, along with an index.html
+// listing them.  Each page shows the document's description, its inputs and outputs, the tools and macros it uses,
+// the documents that use it, and a picture of its canvas.  The path of the index is returned.
+func (ryxProject *RyxProject) GenerateDocs(outputFolder string, toolData []tool_data_loader.ToolData) (string, error) {
+	docs, err := ryxProject.Docs()
+	if err != nil {
+		return ``, err
+	}
+	paths := []string{}
+	for path := range docs {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	usedBy := map[string][]string{}
+	macrosUsed := map[string][]ryxnode.MacroPath{}
+	for _, path := range paths {
+		macroPaths := ryxProject.generateMacroPaths(filepath.Dir(path))
+		found := map[string]bool{}
+		nodes := docs[path].ReadMappedNodes()
+		for _, id := range sortedNodeIds(docs[path]) {
+			macro := nodes[id].ReadMacro(macroPaths...)
+			key := macro.FoundPath
+			if key == `` {
+				key = macro.StoredPath
+			}
+			if key == `` || found[key] {
+				continue
+			}
+			found[key] = true
+			macrosUsed[path] = append(macrosUsed[path], macro)
+			if macro.FoundPath != `` {
+				usedBy[macro.FoundPath] = append(usedBy[macro.FoundPath], path)
+			}
+		}
+	}
+
+	index := &docIndex{Title: filepath.Base(ryxProject.path)}
+	indexPath := filepath.Join(outputFolder, `index.html`)
+	graphics := newGraphicReader(toolData)
+	for _, path := range paths {
+		doc := docs[path]
+		rel, _ := filepath.Rel(ryxProject.path, path)
+		pagePath := filepath.Join(outputFolder, rel+`.html`)
+		page := &docPage{
+			Title:       filepath.ToSlash(rel),
+			Index:       relativeHref(pagePath, indexPath),
+			Type:        doc.ReadDocumentType(),
+			Description: readDescription(doc),
+			Canvas:      template.HTML(doc.RenderSvg(graphics.forFolder(ryxProject.generateMacroPaths(filepath.Dir(path))))),
+		}
+		page.Inputs, page.Outputs = readDataAnchors(doc)
+		page.Tools = countTools(doc)
+		for _, macro := range macrosUsed[path] {
+			page.Macros = append(page.Macros, ryxProject.docLinkFrom(pagePath, outputFolder, macro.StoredPath, macro.FoundPath))
+		}
+		for _, user := range usedBy[path] {
+			userRel, _ := filepath.Rel(ryxProject.path, user)
+			page.WhereUsed = append(page.WhereUsed, ryxProject.docLinkFrom(pagePath, outputFolder, filepath.ToSlash(userRel), user))
+		}
+		err = writeTemplate(pagePath, docPageTemplate, page)
+		if err != nil {
+			return ``, err
+		}
+		index.Docs = append(index.Docs, docIndexEntry{
+			docLink:     docLink{Name: page.Title, Href: relativeHref(indexPath, pagePath)},
+			Type:        page.Type,
+			Description: page.Description,
+		})
+	}
+	err = writeTemplate(indexPath, docIndexTemplate, index)
+	if err != nil {
+		return ``, err
+	}
+	return indexPath, nil
+}
+
+// docLinkFrom links to the page of a document in the project.  Documents outside the project have no page, so they
+// are listed without a link.
+func (ryxProject *RyxProject) docLinkFrom(pagePath string, outputFolder string, name string, docPath string) docLink {
+	rel, err := filepath.Rel(ryxProject.path, docPath)
+	if docPath == `` || err != nil || strings.HasPrefix(rel, `..`) {
+		return docLink{Name: name}
+	}
+	return docLink{Name: name, Href: relativeHref(pagePath, filepath.Join(outputFolder, rel+`.html`))}
+}
+
+func readDescription(doc *ryxdoc.RyxDoc) string {
+	if doc.Properties == nil {
+		return ``
+	}
+	return strings.TrimSpace(doc.Properties.First(`MetaInfo`).First(`Description`).InnerText)
+}
+
+func readDataAnchors(doc *ryxdoc.RyxDoc) (inputs []docAnchor, outputs []docAnchor) {
+	nodes := doc.ReadMappedNodes()
+	for _, id := range sortedNodeIds(doc) {
+		node := nodes[id]
+		if kind, ok := dataInputPlugins[node.ReadPlugin()]; ok {
+			inputs = append(inputs, docAnchor{ToolId: id, Kind: kind, Detail: readAnchorDetail(node)})
+		}
+		if kind, ok := dataOutputPlugins[node.ReadPlugin()]; ok {
+			outputs = append(outputs, docAnchor{ToolId: id, Kind: kind, Detail: readAnchorDetail(node)})
+		}
+	}
+	return inputs, outputs
+}
+
+// readAnchorDetail describes where an input or output tool's data comes from or goes to: the file for Input and
+// Output Data tools, the anchor name for Macro Input and Output tools, and the annotation for anything else.
+func readAnchorDetail(node *ryxnode.RyxNode) string {
+	if node.Properties == nil {
+		return ``
+	}
+	config, err := txml.Parse(`<Configuration>` + node.Properties.Configuration.InnerXml + `</Configuration>`)
+	if err == nil {
+		if file := config.First(`File`).InnerText; file != `` {
+			return file
+		}
+		if name := config.First(`Name`).InnerText; name != `` {
+			return name
+		}
+	}
+	if node.Properties.Annotation == nil {
+		return ``
+	}
+	return node.Properties.Annotation.First(`AnnotationText`).InnerText
+}
+
+func countTools(doc *ryxdoc.RyxDoc) []docToolCount {
+	counts := map[string]int{}
+	for _, node := range doc.ReadMappedNodes() {
+		if node.ReadCategory() == ryxnode.Macro {
+			continue
+		}
+		plugin := node.ReadPlugin()
+		counts[plugin[strings.LastIndex(plugin, `.`)+1:]]++
+	}
+	tools := []docToolCount{}
+	for tool, count := range counts {
+		tools = append(tools, docToolCount{Tool: tool, Count: count})
+	}
+	sort.Slice(tools, func(i, j int) bool {
+		return tools[i].Tool < tools[j].Tool
+	})
+	return tools
+}
+
+func sortedNodeIds(doc *ryxdoc.RyxDoc) []int {
+	ids := []int{}
+	for id := range doc.ReadMappedNodes() {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+func relativeHref(fromPage string, toPage string) string {
+	rel, err := filepath.Rel(filepath.Dir(fromPage), toPage)
+	if err != nil {
+		return ``
+	}
+	return filepath.ToSlash(rel)
+}
+
+func writeTemplate(path string, pageTemplate *template.Template, data interface{}) error {
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+	buffer := &bytes.Buffer{}
+	err = pageTemplate.Execute(buffer, data)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, buffer.Bytes(), 0644)
+}
+
+const docStyle = `<style>
+body { font-family: Arial, sans-serif; margin: 2em; color: #333333; }
+table { border-collapse: collapse; margin-bottom: 1em; }
+th, td { border: 1px solid #cccccc; padding: 4px 8px; text-align: left; }
+.canvas { overflow: auto; border: 1px solid #cccccc; }
+</style>`
+
+var docIndexTemplate = template.Must(template.New(`index`).Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{.Title}}</title>` + docStyle + `</head>
+<body>
+<h1>{{.Title}}</h1>
+<table>
+<tr><th>Document</th><th>Type</th><th>Description</th></tr>
+{{range .Docs}}<tr><td><a href="{{.Href}}">{{.Name}}</a></td><td>{{.Type}}</td><td>{{.Description}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+var docPageTemplate = template.Must(template.New(`page`).Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{.Title}}</title>` + docStyle + `</head>
+<body>
+<p><a href="{{.Index}}">Index</a></p>
+<h1>{{.Title}}</h1>
+<p>{{.Type}}</p>
+{{if .Description}}<p>{{.Description}}</p>
+{{end}}<h2>Inputs</h2>
+{{if .Inputs}}<table>
+<tr><th>Tool</th><th>Type</th><th>Source</th></tr>
+{{range .Inputs}}<tr><td>{{.ToolId}}</td><td>{{.Kind}}</td><td>{{.Detail}}</td></tr>
+{{end}}</table>
+{{else}}<p>None</p>
+{{end}}<h2>Outputs</h2>
+{{if .Outputs}}<table>
+<tr><th>Tool</th><th>Type</th><th>Destination</th></tr>
+{{range .Outputs}}<tr><td>{{.ToolId}}</td><td>{{.Kind}}</td><td>{{.Detail}}</td></tr>
+{{end}}</table>
+{{else}}<p>None</p>
+{{end}}<h2>Tools</h2>
+<table>
+<tr><th>Tool</th><th>Count</th></tr>
+{{range .Tools}}<tr><td>{{.Tool}}</td><td>{{.Count}}</td></tr>
+{{end}}</table>
+<h2>Macros used</h2>
+{{if .Macros}}<ul>
+{{range .Macros}}<li>{{if .Href}}<a href="{{.Href}}">{{.Name}}</a>{{else}}{{.Name}}{{end}}</li>
+{{end}}</ul>
+{{else}}<p>None</p>
+{{end}}<h2>Where used</h2>
+{{if .WhereUsed}}<ul>
+{{range .WhereUsed}}<li>{{if .Href}}<a href="{{.Href}}">{{.Name}}</a>{{else}}{{.Name}}{{end}}</li>
+{{end}}</ul>
+{{else}}<p>None</p>
+{{end}}<h2>Canvas</h2>
+<div class="canvas">{{.Canvas}}</div>
+</body>
+</html>
+`))
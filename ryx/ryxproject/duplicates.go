@@ -0,0 +1,91 @@
+package ryxproject
+
+import (
+	"errors"
+	"fmt"
+	"github.com/tlarsen7572/Golang-Public/ryx/ryxdoc"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+type DuplicateMacroGroup struct {
+	Hash  string
+	Paths []string
+}
+
+func (ryxProject *RyxProject) FindDuplicateMacros() ([]*DuplicateMacroGroup, error) {
+	docs, err := ryxProject.Docs()
+	if err != nil {
+		return nil, err
+	}
+	byHash := map[string][]string{}
+	for path, doc := range docs {
+		if strings.ToLower(filepath.Ext(path)) != `.yxmc` {
+			continue
+		}
+		hash, err := doc.NormalizedHash()
+		if err != nil {
+			continue
+		}
+		byHash[hash] = append(byHash[hash], path)
+	}
+
+	groups := []*DuplicateMacroGroup{}
+	for hash, paths := range byHash {
+		if len(paths) < 2 {
+			continue
+		}
+		sort.Strings(paths)
+		groups = append(groups, &DuplicateMacroGroup{Hash: hash, Paths: paths})
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].Paths[0] < groups[j].Paths[0]
+	})
+	return groups, nil
+}
+
+// ConsolidateMacros repoints every reference to the duplicate macros at the canonical macro.  The duplicates must
+// have the same normalized hash as the canonical macro.  The duplicate files themselves are left in place.
+func (ryxProject *RyxProject) ConsolidateMacros(canonical string, duplicates []string) (int, error) {
+	canonicalDoc, err := ryxdoc.ReadFile(canonical)
+	if err != nil {
+		return 0, err
+	}
+	canonicalHash, err := canonicalDoc.NormalizedHash()
+	if err != nil {
+		return 0, err
+	}
+	newPaths := []string{}
+	for _, duplicate := range duplicates {
+		if duplicate == canonical {
+			return 0, errors.New(`the canonical macro cannot also be one of the duplicates`)
+		}
+		duplicateDoc, err := ryxdoc.ReadFile(duplicate)
+		if err != nil {
+			return 0, err
+		}
+		duplicateHash, err := duplicateDoc.NormalizedHash()
+		if err != nil {
+			return 0, err
+		}
+		if duplicateHash != canonicalHash {
+			return 0, errors.New(fmt.Sprintf(`'%v' is not a duplicate of '%v'`, duplicate, canonical))
+		}
+		newPaths = append(newPaths, canonical)
+	}
+
+	organizer, err := ryxProject._collectAffectedNodes(duplicates, newPaths)
+	if err != nil {
+		return 0, err
+	}
+	for _, tracker := range organizer.trackers {
+		for _, node := range tracker.nodes {
+			node.SetMacro(tracker.newPath)
+		}
+	}
+	for path, doc := range organizer.affectedDocs {
+		_ = doc.Save(path)
+	}
+	return len(organizer.affectedDocs), nil
+}
@@ -0,0 +1,301 @@
+package ryxproject
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/tlarsen7572/Golang-Public/ryx/ryxdoc"
+	"github.com/tlarsen7572/Golang-Public/ryx/ryxfolder"
+	"github.com/tlarsen7572/Golang-Public/ryx/ryxnode"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const ReuseExisting = `Reuse`
+const OverwriteExisting = `Overwrite`
+const RenameImported = `Rename`
+
+type PackageConflict struct {
+	Entry        string
+	TargetPath   string
+	ExistingPath string
+	Identical    bool
+}
+
+type ConflictResolution struct {
+	Choice  string
+	NewName string
+}
+
+type packageEntries map[string][]byte
+
+// InspectPackage lists the documents in a package that already exist at the location they would be imported to.
+// Macros are also listed when a macro with the same file name exists anywhere in the project or the macro search
+// paths.
+func (ryxProject *RyxProject) InspectPackage(packagePath string, targetFolder string) ([]*PackageConflict, error) {
+	targetFolder, err := ryxProject.checkInProject(targetFolder)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := readPackageEntries(packagePath)
+	if err != nil {
+		return nil, err
+	}
+	return ryxProject.findPackageConflicts(entries, targetFolder)
+}
+
+// ImportPackage unpacks a package into a folder of the project.  Conflicting documents are reused, overwritten or
+// renamed according to resolutions, which is keyed by package entry.  Identical documents without a resolution are
+// reused; differing documents without a resolution cause the import to fail before anything is written, so existing
+// workflows are never overwritten without being asked.
+func (ryxProject *RyxProject) ImportPackage(packagePath string, targetFolder string, resolutions map[string]ConflictResolution) ([]string, error) {
+	targetFolder, err := ryxProject.checkInProject(targetFolder)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := readPackageEntries(packagePath)
+	if err != nil {
+		return nil, err
+	}
+	conflicts, err := ryxProject.findPackageConflicts(entries, targetFolder)
+	if err != nil {
+		return nil, err
+	}
+
+	finalPaths := map[string]string{}
+	reused := map[string]bool{}
+	for entry := range entries {
+		finalPaths[entry] = filepath.Join(targetFolder, filepath.FromSlash(entry))
+	}
+	unresolved := []string{}
+	for _, conflict := range conflicts {
+		resolution, ok := resolutions[conflict.Entry]
+		if !ok {
+			if !conflict.Identical {
+				unresolved = append(unresolved, conflict.Entry)
+				continue
+			}
+			resolution = ConflictResolution{Choice: ReuseExisting}
+		}
+		switch resolution.Choice {
+		case ReuseExisting:
+			finalPaths[conflict.Entry] = conflict.ExistingPath
+			reused[conflict.Entry] = true
+		case OverwriteExisting:
+			finalPaths[conflict.Entry] = conflict.ExistingPath
+		case RenameImported:
+			if resolution.NewName == `` {
+				return nil, errors.New(fmt.Sprintf(`no new name was provided for '%v'`, conflict.Entry))
+			}
+			if strings.ContainsAny(resolution.NewName, `/\:`) || strings.Contains(resolution.NewName, `..`) {
+				return nil, errors.New(fmt.Sprintf(`'%v' is not a valid file name for '%v'`, resolution.NewName, conflict.Entry))
+			}
+			newPath := filepath.Join(filepath.Dir(conflict.TargetPath), resolution.NewName)
+			if rel, err := filepath.Rel(targetFolder, newPath); err != nil || strings.HasPrefix(rel, `..`) {
+				return nil, errors.New(fmt.Sprintf(`cannot rename '%v' to '%v' because it is outside of the target folder`, conflict.Entry, newPath))
+			}
+			if _, err := os.Stat(newPath); err == nil {
+				return nil, errors.New(fmt.Sprintf(`cannot rename '%v' to '%v' because the file already exists`, conflict.Entry, newPath))
+			}
+			finalPaths[conflict.Entry] = newPath
+		default:
+			return nil, errors.New(fmt.Sprintf(`'%v' is not a valid choice for '%v'`, resolution.Choice, conflict.Entry))
+		}
+	}
+	if len(unresolved) > 0 {
+		return nil, errors.New(fmt.Sprintf(`the following documents conflict with existing files and need a resolution: %v`, strings.Join(unresolved, `, `)))
+	}
+
+	written := []string{}
+	for _, entry := range sortedEntryNames(entries) {
+		if reused[entry] {
+			continue
+		}
+		finalPath := finalPaths[entry]
+		err = os.MkdirAll(filepath.Dir(finalPath), 0777)
+		if err != nil {
+			return written, err
+		}
+		if !isRyxDoc(entry) {
+			if _, err := os.Stat(finalPath); err == nil {
+				continue
+			}
+			err = ioutil.WriteFile(finalPath, entries[entry], 0644)
+			if err != nil {
+				return written, err
+			}
+			written = append(written, finalPath)
+			continue
+		}
+		doc, err := ryxdoc.ReadBytesLossless(entries[entry])
+		if err != nil {
+			return written, err
+		}
+		redirectPackageMacros(doc, entry, finalPaths)
+		err = doc.Save(finalPath)
+		if err != nil {
+			return written, err
+		}
+		written = append(written, finalPath)
+	}
+	return written, nil
+}
+
+func (ryxProject *RyxProject) findPackageConflicts(entries packageEntries, targetFolder string) ([]*PackageConflict, error) {
+	existingMacros, err := ryxProject.listExistingMacros()
+	if err != nil {
+		return nil, err
+	}
+	conflicts := []*PackageConflict{}
+	for _, entry := range sortedEntryNames(entries) {
+		if !isRyxDoc(entry) {
+			continue
+		}
+		targetPath := filepath.Join(targetFolder, filepath.FromSlash(entry))
+		existingPath := ``
+		if _, err := os.Stat(targetPath); err == nil {
+			existingPath = targetPath
+		} else if strings.ToLower(path.Ext(entry)) == `.yxmc` {
+			existingPath = existingMacros[strings.ToLower(path.Base(entry))]
+		}
+		if existingPath == `` {
+			continue
+		}
+		existingContent, err := ioutil.ReadFile(existingPath)
+		if err != nil {
+			return nil, err
+		}
+		conflicts = append(conflicts, &PackageConflict{
+			Entry:        entry,
+			TargetPath:   targetPath,
+			ExistingPath: existingPath,
+			Identical:    hashContent(existingContent) == hashContent(entries[entry]),
+		})
+	}
+	return conflicts, nil
+}
+
+func (ryxProject *RyxProject) listExistingMacros() (map[string]string, error) {
+	macros := map[string]string{}
+	folders := append([]string{ryxProject.path}, ryxProject.macroPaths...)
+	for _, folder := range folders {
+		structure, err := ryxfolder.Build(folder)
+		if err != nil {
+			if folder == ryxProject.path {
+				return nil, err
+			}
+			continue
+		}
+		for _, file := range structure.AllFiles() {
+			if strings.ToLower(filepath.Ext(file)) != `.yxmc` {
+				continue
+			}
+			name := strings.ToLower(filepath.Base(file))
+			if _, ok := macros[name]; !ok {
+				macros[name] = file
+			}
+		}
+	}
+	return macros, nil
+}
+
+func redirectPackageMacros(doc *ryxdoc.RyxDoc, entry string, finalPaths map[string]string) {
+	entryFolder := path.Dir(entry)
+	docFolder := filepath.Dir(finalPaths[entry])
+	for _, node := range doc.ReadMappedNodes() {
+		if node.ReadCategory() != ryxnode.Macro {
+			continue
+		}
+		stored := node.ReadMacro().StoredPath
+		macroEntry := path.Join(entryFolder, strings.Replace(stored, `\`, `/`, -1))
+		finalPath, ok := finalPaths[macroEntry]
+		if !ok {
+			continue
+		}
+		relPath, err := filepath.Rel(docFolder, finalPath)
+		if err != nil {
+			node.SetMacro(finalPath)
+			continue
+		}
+		node.SetMacro(relPath)
+	}
+}
+
+func readPackageEntries(packagePath string) (packageEntries, error) {
+	archive, err := zip.OpenReader(packagePath)
+	if err != nil {
+		return nil, err
+	}
+	defer archive.Close()
+	entries := packageEntries{}
+	for _, file := range archive.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+		if strings.Contains(file.Name, `\`) {
+			return nil, errors.New(fmt.Sprintf(`package entry '%v' uses a backslash, which is not a valid zip path separator`, file.Name))
+		}
+		name := path.Clean(file.Name)
+		if strings.HasPrefix(name, `..`) || path.IsAbs(name) {
+			return nil, errors.New(fmt.Sprintf(`package entry '%v' points outside of the package`, file.Name))
+		}
+		reader, err := file.Open()
+		if err != nil {
+			return nil, err
+		}
+		content, err := ioutil.ReadAll(reader)
+		_ = reader.Close()
+		if err != nil {
+			return nil, err
+		}
+		entries[name] = content
+	}
+	return entries, nil
+}
+
+func sortedEntryNames(entries packageEntries) []string {
+	names := []string{}
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (ryxProject *RyxProject) checkInProject(folder string) (string, error) {
+	absPath, err := filepath.Abs(folder)
+	if err != nil {
+		return ``, err
+	}
+	rel, err := filepath.Rel(ryxProject.path, absPath)
+	if err != nil {
+		return ``, err
+	}
+	if strings.HasPrefix(rel, `..`) {
+		return ``, errors.New(`path is not a child of the project directory`)
+	}
+	return absPath, nil
+}
+
+func isRyxDoc(file string) bool {
+	ext := strings.ToLower(path.Ext(file))
+	return ext == `.yxmd` || ext == `.yxmc` || ext == `.yxwz`
+}
+
+// hashContent uses the normalized document hash where possible so that formatting differences introduced by
+// packaging do not hide identical macros.
+func hashContent(content []byte) string {
+	if doc, err := ryxdoc.ReadBytes(content); err == nil {
+		if hash, err := doc.NormalizedHash(); err == nil {
+			return hash
+		}
+	}
+	hash := sha256.Sum256(content)
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,138 @@
+package ryxproject
+
+import (
+	"errors"
+	"fmt"
+	"github.com/tlarsen7572/Golang-Public/ryx/ryxfolder"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// MoveFolder relocates a folder, and everything in it, underneath a new parent folder inside the project or inside
+// one of the macro search paths.  If the destination folder already exists the move fails unless merge is set, in
+// which case the contents are merged as long as no individual file would be overwritten.  Macro references to the
+// moved documents, and from the moved documents to other macros, are updated.
+func (ryxProject *RyxProject) MoveFolder(from string, toParent string, merge bool) ([]string, error) {
+	from, err := ryxProject.checkInProject(from)
+	if err != nil {
+		return nil, err
+	}
+	if from == ryxProject.path {
+		return nil, errors.New(`the project folder itself cannot be moved`)
+	}
+	toParent, err = ryxProject.checkInProjectOrMacroPaths(toParent)
+	if err != nil {
+		return nil, err
+	}
+	toPath := filepath.Join(toParent, filepath.Base(from))
+	if toPath == from {
+		return nil, errors.New(`the folder is already in the destination folder`)
+	}
+	if isChildPath(from, toPath) {
+		return nil, errors.New(`a folder cannot be moved into itself`)
+	}
+	if _, err := os.Stat(toPath); err == nil && !merge {
+		return nil, errors.New(fmt.Sprintf(`'%v' already exists`, toPath))
+	}
+
+	folders, files, err := listFolderContents(from)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		newPath := filepath.Join(toPath, strings.TrimPrefix(file, from))
+		if _, err := os.Stat(newPath); err == nil {
+			return nil, errors.New(fmt.Sprintf(`cannot merge the folders because '%v' already exists`, newPath))
+		}
+	}
+	for _, folder := range folders {
+		err = os.MkdirAll(filepath.Join(toPath, strings.TrimPrefix(folder, from)), 0777)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	structure, err := ryxfolder.Build(from)
+	if err != nil {
+		return nil, err
+	}
+	oldDocs := structure.AllFiles()
+	newDocs := make([]string, 0)
+	for _, doc := range oldDocs {
+		newDocs = append(newDocs, filepath.Join(toPath, strings.TrimPrefix(doc, from)))
+	}
+	failed, err := ryxProject._renameFiles(oldDocs, newDocs)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		if StringsContain(oldDocs, file) {
+			continue
+		}
+		err = os.Rename(file, filepath.Join(toPath, strings.TrimPrefix(file, from)))
+		if err != nil {
+			failed = append(failed, file)
+		}
+	}
+	removeEmptyFolders(folders)
+	return failed, nil
+}
+
+func (ryxProject *RyxProject) checkInProjectOrMacroPaths(folder string) (string, error) {
+	absPath, err := ryxProject.checkInProject(folder)
+	if err == nil {
+		return absPath, nil
+	}
+	absPath, err = filepath.Abs(folder)
+	if err != nil {
+		return ``, err
+	}
+	for _, macroPath := range ryxProject.macroPaths {
+		absMacroPath, err := filepath.Abs(macroPath)
+		if err != nil {
+			continue
+		}
+		if absPath == absMacroPath || isChildPath(absMacroPath, absPath) {
+			return absPath, nil
+		}
+	}
+	return ``, errors.New(`path is not a child of the project directory or a macro path`)
+}
+
+func listFolderContents(root string) ([]string, []string, error) {
+	folders := []string{}
+	files := []string{}
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			folders = append(folders, path)
+			return nil
+		}
+		files = append(files, path)
+		return nil
+	})
+	return folders, files, err
+}
+
+func removeEmptyFolders(folders []string) {
+	sorted := append([]string{}, folders...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return len(sorted[i]) > len(sorted[j])
+	})
+	for _, folder := range sorted {
+		_ = os.Remove(folder)
+	}
+}
+
+func isChildPath(parent string, child string) bool {
+	rel, err := filepath.Rel(parent, child)
+	if err != nil {
+		return false
+	}
+	return rel != `.` && !strings.HasPrefix(rel, `..`)
+}
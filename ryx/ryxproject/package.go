@@ -0,0 +1,295 @@
+package ryxproject
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"github.com/tlarsen7572/Golang-Public/ryx/ryxdoc"
+	"github.com/tlarsen7572/Golang-Public/ryx/ryxnode"
+	"github.com/tlarsen7572/Golang-Public/ryx/ryxnode/toolconfig"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const externalsFolder = `_externals`
+
+type ExportResult struct {
+	PackagePath string
+	Documents   []string
+	Macros      []string
+	DataFiles   []string
+	Missing     []string
+}
+
+type packageContents struct {
+	docs      []string
+	macros    []string
+	dataFiles []string
+	missing   []string
+	entries   map[string]string
+}
+
+// ExportPackage writes the workflows, every macro they use (directly or through other macros) and, optionally, their
+// input data files into a zip archive laid out like an Alteryx .yxzp package.  Macro and data paths inside the
+// packaged documents are rewritten relative to each document's location in the archive.
+func (ryxProject *RyxProject) ExportPackage(docPaths []string, packagePath string, includeData bool) (*ExportResult, error) {
+	if len(docPaths) == 0 {
+		return nil, errors.New(`no documents were provided to package`)
+	}
+	contents, err := ryxProject.collectPackageContents(docPaths, includeData)
+	if err != nil {
+		return nil, err
+	}
+	contents.generateEntries(ryxProject.path)
+
+	file, err := os.Create(packagePath)
+	if err != nil {
+		return nil, err
+	}
+	writer := zip.NewWriter(file)
+	allDocs := append(append([]string{}, contents.docs...), contents.macros...)
+	for _, docPath := range allDocs {
+		err = ryxProject.writePackageDoc(writer, docPath, contents)
+		if err != nil {
+			_ = writer.Close()
+			_ = file.Close()
+			return nil, err
+		}
+	}
+	for _, dataPath := range contents.dataFiles {
+		err = writePackageFile(writer, dataPath, contents.entries[dataPath])
+		if err != nil {
+			_ = writer.Close()
+			_ = file.Close()
+			return nil, err
+		}
+	}
+	err = writer.Close()
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	err = file.Close()
+	if err != nil {
+		return nil, err
+	}
+	return &ExportResult{
+		PackagePath: packagePath,
+		Documents:   contents.docs,
+		Macros:      contents.macros,
+		DataFiles:   contents.dataFiles,
+		Missing:     contents.missing,
+	}, nil
+}
+
+func (ryxProject *RyxProject) collectPackageContents(docPaths []string, includeData bool) (*packageContents, error) {
+	contents := &packageContents{
+		docs:      []string{},
+		macros:    []string{},
+		dataFiles: []string{},
+		missing:   []string{},
+		entries:   map[string]string{},
+	}
+	visited := map[string]bool{}
+	queue := []string{}
+	for _, docPath := range docPaths {
+		absPath, err := filepath.Abs(docPath)
+		if err != nil {
+			return nil, err
+		}
+		if visited[absPath] {
+			continue
+		}
+		visited[absPath] = true
+		contents.docs = append(contents.docs, absPath)
+		queue = append(queue, absPath)
+	}
+
+	for len(queue) > 0 {
+		docPath := queue[0]
+		queue = queue[1:]
+		doc, err := ryxdoc.ReadFile(docPath)
+		if err != nil {
+			return nil, err
+		}
+		folder := filepath.Dir(docPath)
+		macroPaths := ryxProject.generateMacroPaths(folder)
+		for _, node := range doc.ReadMappedNodes() {
+			if node.ReadCategory() == ryxnode.Macro {
+				macro := node.ReadMacro(macroPaths...)
+				if macro.FoundPath == `` {
+					if !StringsContain(contents.missing, macro.StoredPath) {
+						contents.missing = append(contents.missing, macro.StoredPath)
+					}
+					continue
+				}
+				foundPath, err := filepath.Abs(macro.FoundPath)
+				if err != nil {
+					return nil, err
+				}
+				if visited[foundPath] {
+					continue
+				}
+				visited[foundPath] = true
+				contents.macros = append(contents.macros, foundPath)
+				queue = append(queue, foundPath)
+				continue
+			}
+			if !includeData || node.ReadPlugin() != toolconfig.InputDataPlugin {
+				continue
+			}
+			dataPath := findDataFile(readInputDataFile(node), folder)
+			if dataPath == `` || visited[dataPath] {
+				continue
+			}
+			visited[dataPath] = true
+			contents.dataFiles = append(contents.dataFiles, dataPath)
+		}
+	}
+	return contents, nil
+}
+
+// generateEntries decides where each file lives inside the archive.  Files inside the project keep their
+// project-relative paths; everything else is placed in a numbered folder under _externals, one per source folder.
+func (contents *packageContents) generateEntries(projectPath string) {
+	externalFolders := map[string]string{}
+	allFiles := append(append(append([]string{}, contents.docs...), contents.macros...), contents.dataFiles...)
+	for _, file := range allFiles {
+		rel, err := filepath.Rel(projectPath, file)
+		if err == nil && !strings.HasPrefix(rel, `..`) {
+			contents.entries[file] = filepath.ToSlash(rel)
+			continue
+		}
+		folder, name := filepath.Split(file)
+		externalFolder, ok := externalFolders[folder]
+		if !ok {
+			externalFolder = path.Join(externalsFolder, fmt.Sprintf(`%v`, len(externalFolders)+1))
+			externalFolders[folder] = externalFolder
+		}
+		contents.entries[file] = path.Join(externalFolder, name)
+	}
+}
+
+func (ryxProject *RyxProject) writePackageDoc(writer *zip.Writer, docPath string, contents *packageContents) error {
+	doc, err := ryxdoc.ReadFileLossless(docPath)
+	if err != nil {
+		return err
+	}
+	folder := filepath.Dir(docPath)
+	macroPaths := ryxProject.generateMacroPaths(folder)
+	entry := contents.entries[docPath]
+	entryFolder := path.Dir(entry)
+	for _, node := range doc.ReadMappedNodes() {
+		if node.ReadCategory() == ryxnode.Macro {
+			macro := node.ReadMacro(macroPaths...)
+			macroEntry, ok := contents.entries[absOrEmpty(macro.FoundPath)]
+			if !ok {
+				continue
+			}
+			node.SetMacro(relativeEntry(entryFolder, macroEntry))
+			continue
+		}
+		if node.ReadPlugin() != toolconfig.InputDataPlugin {
+			continue
+		}
+		storedData := readInputDataFile(node)
+		dataEntry, ok := contents.entries[findDataFile(storedData, folder)]
+		if !ok {
+			continue
+		}
+		setInputDataFile(node, relativeEntry(entryFolder, dataEntry))
+	}
+	data, err := doc.ToBytes()
+	if err != nil {
+		return err
+	}
+	entryWriter, err := writer.Create(entry)
+	if err != nil {
+		return err
+	}
+	_, err = entryWriter.Write(data)
+	return err
+}
+
+func writePackageFile(writer *zip.Writer, filePath string, entry string) error {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+	entryWriter, err := writer.Create(entry)
+	if err != nil {
+		return err
+	}
+	_, err = entryWriter.Write(data)
+	return err
+}
+
+func relativeEntry(fromFolder string, toEntry string) string {
+	rel, err := filepath.Rel(filepath.FromSlash(fromFolder), filepath.FromSlash(toEntry))
+	if err != nil {
+		return toEntry
+	}
+	return rel
+}
+
+func absOrEmpty(filePath string) string {
+	if filePath == `` {
+		return ``
+	}
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		return ``
+	}
+	return absPath
+}
+
+// readInputDataFile returns the file path stored in an Input Data tool, without any table or sheet suffix.
+func readInputDataFile(node *ryxnode.RyxNode) string {
+	config, err := readInputData(node)
+	if err != nil {
+		return ``
+	}
+	return strings.Split(config.File(), `|||`)[0]
+}
+
+// setInputDataFile points an Input Data tool at a new file, keeping any table or sheet suffix.
+func setInputDataFile(node *ryxnode.RyxNode, newPath string) {
+	config, err := readInputData(node)
+	if err != nil {
+		return
+	}
+	winPath := strings.Replace(newPath, string(os.PathSeparator), `\`, -1)
+	parts := strings.SplitN(config.File(), `|||`, 2)
+	parts[0] = winPath
+	config.SetFile(strings.Join(parts, `|||`))
+}
+
+func readInputData(node *ryxnode.RyxNode) (*toolconfig.InputData, error) {
+	tool, err := toolconfig.Read(node)
+	if err != nil {
+		return nil, err
+	}
+	config, ok := tool.(*toolconfig.InputData)
+	if !ok {
+		return nil, errors.New(`the tool is not an Input Data tool`)
+	}
+	return config, nil
+}
+
+func findDataFile(stored string, relativeTo string) string {
+	if stored == `` {
+		return ``
+	}
+	osStored := strings.Replace(stored, `\`, string(os.PathSeparator), -1)
+	if !filepath.IsAbs(osStored) {
+		osStored = filepath.Join(relativeTo, osStored)
+	}
+	stat, err := os.Stat(osStored)
+	if err != nil || stat.IsDir() {
+		return ``
+	}
+	return osStored
+}
@@ -0,0 +1,115 @@
+package ryxproject
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+type PatternRename struct {
+	From      string
+	To        string
+	Collision string
+}
+
+// PreviewRenameByPattern computes the new names of every project file whose name matches the pattern.  Patterns are
+// regular expressions unless isGlob is set, in which case each * and ? in the glob becomes a numbered capture group
+// that the replacement template can refer to with $1, $2, etc.  Only the file name is renamed; files stay in their
+// folders.  Any rename that would collide with another file is reported in its Collision field.
+func (ryxProject *RyxProject) PreviewRenameByPattern(pattern string, replacement string, isGlob bool) ([]*PatternRename, error) {
+	matcher, err := compileRenamePattern(pattern, isGlob)
+	if err != nil {
+		return nil, err
+	}
+	structure, err := ryxProject.Structure()
+	if err != nil {
+		return nil, err
+	}
+	files := structure.AllFiles()
+	sort.Strings(files)
+
+	renames := []*PatternRename{}
+	renamedFrom := map[string]bool{}
+	for _, file := range files {
+		folder, name := filepath.Split(file)
+		if !matcher.MatchString(name) {
+			continue
+		}
+		newName := matcher.ReplaceAllString(name, replacement)
+		if newName == name {
+			continue
+		}
+		rename := &PatternRename{From: file, To: filepath.Join(folder, newName)}
+		if newName == `` || strings.ContainsAny(newName, `/\`) {
+			rename.Collision = `the new name is not a valid file name`
+		}
+		renames = append(renames, rename)
+		renamedFrom[strings.ToLower(file)] = true
+	}
+
+	targets := map[string]*PatternRename{}
+	for _, rename := range renames {
+		lowerTo := strings.ToLower(rename.To)
+		switch {
+		case rename.Collision != ``:
+		case targets[lowerTo] != nil:
+			rename.Collision = fmt.Sprintf(`'%v' is also being renamed to this name`, targets[lowerTo].From)
+		case renamedFrom[lowerTo] && lowerTo != strings.ToLower(rename.From):
+			rename.Collision = `a file with this name already exists and is also being renamed`
+		case lowerTo != strings.ToLower(rename.From) && fileExists(rename.To):
+			rename.Collision = `a file with this name already exists`
+		}
+		if _, ok := targets[lowerTo]; !ok {
+			targets[lowerTo] = rename
+		}
+	}
+	return renames, nil
+}
+
+// RenameByPattern renames every matching file and updates all macro references to them.  Nothing is renamed if any
+// of the renames would collide.
+func (ryxProject *RyxProject) RenameByPattern(pattern string, replacement string, isGlob bool) ([]string, error) {
+	renames, err := ryxProject.PreviewRenameByPattern(pattern, replacement, isGlob)
+	if err != nil {
+		return nil, err
+	}
+	fromFiles := []string{}
+	toFiles := []string{}
+	for _, rename := range renames {
+		if rename.Collision != `` {
+			return nil, errors.New(fmt.Sprintf(`cannot rename '%v' to '%v': %v`, rename.From, rename.To, rename.Collision))
+		}
+		fromFiles = append(fromFiles, rename.From)
+		toFiles = append(toFiles, rename.To)
+	}
+	return ryxProject._renameFiles(fromFiles, toFiles)
+}
+
+func compileRenamePattern(pattern string, isGlob bool) (*regexp.Regexp, error) {
+	if !isGlob {
+		return regexp.Compile(pattern)
+	}
+	expression := strings.Builder{}
+	expression.WriteString(`^`)
+	for _, char := range pattern {
+		switch char {
+		case '*':
+			expression.WriteString(`(.*)`)
+		case '?':
+			expression.WriteString(`(.)`)
+		default:
+			expression.WriteString(regexp.QuoteMeta(string(char)))
+		}
+	}
+	expression.WriteString(`$`)
+	return regexp.Compile(expression.String())
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -2,11 +2,13 @@ package ryxproject
 
 import (
 	"errors"
+	"fmt"
 	"github.com/tlarsen7572/Golang-Public/ryx/ryxdoc"
 	"github.com/tlarsen7572/Golang-Public/ryx/ryxfolder"
 	"github.com/tlarsen7572/Golang-Public/ryx/ryxnode"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 )
 
@@ -198,7 +200,220 @@ func (ryxProject *RyxProject) RetrieveDocument(path string) (*ryxdoc.RyxDoc, err
 	if strings.Contains(rel, filepath.Join(`..`, ``)) {
 		return nil, errors.New(`path is not a child of the project directory`)
 	}
-	return ryxdoc.ReadFile(absPath)
+	return ryxdoc.ReadFileLossless(absPath)
+}
+
+func (ryxProject *RyxProject) ExtractMacro(docPath string, macroPath string, relative bool, anchorNames map[string]string, toolIds ...int) (*ryxdoc.RyxDoc, error) {
+	if len(toolIds) == 0 {
+		return nil, errors.New(`no tools were selected to extract`)
+	}
+	doc, err := ryxProject.RetrieveDocument(docPath)
+	if err != nil {
+		return nil, err
+	}
+	macroFolder, err := ryxProject.checkInProjectOrMacroPaths(filepath.Dir(macroPath))
+	if err != nil {
+		return nil, err
+	}
+	macroAbsPath := filepath.Join(macroFolder, filepath.Base(macroPath))
+	if _, err := os.Stat(macroAbsPath); err == nil {
+		return nil, errors.New(`a file already exists at the macro path`)
+	}
+	relativeTo := ``
+	if relative {
+		relativeTo = filepath.Dir(docPath)
+	}
+	err = doc.ExtractMacroNamed(macroAbsPath, relativeTo, anchorNames, toolIds...)
+	if err != nil {
+		return nil, err
+	}
+	err = doc.Save(docPath)
+	if err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// InlineMacro replaces a macro tool in the document with the macro's tools.  The IDs of the interface tools dropped
+// from the macro are returned along with the document, since the macro tool's answers to them are lost.
+func (ryxProject *RyxProject) InlineMacro(docPath string, toolId int) (*ryxdoc.RyxDoc, []int, error) {
+	doc, err := ryxProject.RetrieveDocument(docPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	macroPaths := ryxProject.generateMacroPaths(filepath.Dir(docPath))
+	dropped, err := doc.InlineMacro(toolId, macroPaths...)
+	if err != nil {
+		return nil, nil, err
+	}
+	err = doc.Save(docPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	return doc, dropped, nil
+}
+
+func (ryxProject *RyxProject) AutoLayout(docPath string) (*ryxdoc.RyxDoc, error) {
+	doc, err := ryxProject.RetrieveDocument(docPath)
+	if err != nil {
+		return nil, err
+	}
+	doc.AutoLayout()
+	err = doc.Save(docPath)
+	if err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+func (ryxProject *RyxProject) WrapInContainer(docPath string, caption string, color string, toolIds ...int) (*ryxdoc.RyxDoc, error) {
+	doc, err := ryxProject.RetrieveDocument(docPath)
+	if err != nil {
+		return nil, err
+	}
+	_, err = doc.WrapInContainer(toolIds, caption, color)
+	if err != nil {
+		return nil, err
+	}
+	err = doc.Save(docPath)
+	if err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+func (ryxProject *RyxProject) UnwrapContainer(docPath string, containerId int) (*ryxdoc.RyxDoc, error) {
+	doc, err := ryxProject.RetrieveDocument(docPath)
+	if err != nil {
+		return nil, err
+	}
+	err = doc.Unwrap(containerId)
+	if err != nil {
+		return nil, err
+	}
+	err = doc.Save(docPath)
+	if err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+func (ryxProject *RyxProject) RemoveToolsAndReconnect(docPath string, toolIds ...int) (*ryxdoc.RyxDoc, error) {
+	doc, err := ryxProject.RetrieveDocument(docPath)
+	if err != nil {
+		return nil, err
+	}
+	err = doc.RemoveToolsAndReconnect(toolIds...)
+	if err != nil {
+		return nil, err
+	}
+	err = doc.Save(docPath)
+	if err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+func (ryxProject *RyxProject) RenumberTools(docPath string, order ryxdoc.RenumberOrder) (*ryxdoc.RyxDoc, error) {
+	doc, err := ryxProject.RetrieveDocument(docPath)
+	if err != nil {
+		return nil, err
+	}
+	_, err = doc.RenumberTools(order)
+	if err != nil {
+		return nil, err
+	}
+	err = doc.Save(docPath)
+	if err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// ConvertDocumentType converts the document and renames it with the extension of its new type.  Documents that use
+// the converted document are updated to point to the new file.  Macros that are still used by other documents cannot
+// be converted to workflows or apps, since the documents using them would break.  The new path is returned.
+func (ryxProject *RyxProject) ConvertDocumentType(docPath string, docType ryxdoc.DocumentType, readAnchors ryxdoc.ToolAnchors) (string, error) {
+	extension, err := docType.Extension()
+	if err != nil {
+		return ``, err
+	}
+	doc, err := ryxProject.RetrieveDocument(docPath)
+	if err != nil {
+		return ``, err
+	}
+	newPath := strings.TrimSuffix(docPath, filepath.Ext(docPath)) + extension
+	if newPath != docPath {
+		if _, err := os.Stat(newPath); err == nil {
+			return ``, errors.New(`a file already exists at the converted document's path`)
+		}
+	}
+	if strings.ToLower(filepath.Ext(docPath)) == `.yxmc` && extension != `.yxmc` {
+		if usage := ryxProject.WhereUsed(docPath); len(usage) > 0 {
+			sort.Strings(usage)
+			return ``, errors.New(fmt.Sprintf(`the macro cannot be converted because it is used by: %v`, strings.Join(usage, `, `)))
+		}
+	}
+	err = doc.ConvertDocumentType(docType, readAnchors)
+	if err != nil {
+		return ``, err
+	}
+	if newPath == docPath {
+		return newPath, doc.Save(docPath)
+	}
+	failed, err := ryxProject.RenameFiles([]string{docPath}, []string{newPath})
+	if err != nil {
+		return ``, err
+	}
+	if len(failed) > 0 {
+		return ``, errors.New(`the converted document could not be renamed`)
+	}
+	err = doc.Save(newPath)
+	if err != nil {
+		_, _ = ryxProject.RenameFiles([]string{newPath}, []string{docPath})
+		return ``, err
+	}
+	return newPath, nil
+}
+
+func (ryxProject *RyxProject) CopyTools(fromPath string, toPath string, x float64, y float64, toolIds ...int) (*ryxdoc.RyxDoc, error) {
+	fromDoc, err := ryxProject.RetrieveDocument(fromPath)
+	if err != nil {
+		return nil, err
+	}
+	toDoc := fromDoc
+	if toPath != fromPath {
+		toDoc, err = ryxProject.RetrieveDocument(toPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+	fromFolder := filepath.Dir(fromPath)
+	_, err = ryxdoc.CopyTools(fromDoc, toolIds, toDoc, x, y, fromFolder, filepath.Dir(toPath), ryxProject.macroPaths...)
+	if err != nil {
+		return nil, err
+	}
+	err = toDoc.Save(toPath)
+	if err != nil {
+		return nil, err
+	}
+	return toDoc, nil
+}
+
+func (ryxProject *RyxProject) RenameField(docPath string, toolId int, oldName string, newName string) (*ryxdoc.RyxDoc, []*ryxdoc.FieldIssue, error) {
+	doc, err := ryxProject.RetrieveDocument(docPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	issues, err := doc.RenameField(toolId, oldName, newName)
+	if err != nil {
+		return nil, nil, err
+	}
+	err = doc.Save(docPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	return doc, issues, nil
 }
 
 func (ryxProject *RyxProject) WhereUsed(path string) []string {
@@ -317,7 +532,7 @@ func (ryxProject *RyxProject) BatchChangeMacroSettings(name string, newSetting s
 func docsFromStructure(structure *ryxfolder.RyxFolder) map[string]*ryxdoc.RyxDoc {
 	docs := map[string]*ryxdoc.RyxDoc{}
 	for _, file := range structure.AllFiles() {
-		doc, err := ryxdoc.ReadFile(file)
+		doc, err := ryxdoc.ReadFileLossless(file)
 		if err == nil {
 			docs[file] = doc
 		}
@@ -1,11 +1,16 @@
 package ryxproject_test
 
 import (
+	"archive/zip"
 	"encoding/json"
 	"github.com/tlarsen7572/Golang-Public/ryx/ryxdoc"
+	"github.com/tlarsen7572/Golang-Public/ryx/ryxnode/toolconfig"
 	"github.com/tlarsen7572/Golang-Public/ryx/ryxproject"
 	r "github.com/tlarsen7572/Golang-Public/ryx/testdocbuilder"
+	"github.com/tlarsen7572/Golang-Public/ryx/tool_data_loader"
+	"io/ioutil"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -447,6 +452,922 @@ func TestBatchChangeMacroSettingOnlyMatchingStoredPaths(t *testing.T) {
 	}
 }
 
+func TestExportPackage(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	proj, _ := ryxproject.Open(baseFolder)
+	workflow := filepath.Join(baseFolder, `01 SETLEAF Equations Completed.yxmd`)
+	packagePath := filepath.Join(baseFolder, `export.yxzp`)
+	result, err := proj.ExportPackage([]string{workflow}, packagePath, false)
+	if err != nil {
+		t.Fatalf(`expected no error but got: %v`, err.Error())
+	}
+	if count := len(result.Macros); count != 2 {
+		t.Fatalf(`expected 2 packaged macros but got %v`, count)
+	}
+	archive, err := zip.OpenReader(packagePath)
+	if err != nil {
+		t.Fatalf(`expected no error opening the package but got: %v`, err.Error())
+	}
+	defer archive.Close()
+	entries := map[string]*zip.File{}
+	for _, file := range archive.File {
+		entries[file.Name] = file
+	}
+	for _, expected := range []string{`01 SETLEAF Equations Completed.yxmd`, `Calculate Filter Expression.yxmc`, `macros/Tag with Sets.yxmc`} {
+		if _, ok := entries[expected]; !ok {
+			t.Fatalf(`expected package entry '%v' but it was missing`, expected)
+		}
+	}
+	reader, _ := entries[`01 SETLEAF Equations Completed.yxmd`].Open()
+	content, _ := ioutil.ReadAll(reader)
+	_ = reader.Close()
+	doc, err := ryxdoc.ReadBytes(content)
+	if err != nil {
+		t.Fatalf(`expected no error reading the packaged workflow but got: %v`, err.Error())
+	}
+	expected := filepath.Join(`macros`, `Tag with Sets.yxmc`)
+	expected = strings.Replace(expected, string(os.PathSeparator), `\`, -1)
+	if actual := doc.ReadMappedNodes()[18].ReadMacro().StoredPath; actual != expected {
+		t.Fatalf(`expected stored path of '%v' but got '%v'`, expected, actual)
+	}
+}
+
+func TestExportPackageWithEscapedDataPath(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	dataFolder, _ := ioutil.TempDir(``, `ryxdata`)
+	defer os.RemoveAll(dataFolder)
+	dataPath := filepath.Join(dataFolder, `Sales & Returns.csv`)
+	_ = ioutil.WriteFile(dataPath, []byte("Region,Sales\n"), 0644)
+	stored := strings.Replace(dataPath, `&`, `&amp;`, -1)
+	workflow := filepath.Join(baseFolder, `Input.yxmd`)
+	defer os.Remove(workflow)
+	_ = ioutil.WriteFile(workflow, []byte(`<?xml version="1.0"?>
+<AlteryxDocument yxmdVer="2019.3">
+  <Nodes>
+    <Node ToolID="1">
+      <GuiSettings Plugin="AlteryxBasePluginsGui.DbFileInput.DbFileInput">
+        <Position x="54" y="54" />
+      </GuiSettings>
+      <Properties>
+        <Configuration>
+          <File OutputFileName="" FileFormat="0">`+stored+`|||Sheet1</File>
+        </Configuration>
+        <Annotation DisplayMode="0">
+          <Name />
+          <DefaultAnnotationText />
+          <Left value="False" />
+        </Annotation>
+      </Properties>
+      <EngineSettings EngineDll="AlteryxBasePluginsEngine.dll" EngineDllEntryPoint="AlteryxDbFileInput" />
+    </Node>
+  </Nodes>
+  <Connections />
+  <Properties />
+</AlteryxDocument>`), 0644)
+
+	proj, _ := ryxproject.Open(baseFolder)
+	packagePath := filepath.Join(baseFolder, `export.yxzp`)
+	result, err := proj.ExportPackage([]string{workflow}, packagePath, true)
+	if err != nil {
+		t.Fatalf(`expected no error but got: %v`, err.Error())
+	}
+	if count := len(result.DataFiles); count != 1 {
+		t.Fatalf(`expected 1 packaged data file but got %v`, count)
+	}
+	archive, err := zip.OpenReader(packagePath)
+	if err != nil {
+		t.Fatalf(`expected no error opening the package but got: %v`, err.Error())
+	}
+	defer archive.Close()
+	var content []byte
+	for _, file := range archive.File {
+		if file.Name == `Input.yxmd` {
+			reader, _ := file.Open()
+			content, _ = ioutil.ReadAll(reader)
+			_ = reader.Close()
+		}
+	}
+	doc, err := ryxdoc.ReadBytes(content)
+	if err != nil {
+		t.Fatalf(`expected no error reading the packaged workflow but got: %v`, err.Error())
+	}
+	tool, _ := toolconfig.Read(doc.ReadMappedNodes()[1])
+	expected := `_externals\1\Sales & Returns.csv|||Sheet1`
+	if actual := tool.(*toolconfig.InputData).File(); actual != expected {
+		t.Fatalf(`expected file '%v' but got '%v'`, expected, actual)
+	}
+}
+
+func TestExportPackageWithoutDocuments(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	proj, _ := ryxproject.Open(baseFolder)
+	_, err := proj.ExportPackage([]string{}, filepath.Join(baseFolder, `export.yxzp`), false)
+	if err == nil {
+		t.Fatalf(`expected an error but got none`)
+	}
+}
+
+func TestImportPackageReusesIdenticalMacros(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	proj, _ := ryxproject.Open(baseFolder)
+	packagePath := filepath.Join(baseFolder, `export.yxzp`)
+	_, _ = proj.ExportPackage([]string{filepath.Join(baseFolder, `MultiInOut.yxmd`)}, packagePath, false)
+	target := filepath.Join(baseFolder, `imported`)
+
+	conflicts, err := proj.InspectPackage(packagePath, target)
+	if err != nil {
+		t.Fatalf(`expected no error but got: %v`, err.Error())
+	}
+	if count := len(conflicts); count != 1 {
+		t.Fatalf(`expected 1 conflict but got %v`, count)
+	}
+	if !conflicts[0].Identical {
+		t.Fatalf(`expected the conflicting macro to be identical but it was not`)
+	}
+
+	written, err := proj.ImportPackage(packagePath, target, nil)
+	if err != nil {
+		t.Fatalf(`expected no error but got: %v`, err.Error())
+	}
+	if count := len(written); count != 1 {
+		t.Fatalf(`expected 1 written file but got %v`, count)
+	}
+	doc, _ := ryxdoc.ReadFile(filepath.Join(target, `MultiInOut.yxmd`))
+	macro := doc.ReadMappedNodes()[6].ReadMacro(target)
+	if expected := filepath.Join(baseFolder, `MultiInOut.yxmc`); macro.FoundPath != expected {
+		t.Fatalf(`expected macro at '%v' but got '%v'`, expected, macro.FoundPath)
+	}
+}
+
+func TestImportPackageRenamesMacros(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	proj, _ := ryxproject.Open(baseFolder)
+	packagePath := filepath.Join(baseFolder, `export.yxzp`)
+	_, _ = proj.ExportPackage([]string{filepath.Join(baseFolder, `MultiInOut.yxmd`)}, packagePath, false)
+	target := filepath.Join(baseFolder, `imported`)
+	resolutions := map[string]ryxproject.ConflictResolution{
+		`MultiInOut.yxmc`: {Choice: ryxproject.RenameImported, NewName: `Renamed.yxmc`},
+	}
+	_, err := proj.ImportPackage(packagePath, target, resolutions)
+	if err != nil {
+		t.Fatalf(`expected no error but got: %v`, err.Error())
+	}
+	doc, _ := ryxdoc.ReadFile(filepath.Join(target, `MultiInOut.yxmd`))
+	macro := doc.ReadMappedNodes()[6].ReadMacro(target)
+	if expected := filepath.Join(target, `Renamed.yxmc`); macro.FoundPath != expected {
+		t.Fatalf(`expected macro at '%v' but got '%v'`, expected, macro.FoundPath)
+	}
+}
+
+func TestImportPackageOutsideProject(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	proj, _ := ryxproject.Open(baseFolder)
+	_, err := proj.ImportPackage(filepath.Join(baseFolder, `export.yxzp`), filepath.Dir(baseFolder), nil)
+	if err == nil {
+		t.Fatalf(`expected an error but got none`)
+	}
+}
+
+func TestImportPackageDoesNotOverwriteWorkflows(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	proj, _ := ryxproject.Open(baseFolder)
+	packagePath := filepath.Join(baseFolder, `export.yxzp`)
+	_, _ = proj.ExportPackage([]string{filepath.Join(baseFolder, `MultiInOut.yxmd`)}, packagePath, false)
+	target := filepath.Join(baseFolder, `imported`)
+	_, err := proj.ImportPackage(packagePath, target, nil)
+	if err != nil {
+		t.Fatalf(`expected no error but got: %v`, err.Error())
+	}
+
+	imported := filepath.Join(target, `MultiInOut.yxmd`)
+	doc, _ := ryxdoc.ReadFile(imported)
+	for id := range doc.ReadMappedNodes() {
+		if id != 6 {
+			doc.RemoveNodes(id)
+			break
+		}
+	}
+	_ = doc.Save(imported)
+	before, _ := ioutil.ReadFile(imported)
+
+	conflicts, err := proj.InspectPackage(packagePath, target)
+	if err != nil {
+		t.Fatalf(`expected no error but got: %v`, err.Error())
+	}
+	found := false
+	for _, conflict := range conflicts {
+		if conflict.Entry == `MultiInOut.yxmd` {
+			found = true
+			if conflict.Identical {
+				t.Fatalf(`expected the edited workflow to differ from the package but it was identical`)
+			}
+		}
+	}
+	if !found {
+		t.Fatalf(`expected the existing workflow to be reported as a conflict but it was not`)
+	}
+	_, err = proj.ImportPackage(packagePath, target, nil)
+	if err == nil {
+		t.Fatalf(`expected an error but got none`)
+	}
+	after, _ := ioutil.ReadFile(imported)
+	if string(before) != string(after) {
+		t.Fatalf(`expected the existing workflow to be left alone but it was overwritten`)
+	}
+}
+
+func TestImportPackageRejectsInvalidNewName(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	proj, _ := ryxproject.Open(baseFolder)
+	packagePath := filepath.Join(baseFolder, `export.yxzp`)
+	_, _ = proj.ExportPackage([]string{filepath.Join(baseFolder, `MultiInOut.yxmd`)}, packagePath, false)
+	target := filepath.Join(baseFolder, `imported`)
+	for _, newName := range []string{`../Renamed.yxmc`, `..`, `sub/Renamed.yxmc`, `sub\Renamed.yxmc`} {
+		resolutions := map[string]ryxproject.ConflictResolution{
+			`MultiInOut.yxmc`: {Choice: ryxproject.RenameImported, NewName: newName},
+		}
+		_, err := proj.ImportPackage(packagePath, target, resolutions)
+		if err == nil {
+			t.Fatalf(`expected an error for '%v' but got none`, newName)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(baseFolder, `Renamed.yxmc`)); err == nil {
+		t.Fatalf(`expected no macro to be written outside of the target folder`)
+	}
+}
+
+func TestImportPackageRejectsBackslashEntries(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	proj, _ := ryxproject.Open(baseFolder)
+	packagePath := filepath.Join(baseFolder, `export.yxzp`)
+	file, _ := os.Create(packagePath)
+	archive := zip.NewWriter(file)
+	writer, _ := archive.Create(`..\..\x.yxmd`)
+	content, _ := ioutil.ReadFile(filepath.Join(baseFolder, `MultiInOut.yxmd`))
+	_, _ = writer.Write(content)
+	_ = archive.Close()
+	_ = file.Close()
+
+	_, err := proj.ImportPackage(packagePath, filepath.Join(baseFolder, `imported`), nil)
+	if err == nil {
+		t.Fatalf(`expected an error but got none`)
+	}
+	_, err = proj.InspectPackage(packagePath, filepath.Join(baseFolder, `imported`))
+	if err == nil {
+		t.Fatalf(`expected an error but got none`)
+	}
+}
+
+func TestFindAndConsolidateDuplicateMacros(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	original := filepath.Join(baseFolder, `MultiInOut.yxmc`)
+	duplicate := filepath.Join(baseFolder, `macros`, `MultiInOut Copy.yxmc`)
+	doc, _ := ryxdoc.ReadFile(original)
+	doc.ReadMappedNodes()[2].SetPosition(300, 300)
+	_ = doc.Save(duplicate)
+
+	proj, _ := ryxproject.Open(baseFolder)
+	groups, err := proj.FindDuplicateMacros()
+	if err != nil {
+		t.Fatalf(`expected no error but got: %v`, err.Error())
+	}
+	if count := len(groups); count != 1 {
+		t.Fatalf(`expected 1 duplicate group but got %v`, count)
+	}
+	if count := len(groups[0].Paths); count != 2 {
+		t.Fatalf(`expected 2 duplicate macros but got %v`, count)
+	}
+
+	changed, err := proj.ConsolidateMacros(duplicate, []string{original})
+	if err != nil {
+		t.Fatalf(`expected no error but got: %v`, err.Error())
+	}
+	if changed != 1 {
+		t.Fatalf(`expected 1 changed doc but got %v`, changed)
+	}
+	workflow, _ := ryxdoc.ReadFile(filepath.Join(baseFolder, `MultiInOut.yxmd`))
+	if found := workflow.ReadMappedNodes()[6].ReadMacro().FoundPath; found != duplicate {
+		t.Fatalf(`expected macro at '%v' but got '%v'`, duplicate, found)
+	}
+}
+
+func TestConsolidateMacrosThatAreNotDuplicates(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	proj, _ := ryxproject.Open(baseFolder)
+	canonical := filepath.Join(baseFolder, `MultiInOut.yxmc`)
+	other := filepath.Join(baseFolder, `Calculate Filter Expression.yxmc`)
+	_, err := proj.ConsolidateMacros(canonical, []string{other})
+	if err == nil {
+		t.Fatalf(`expected an error but got none`)
+	}
+}
+
+func TestPreviewRenameByPattern(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	proj, _ := ryxproject.Open(baseFolder)
+	renames, err := proj.PreviewRenameByPattern(`MultiInOut.*`, `v2_MultiInOut.$1`, true)
+	if err != nil {
+		t.Fatalf(`expected no error but got: %v`, err.Error())
+	}
+	if count := len(renames); count != 2 {
+		t.Fatalf(`expected 2 renames but got %v`, count)
+	}
+	expected := filepath.Join(baseFolder, `v2_MultiInOut.yxmc`)
+	if renames[0].To != expected {
+		t.Fatalf(`expected new name '%v' but got '%v'`, expected, renames[0].To)
+	}
+	if renames[0].Collision != `` {
+		t.Fatalf(`expected no collision but got '%v'`, renames[0].Collision)
+	}
+}
+
+func TestRenameByPattern(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	proj, _ := ryxproject.Open(baseFolder)
+	errFiles, err := proj.RenameByPattern(`^Multi(.*)\.yxmc$`, `Many$1.yxmc`, false)
+	if err != nil {
+		t.Fatalf(`expected no error but got: %v`, err.Error())
+	}
+	if count := len(errFiles); count != 0 {
+		t.Fatalf(`expected 0 file errors but got %v`, count)
+	}
+	newMacro := filepath.Join(baseFolder, `ManyInOut.yxmc`)
+	workflow, _ := ryxdoc.ReadFile(filepath.Join(baseFolder, `MultiInOut.yxmd`))
+	if found := workflow.ReadMappedNodes()[6].ReadMacro().FoundPath; found != newMacro {
+		t.Fatalf(`expected macro at '%v' but got '%v'`, newMacro, found)
+	}
+}
+
+func TestRenameByPatternWithCollision(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	proj, _ := ryxproject.Open(baseFolder)
+	_, err := proj.RenameByPattern(`MultiInOut.*`, `Interface.yxmc`, true)
+	if err == nil {
+		t.Fatalf(`expected an error but got none`)
+	}
+	if _, err := os.Stat(filepath.Join(baseFolder, `MultiInOut.yxmc`)); err != nil {
+		t.Fatalf(`expected the original macro to still exist but got: %v`, err.Error())
+	}
+}
+
+func TestMoveFolder(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	from := filepath.Join(baseFolder, `macros`)
+	toParent := filepath.Join(baseFolder, `library`)
+	_ = os.Mkdir(toParent, 0777)
+	_ = ioutil.WriteFile(filepath.Join(from, `data.csv`), []byte(`A,B`), 0644)
+	proj, _ := ryxproject.Open(baseFolder)
+	failed, err := proj.MoveFolder(from, toParent, false)
+	if err != nil {
+		t.Fatalf(`expected no error but got: %v`, err.Error())
+	}
+	if count := len(failed); count != 0 {
+		t.Fatalf(`expected 0 failed files but got %v`, count)
+	}
+	if _, err := os.Stat(from); !os.IsNotExist(err) {
+		t.Fatalf(`expected '%v' to no longer exist`, from)
+	}
+	if _, err := os.Stat(filepath.Join(toParent, `macros`, `data.csv`)); err != nil {
+		t.Fatalf(`expected the data file to be moved but got: %v`, err.Error())
+	}
+	expectedMacro := filepath.Join(toParent, `macros`, `Tag with Sets.yxmc`)
+	workflow, _ := ryxdoc.ReadFile(filepath.Join(baseFolder, `01 SETLEAF Equations Completed.yxmd`))
+	if found := workflow.ReadMappedNodes()[18].ReadMacro().FoundPath; found != expectedMacro {
+		t.Fatalf(`expected macro at '%v' but got '%v'`, expectedMacro, found)
+	}
+}
+
+func TestMoveFolderIntoExistingFolder(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	from := filepath.Join(baseFolder, `macros`)
+	toParent := filepath.Join(baseFolder, `library`)
+	_ = os.MkdirAll(filepath.Join(toParent, `macros`), 0777)
+	_ = ioutil.WriteFile(filepath.Join(toParent, `macros`, `existing.csv`), []byte(`A,B`), 0644)
+	proj, _ := ryxproject.Open(baseFolder)
+	_, err := proj.MoveFolder(from, toParent, false)
+	if err == nil {
+		t.Fatalf(`expected an error without merging but got none`)
+	}
+	_, err = proj.MoveFolder(from, toParent, true)
+	if err != nil {
+		t.Fatalf(`expected no error when merging but got: %v`, err.Error())
+	}
+	for _, file := range []string{`existing.csv`, `Tag with Sets.yxmc`} {
+		if _, err := os.Stat(filepath.Join(toParent, `macros`, file)); err != nil {
+			t.Fatalf(`expected '%v' in the merged folder but got: %v`, file, err.Error())
+		}
+	}
+}
+
+func TestMoveFolderIntoItself(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	from := filepath.Join(baseFolder, `macros`)
+	proj, _ := ryxproject.Open(baseFolder)
+	_, err := proj.MoveFolder(from, from, false)
+	if err == nil {
+		t.Fatalf(`expected an error but got none`)
+	}
+}
+
+func TestAutoLayoutKeepsXmlDeclaration(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	proj, _ := ryxproject.Open(baseFolder)
+	workflow := filepath.Join(baseFolder, `01 SETLEAF Equations Completed.yxmd`)
+	_, err := proj.AutoLayout(workflow)
+	if err != nil {
+		t.Fatalf(`expected no error but got: %v`, err.Error())
+	}
+	saved, _ := ioutil.ReadFile(workflow)
+	if !strings.HasPrefix(string(saved), `<?xml version="1.0"?>`) {
+		t.Fatalf(`expected the XML declaration to be kept`)
+	}
+}
+
+func TestWrapAndUnwrapContainer(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	proj, _ := ryxproject.Open(baseFolder)
+	workflow := filepath.Join(baseFolder, `01 SETLEAF Equations Completed.yxmd`)
+	_, err := proj.WrapInContainer(workflow, `Branches`, ``, 14, 15)
+	if err != nil {
+		t.Fatalf(`expected no error but got: %v`, err.Error())
+	}
+	saved, err := ryxdoc.ReadFile(workflow)
+	if err != nil {
+		t.Fatalf(`expected no error reading the saved workflow but got: %v`, err.Error())
+	}
+	if count := len(saved.ReadMappedNodes()[24].ChildNodes); count != 2 {
+		t.Fatalf(`expected 2 tools in the saved container but got %v`, count)
+	}
+
+	_, err = proj.UnwrapContainer(workflow, 24)
+	if err != nil {
+		t.Fatalf(`expected no error but got: %v`, err.Error())
+	}
+	saved, _ = ryxdoc.ReadFile(workflow)
+	if count := len(saved.ReadMappedNodes()); count != 16 {
+		t.Fatalf(`expected 16 nodes but got %v`, count)
+	}
+}
+
+func TestValidateWorkflows(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	workflow := filepath.Join(baseFolder, `01 SETLEAF Equations Completed.yxmd`)
+	doc, _ := ryxdoc.ReadFile(workflow)
+	doc.AddConnection(&ryxdoc.RyxConn{FromId: 100, FromAnchor: `Output`, ToId: 13, ToAnchor: `Input`})
+	_ = doc.Save(workflow)
+	broken := filepath.Join(baseFolder, `Broken.yxmd`)
+	_ = ioutil.WriteFile(broken, []byte(`<AlteryxDocument>`), 0644)
+
+	proj, _ := ryxproject.Open(baseFolder)
+	findings, err := proj.ValidateWorkflows()
+	if err != nil {
+		t.Fatalf(`expected no error but got: %v`, err.Error())
+	}
+	if len(findings) != 2 {
+		t.Fatalf(`expected findings for 2 files but got %v`, len(findings))
+	}
+	if issues := findings[workflow]; len(issues) != 1 || issues[0].ToolId != `100` {
+		t.Fatalf(`expected 1 issue for tool 100 but got %v`, issues)
+	}
+	if issues := findings[broken]; len(issues) != 1 {
+		t.Fatalf(`expected 1 issue for the broken file but got %v`, issues)
+	}
+}
+
+func TestRenumberTools(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	proj, _ := ryxproject.Open(baseFolder)
+	macro := filepath.Join(baseFolder, `Interface.yxmc`)
+	_, err := proj.RenumberTools(macro, ryxdoc.RenumberByPosition)
+	if err != nil {
+		t.Fatalf(`expected no error but got: %v`, err.Error())
+	}
+	saved, err := ryxdoc.ReadFile(macro)
+	if err != nil {
+		t.Fatalf(`expected no error reading the saved macro but got: %v`, err.Error())
+	}
+	if issues := saved.Validate(nil); len(issues) != 0 {
+		t.Fatalf(`expected no issues but got %v: %v`, len(issues), issues[0].Problem)
+	}
+	if _, ok := saved.ReadMappedNodes()[len(saved.ReadMappedNodes())]; !ok {
+		t.Fatalf(`expected the tool IDs to be consecutive`)
+	}
+}
+
+func TestConvertDocumentType(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	proj, _ := ryxproject.Open(baseFolder)
+	macro := filepath.Join(baseFolder, `Interface.yxmc`)
+	newPath, err := proj.ConvertDocumentType(macro, ryxdoc.Workflow, nil)
+	if err != nil {
+		t.Fatalf(`expected no error but got: %v`, err.Error())
+	}
+	expected := filepath.Join(baseFolder, `Interface.yxmd`)
+	if newPath != expected {
+		t.Fatalf(`expected new path '%v' but got '%v'`, expected, newPath)
+	}
+	if _, err := os.Stat(macro); err == nil {
+		t.Fatalf(`expected the old file to be removed`)
+	}
+	converted, err := ryxdoc.ReadFile(newPath)
+	if err != nil {
+		t.Fatalf(`expected no error reading the converted file but got: %v`, err.Error())
+	}
+	if docType := converted.ReadDocumentType(); docType != ryxdoc.Workflow {
+		t.Fatalf(`expected a workflow but got %v`, docType)
+	}
+}
+
+func TestConvertDocumentTypeUsedMacro(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	proj, _ := ryxproject.Open(baseFolder)
+	macro := filepath.Join(baseFolder, `Calculate Filter Expression.yxmc`)
+	before, _ := ioutil.ReadFile(macro)
+	_, err := proj.ConvertDocumentType(macro, ryxdoc.Workflow, nil)
+	if err == nil {
+		t.Fatalf(`expected an error but got none`)
+	}
+	if !strings.Contains(err.Error(), `01 SETLEAF Equations Completed.yxmd`) {
+		t.Fatalf(`expected the error to list the workflow using the macro but got: %v`, err.Error())
+	}
+	after, _ := ioutil.ReadFile(macro)
+	if string(before) != string(after) {
+		t.Fatalf(`expected the macro to be left alone but it was changed`)
+	}
+	if _, err := os.Stat(filepath.Join(baseFolder, `Calculate Filter Expression.yxmd`)); err == nil {
+		t.Fatalf(`expected no converted file to be written`)
+	}
+}
+
+func TestConvertDocumentTypeOntoExistingFile(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	proj, _ := ryxproject.Open(baseFolder)
+	_, err := proj.ConvertDocumentType(filepath.Join(baseFolder, `MultiInOut.yxmc`), ryxdoc.Workflow, nil)
+	if err == nil {
+		t.Fatalf(`expected an error but got none`)
+	}
+}
+
+func TestCopyTools(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	proj, _ := ryxproject.Open(baseFolder)
+	from := filepath.Join(baseFolder, `01 SETLEAF Equations Completed.yxmd`)
+	to := filepath.Join(baseFolder, `macros`, `Tag with Sets.yxmc`)
+	doc, err := proj.CopyTools(from, to, 100, 100, 18, 19)
+	if err != nil {
+		t.Fatalf(`expected no error but got: %v`, err.Error())
+	}
+	saved, _ := ryxdoc.ReadFile(to)
+	if len(saved.ReadMappedNodes()) != len(doc.ReadMappedNodes()) {
+		t.Fatalf(`expected the copied tools to be saved`)
+	}
+	found := false
+	for _, node := range saved.ReadMappedNodes() {
+		if macro := node.ReadMacro(); macro.StoredPath == `Tag with Sets.yxmc` {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf(`expected the copied macro to be stored relative to the destination`)
+	}
+}
+
+func TestRenderSvg(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	proj, _ := ryxproject.Open(baseFolder)
+	toolData := []tool_data_loader.ToolData{
+		{Plugin: `AlteryxBasePluginsGui.Join.Join`, Inputs: []string{`Left`, `Right`}, Outputs: []string{`Left`, `Join`, `Right`}, Icon: `am9pbg==`},
+	}
+	image, err := proj.RenderSvg(filepath.Join(baseFolder, `01 SETLEAF Equations Completed.yxmd`), toolData)
+	if err != nil {
+		t.Fatalf(`expected no error but got: %v`, err.Error())
+	}
+	svg := string(image)
+	if count := strings.Count(svg, `<image `); count != 2 {
+		t.Fatalf(`expected icons for the Join tool and the Calculate Filter Expression macro but got %v icons`, count)
+	}
+	if !strings.Contains(svg, `href="data:image/png;base64,am9pbg=="`) {
+		t.Fatalf(`expected the Join tool's icon`)
+	}
+}
+
+func TestGenerateDocs(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	outputFolder, _ := ioutil.TempDir(``, `ryxdocs`)
+	defer os.RemoveAll(outputFolder)
+
+	proj, _ := ryxproject.Open(baseFolder)
+	indexPath, err := proj.GenerateDocs(outputFolder, nil)
+	if err != nil {
+		t.Fatalf(`expected no error but got: %v`, err.Error())
+	}
+	index, _ := ioutil.ReadFile(indexPath)
+	if count := strings.Count(string(index), `<a href=`); count != 7 {
+		t.Fatalf(`expected 7 documents in the index but got %v`, count)
+	}
+	macroPage, err := ioutil.ReadFile(filepath.Join(outputFolder, `macros`, `Tag with Sets.yxmc.html`))
+	if err != nil {
+		t.Fatalf(`expected a page for the macro but got: %v`, err.Error())
+	}
+	if !strings.Contains(string(macroPage), `<a href="../01%20SETLEAF%20Equations%20Completed.yxmd.html">`) {
+		t.Fatalf(`expected the macro page to link to the workflow using it but got: %v`, string(macroPage))
+	}
+	workflowPage, _ := ioutil.ReadFile(filepath.Join(outputFolder, `01 SETLEAF Equations Completed.yxmd.html`))
+	for _, expected := range []string{`<svg `, `<td>TextInput</td><td>3</td>`, `<td>SETLEAF</td>`, `macros\Tag with Sets.yxmc`} {
+		if !strings.Contains(string(workflowPage), expected) {
+			t.Fatalf(`expected the workflow page to contain '%v' but got: %v`, expected, string(workflowPage))
+		}
+	}
+}
+
+func TestRenameField(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	proj, _ := ryxproject.Open(baseFolder)
+	path := filepath.Join(baseFolder, `01 SETLEAF Equations Completed.yxmd`)
+	_, issues, err := proj.RenameField(path, 14, `Filter`, `Filters`)
+	if err != nil {
+		t.Fatalf(`expected no error but got: %v`, err.Error())
+	}
+	if len(issues) != 2 {
+		t.Fatalf(`expected 2 issues but got %v`, len(issues))
+	}
+	saved, _ := ryxdoc.ReadFile(path)
+	config := saved.ReadMappedNodes()[17].Properties.Configuration.InnerXml
+	if !strings.Contains(config, `rename="Filters"`) {
+		t.Fatalf(`expected the renamed field to be saved but got %v`, config)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	proj, _ := ryxproject.Open(baseFolder)
+	results, err := proj.Search(`setheader`, false)
+	if err != nil {
+		t.Fatalf(`expected no error but got: %v`, err.Error())
+	}
+	if len(results) != 1 {
+		t.Fatalf(`expected 1 result but got %v`, len(results))
+	}
+	result := results[0]
+	if filepath.Base(result.Path) != `01 SETLEAF Equations Completed.yxmd` || result.ToolId != 4 || result.Source != ryxproject.SearchAnnotation || result.Snippet != `SETHEADER` {
+		t.Fatalf(`expected the annotation of tool 4 but got %v`, result)
+	}
+
+	results, _ = proj.Search(`Macro Input (1)`, false)
+	found := false
+	for _, result := range results {
+		if result.Source == ryxproject.SearchConstant && result.ToolId == 0 {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf(`expected a document constant in the results but got %v`, results)
+	}
+}
+
+func TestSearchExpressionsWithRegex(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	proj, _ := ryxproject.Open(baseFolder)
+	results, err := proj.Search(`\[VALOPTION\] = '(NE|GE)'`, true)
+	if err != nil {
+		t.Fatalf(`expected no error but got: %v`, err.Error())
+	}
+	if len(results) != 2 {
+		t.Fatalf(`expected 2 results but got %v`, len(results))
+	}
+	for _, result := range results {
+		if filepath.Base(result.Path) != `Calculate Filter Expression.yxmc` || result.Source != ryxproject.SearchExpression {
+			t.Fatalf(`expected a formula expression in Calculate Filter Expression.yxmc but got %v`, result)
+		}
+		if !strings.HasPrefix(result.Snippet, `IF [VALOPTION] = '`) || strings.Contains(result.Snippet, "\n") {
+			t.Fatalf(`expected the snippet to start at the expression and be on one line but got '%v'`, result.Snippet)
+		}
+	}
+
+	_, err = proj.Search(`[`, true)
+	if err == nil {
+		t.Fatalf(`expected an error for an invalid regular expression but got none`)
+	}
+}
+
+func TestSearchFindsChangedDocuments(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	proj, _ := ryxproject.Open(baseFolder)
+	results, _ := proj.Search(`Filter_Equations`, false)
+	if len(results) != 0 {
+		t.Fatalf(`expected no results before the change but got %v`, len(results))
+	}
+	path := filepath.Join(baseFolder, `01 SETLEAF Equations Completed.yxmd`)
+	_, _, err := proj.RenameField(path, 14, `Filter`, `Filter_Equations`)
+	if err != nil {
+		t.Fatalf(`expected no error but got: %v`, err.Error())
+	}
+	results, _ = proj.Search(`filter_equations`, false)
+	if len(results) != 2 {
+		t.Fatalf(`expected the renamed field in tools 14 and 17 but got %v`, len(results))
+	}
+}
+
+func TestSearchDropsUnreadableDocuments(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	proj, _ := ryxproject.Open(baseFolder)
+	path := filepath.Join(baseFolder, `01 SETLEAF Equations Completed.yxmd`)
+	results, _ := proj.Search(`VALSIGN`, false)
+	if !searchResultsContain(results, path) {
+		t.Fatalf(`expected results from '%v' before the change but got none`, path)
+	}
+	_ = ioutil.WriteFile(path, []byte(`not a document`), 0644)
+	results, _ = proj.Search(`VALSIGN`, false)
+	if searchResultsContain(results, path) {
+		t.Fatalf(`expected no results from the unreadable document but got some`)
+	}
+}
+
+func searchResultsContain(results []*ryxproject.SearchResult, path string) bool {
+	for _, result := range results {
+		if result.Path == path {
+			return true
+		}
+	}
+	return false
+}
+
+func TestExtractMacro(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	proj, _ := ryxproject.Open(baseFolder)
+	workflow := filepath.Join(baseFolder, `01 SETLEAF Equations Completed.yxmd`)
+	macroPath := filepath.Join(baseFolder, `macros`, `Extracted.yxmc`)
+	doc, err := proj.ExtractMacro(workflow, macroPath, true, nil, 14, 15, 16)
+	if err != nil {
+		t.Fatalf(`expected no error but got: %v`, err.Error())
+	}
+	if _, err := os.Stat(macroPath); err != nil {
+		t.Fatalf(`expected the macro to exist but got: %v`, err.Error())
+	}
+	if count := len(doc.ReadMappedNodes()); count != 14 {
+		t.Fatalf(`expected 14 nodes in the returned doc but got %v`, count)
+	}
+	saved, _ := ryxdoc.ReadFile(workflow)
+	macro := saved.ReadMappedNodes()[24].ReadMacro(baseFolder)
+	if macro.FoundPath != macroPath {
+		t.Fatalf(`expected macro at '%v' but got '%v'`, macroPath, macro.FoundPath)
+	}
+	expectedStored := strings.Replace(filepath.Join(`macros`, `Extracted.yxmc`), string(os.PathSeparator), `\`, -1)
+	if macro.StoredPath != expectedStored {
+		t.Fatalf(`expected stored path '%v' but got '%v'`, expectedStored, macro.StoredPath)
+	}
+}
+
+func TestInlineMacro(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	proj, _ := ryxproject.Open(baseFolder)
+	workflow := filepath.Join(baseFolder, `01 SETLEAF Equations Completed.yxmd`)
+	_, dropped, err := proj.InlineMacro(workflow, 12)
+	if err != nil {
+		t.Fatalf(`expected no error but got: %v`, err.Error())
+	}
+	if len(dropped) != 1 || dropped[0] != 5 {
+		t.Fatalf(`expected interface tool 5 to be reported as dropped but got %v`, dropped)
+	}
+	saved, _ := ryxdoc.ReadFile(workflow)
+	if _, ok := saved.ReadMappedNodes()[12]; ok {
+		t.Fatalf(`expected the macro tool to be removed from the saved workflow`)
+	}
+}
+
+func TestExtractMacroWithHole(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	proj, _ := ryxproject.Open(baseFolder)
+	workflow := filepath.Join(baseFolder, `01 SETLEAF Equations Completed.yxmd`)
+	macroPath := filepath.Join(baseFolder, `Extracted.yxmc`)
+	_, err := proj.ExtractMacro(workflow, macroPath, false, nil, 13, 15, 16, 17)
+	if err != ryxdoc.ErrSelectionHasHole {
+		t.Fatalf(`expected the selection hole error but got: %v`, err)
+	}
+	if _, err := os.Stat(macroPath); !os.IsNotExist(err) {
+		t.Fatalf(`expected no macro to be created`)
+	}
+}
+
+func TestDiffDocuments(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	proj, _ := ryxproject.Open(baseFolder)
+	workflow := filepath.Join(baseFolder, `01 SETLEAF Equations Completed.yxmd`)
+	diff, err := proj.DiffDocuments(workflow, workflow)
+	if err != nil {
+		t.Fatalf(`expected no error but got: %v`, err.Error())
+	}
+	if !diff.IsEmpty() {
+		t.Fatalf(`expected no differences but got: %v`, diff.String())
+	}
+}
+
+func TestDiffRevision(t *testing.T) {
+	if _, err := exec.LookPath(`git`); err != nil {
+		t.Skip(`git is not available`)
+	}
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	proj, _ := ryxproject.Open(baseFolder)
+	workflow := filepath.Join(baseFolder, `01 SETLEAF Equations Completed.yxmd`)
+	doc, _ := ryxdoc.ReadFile(workflow)
+	doc.RemoveNodes(19)
+	_ = doc.Save(workflow)
+
+	diff, err := proj.DiffRevision(workflow, `HEAD`)
+	if err != nil {
+		t.Skip(`the test documents are not in a git repository`)
+	}
+	if count := len(diff.RemovedTools); count != 1 {
+		t.Fatalf(`expected 1 removed tool but got %v`, count)
+	}
+}
+
+func TestDiffInvalidRevision(t *testing.T) {
+	r.RebuildTestdocs(baseFolder)
+	defer r.RebuildTestdocs(baseFolder)
+
+	proj, _ := ryxproject.Open(baseFolder)
+	workflow := filepath.Join(baseFolder, `01 SETLEAF Equations Completed.yxmd`)
+	_, err := proj.DiffRevision(workflow, `--not-a-revision`)
+	if err == nil {
+		t.Fatalf(`expected an error but got none`)
+	}
+}
+
 func generateAbsPath(path ...string) (string, error) {
 	return filepath.Abs(filepath.Join(path...))
 }
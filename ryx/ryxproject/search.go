@@ -0,0 +1,330 @@
+package ryxproject
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"github.com/tlarsen7572/Golang-Public/ryx/ryxdoc"
+	"github.com/tlarsen7572/Golang-Public/ryx/ryxnode"
+	"github.com/tlarsen7572/Golang-Public/ryx/ryxnode/toolconfig"
+	"github.com/tlarsen7572/Golang-Public/txml"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+type SearchResult struct {
+	Path    string
+	ToolId  int
+	Source  string
+	Snippet string
+}
+
+const SearchPlugin = `Plugin`
+const SearchMacro = `Macro`
+const SearchConfiguration = `Configuration`
+const SearchExpression = `Expression`
+const SearchAnnotation = `Annotation`
+const SearchConstant = `Constant`
+
+const searchIndexVersion = 1
+const snippetPadding = 30
+
+// searchIndex is the text of every document in the project, along with an inverted index from the words in that
+// text to the documents containing them.  It is saved between searches so that only documents which changed since
+// the last search are read again.
+type searchIndex struct {
+	Version int
+	Docs    map[string]*searchDoc
+	Terms   map[string][]string
+}
+
+type searchDoc struct {
+	ModTime int64
+	Size    int64
+	Entries []searchEntry
+}
+
+type searchEntry struct {
+	ToolId int
+	Source string
+	Text   string
+}
+
+// Search finds text in the plugin names, macro paths, configurations, expressions and annotations of every tool in
+// the project, as well as the constants of every document.  The query is matched without regard to case unless
+// isRegex is true, in which case it is used as a regular expression.  Document-level matches, such as constants,
+// have a ToolId of 0.
+func (ryxProject *RyxProject) Search(query string, isRegex bool) ([]*SearchResult, error) {
+	if query == `` {
+		return nil, errors.New(`the search query cannot be blank`)
+	}
+	pattern := `(?i)` + regexp.QuoteMeta(query)
+	if isRegex {
+		pattern = query
+	}
+	matcher, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	structure, err := ryxProject.Structure()
+	if err != nil {
+		return nil, err
+	}
+
+	indexPath := searchIndexPath(ryxProject.path)
+	index := readSearchIndex(indexPath)
+	if index.refresh(structure.AllFiles()) && indexPath != `` {
+		_ = index.save(indexPath)
+	}
+
+	words := query
+	if isRegex {
+		words, _ = matcher.LiteralPrefix()
+	}
+	results := []*SearchResult{}
+	for _, path := range index.candidates(words) {
+		for _, entry := range index.Docs[path].Entries {
+			location := matcher.FindStringIndex(entry.Text)
+			if location == nil {
+				continue
+			}
+			results = append(results, &SearchResult{
+				Path:    path,
+				ToolId:  entry.ToolId,
+				Source:  entry.Source,
+				Snippet: readSnippet(entry.Text, location[0], location[1]),
+			})
+		}
+	}
+	return results, nil
+}
+
+// searchIndexPath keeps the index in the user's cache folder rather than the project, so it does not show up next
+// to the documents or in source control.  A blank path means the index cannot be saved.
+func searchIndexPath(projectPath string) string {
+	cache, err := os.UserCacheDir()
+	if err != nil {
+		return ``
+	}
+	sum := sha1.Sum([]byte(projectPath))
+	return filepath.Join(cache, `ryx`, `search`, hex.EncodeToString(sum[:])+`.json`)
+}
+
+func readSearchIndex(path string) *searchIndex {
+	index := &searchIndex{}
+	content, err := ioutil.ReadFile(path)
+	if err != nil || json.Unmarshal(content, index) != nil || index.Version != searchIndexVersion || index.Docs == nil {
+		return &searchIndex{Version: searchIndexVersion, Docs: map[string]*searchDoc{}, Terms: map[string][]string{}}
+	}
+	return index
+}
+
+func (index *searchIndex) save(path string) error {
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+	content, err := json.Marshal(index)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, content, 0644)
+}
+
+// refresh re-reads documents that were added or changed since the index was built and drops documents that no
+// longer exist.  It returns whether anything changed.
+func (index *searchIndex) refresh(paths []string) bool {
+	changed := false
+	found := map[string]bool{}
+	for _, path := range paths {
+		found[path] = true
+		stat, err := os.Stat(path)
+		if err != nil {
+			changed = index.drop(path) || changed
+			continue
+		}
+		existing, ok := index.Docs[path]
+		if ok && existing.ModTime == stat.ModTime().UnixNano() && existing.Size == stat.Size() {
+			continue
+		}
+		doc, err := ryxdoc.ReadFile(path)
+		if err != nil {
+			changed = index.drop(path) || changed
+			continue
+		}
+		index.Docs[path] = &searchDoc{
+			ModTime: stat.ModTime().UnixNano(),
+			Size:    stat.Size(),
+			Entries: readSearchEntries(doc),
+		}
+		changed = true
+	}
+	for path := range index.Docs {
+		if !found[path] {
+			delete(index.Docs, path)
+			changed = true
+		}
+	}
+	if changed || index.Terms == nil {
+		index.Terms = map[string][]string{}
+		for path, doc := range index.Docs {
+			terms := map[string]bool{}
+			for _, entry := range doc.Entries {
+				for _, term := range splitTerms(entry.Text) {
+					terms[term] = true
+				}
+			}
+			for term := range terms {
+				index.Terms[term] = append(index.Terms[term], path)
+			}
+		}
+	}
+	return changed
+}
+
+// drop removes a document that can no longer be read, so its old text is not searched.  It returns whether the
+// document was in the index.
+func (index *searchIndex) drop(path string) bool {
+	if _, ok := index.Docs[path]; !ok {
+		return false
+	}
+	delete(index.Docs, path)
+	return true
+}
+
+// candidates returns the documents that might match the words, in path order.  Words only need to be part of an
+// indexed term, so partial words still find their documents.
+func (index *searchIndex) candidates(words string) []string {
+	var matching map[string]bool
+	for _, word := range splitTerms(words) {
+		docs := map[string]bool{}
+		for term, paths := range index.Terms {
+			if !strings.Contains(term, word) {
+				continue
+			}
+			for _, path := range paths {
+				if matching == nil || matching[path] {
+					docs[path] = true
+				}
+			}
+		}
+		matching = docs
+	}
+	paths := []string{}
+	for path := range index.Docs {
+		if matching == nil || matching[path] {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func readSearchEntries(doc *ryxdoc.RyxDoc) []searchEntry {
+	entries := []searchEntry{}
+	nodes := doc.ReadMappedNodes()
+	for _, id := range sortedNodeIds(doc) {
+		node := nodes[id]
+		add := func(source string, text string) {
+			if text = strings.TrimSpace(text); text != `` {
+				entries = append(entries, searchEntry{ToolId: id, Source: source, Text: text})
+			}
+		}
+		add(SearchPlugin, node.ReadPlugin())
+		if node.ReadCategory() == ryxnode.Macro {
+			add(SearchMacro, node.ReadMacro().StoredPath)
+		}
+		if node.Properties == nil {
+			continue
+		}
+		config, err := toolconfig.ReadConfig(node)
+		if err == nil {
+			texts, expressions := readConfigTexts(config.Xml)
+			add(SearchConfiguration, strings.Join(texts, "\n"))
+			for _, expression := range expressions {
+				add(SearchExpression, expression)
+			}
+		}
+		if node.Properties.Annotation != nil {
+			add(SearchAnnotation, toolconfig.ReadText(node.Properties.Annotation.First(`Name`)))
+			add(SearchAnnotation, toolconfig.ReadText(node.Properties.Annotation.First(`AnnotationText`)))
+		}
+	}
+	if doc.Properties != nil {
+		for _, constant := range doc.Properties.First(`Constants`).AllNodes(`Constant`) {
+			name := strings.TrimSpace(toolconfig.ReadText(constant.First(`Name`)))
+			value := strings.TrimSpace(toolconfig.ReadText(constant.First(`Value`)))
+			entries = append(entries, searchEntry{Source: SearchConstant, Text: name + `=` + value})
+		}
+	}
+	return entries
+}
+
+// readConfigTexts collects the text and attribute values of a configuration.  Formula and Filter expressions are
+// returned separately so they can be reported as expressions.
+func readConfigTexts(element *txml.Node) (texts []string, expressions []string) {
+	names := []string{}
+	for name := range element.Attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if name == `expression` {
+			expressions = append(expressions, element.Attributes[name])
+			continue
+		}
+		texts = append(texts, element.Attributes[name])
+	}
+	if text := strings.TrimSpace(toolconfig.ReadText(element)); text != `` {
+		if element.Name == `Expression` {
+			expressions = append(expressions, text)
+		} else {
+			texts = append(texts, text)
+		}
+	}
+	for _, child := range element.Nodes {
+		childTexts, childExpressions := readConfigTexts(child)
+		texts = append(texts, childTexts...)
+		expressions = append(expressions, childExpressions...)
+	}
+	return texts, expressions
+}
+
+func splitTerms(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(char rune) bool {
+		return !unicode.IsLetter(char) && !unicode.IsDigit(char) && char != '_'
+	})
+}
+
+// readSnippet returns the match with some of the text around it on one line.
+func readSnippet(text string, start int, end int) string {
+	from := start - snippetPadding
+	prefix := `...`
+	if from <= 0 {
+		from = 0
+		prefix = ``
+	}
+	to := end + snippetPadding
+	suffix := `...`
+	if to >= len(text) {
+		to = len(text)
+		suffix = ``
+	}
+	for from > 0 && !isRuneStart(text[from]) {
+		from--
+	}
+	for to < len(text) && !isRuneStart(text[to]) {
+		to++
+	}
+	return prefix + strings.Join(strings.Fields(text[from:to]), ` `) + suffix
+}
+
+func isRuneStart(char byte) bool {
+	return char&0xC0 != 0x80
+}
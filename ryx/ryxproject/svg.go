@@ -0,0 +1,53 @@
+package ryxproject
+
+import (
+	"github.com/tlarsen7572/Golang-Public/ryx/ryxdoc"
+	"github.com/tlarsen7572/Golang-Public/ryx/ryxnode"
+	"github.com/tlarsen7572/Golang-Public/ryx/tool_data_loader"
+	"path/filepath"
+)
+
+type graphicReader struct {
+	tools  map[string]tool_data_loader.ToolData
+	macros map[string]*macroAnchors
+}
+
+// RenderSvg draws a document as an SVG image.  Icons and anchors for built-in tools come from toolData.  Macros are
+// read from disk, so they are drawn with their own icons even when they are not in toolData.
+func (ryxProject *RyxProject) RenderSvg(docPath string, toolData []tool_data_loader.ToolData) ([]byte, error) {
+	doc, err := ryxProject.RetrieveDocument(docPath)
+	if err != nil {
+		return nil, err
+	}
+	graphics := newGraphicReader(toolData)
+	return doc.RenderSvg(graphics.forFolder(ryxProject.generateMacroPaths(filepath.Dir(docPath)))), nil
+}
+
+func newGraphicReader(toolData []tool_data_loader.ToolData) *graphicReader {
+	tools := map[string]tool_data_loader.ToolData{}
+	for _, tool := range toolData {
+		tools[tool.Plugin] = tool
+	}
+	return &graphicReader{tools: tools, macros: map[string]*macroAnchors{}}
+}
+
+// forFolder returns a ToolGraphic that finds macros with the given macro paths.  Macros are only read once per
+// graphicReader.
+func (graphics *graphicReader) forFolder(macroPaths []string) ryxdoc.ToolGraphic {
+	return func(node *ryxnode.RyxNode) (string, []string, []string, bool) {
+		if node.ReadCategory() != ryxnode.Macro {
+			tool, ok := graphics.tools[node.ReadPlugin()]
+			return tool.Icon, tool.Inputs, tool.Outputs, ok
+		}
+		foundPath := node.ReadMacro(macroPaths...).FoundPath
+		if foundPath == `` {
+			return ``, nil, nil, false
+		}
+		macro, ok := graphics.macros[foundPath]
+		if !ok {
+			macro = readMacroAnchors(foundPath)
+			graphics.macros[foundPath] = macro
+		}
+		return macro.icon, macro.inputs, macro.outputs, macro.err == nil
+	}
+}
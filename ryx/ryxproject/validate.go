@@ -0,0 +1,66 @@
+package ryxproject
+
+import (
+	"fmt"
+	"github.com/tlarsen7572/Golang-Public/ryx/ryxdoc"
+	"github.com/tlarsen7572/Golang-Public/ryx/tool_data_loader"
+	"path/filepath"
+)
+
+type macroAnchors struct {
+	icon    string
+	inputs  []string
+	outputs []string
+	err     error
+}
+
+// ValidateWorkflows validates every document in the project and returns the problems found, keyed by file.  Files
+// without any problems are left out.  Files that cannot be read are reported as a single problem.
+func (ryxProject *RyxProject) ValidateWorkflows() (map[string][]*ryxdoc.ValidationIssue, error) {
+	structure, err := ryxProject.Structure()
+	if err != nil {
+		return nil, err
+	}
+	cache := map[string]*macroAnchors{}
+	readAnchors := func(macroPath string) ([]string, []string, error) {
+		anchors, ok := cache[macroPath]
+		if !ok {
+			anchors = readMacroAnchors(macroPath)
+			cache[macroPath] = anchors
+		}
+		return anchors.inputs, anchors.outputs, anchors.err
+	}
+
+	findings := map[string][]*ryxdoc.ValidationIssue{}
+	for _, file := range structure.AllFiles() {
+		doc, err := ryxdoc.ReadFile(file)
+		if err != nil {
+			findings[file] = []*ryxdoc.ValidationIssue{{Problem: fmt.Sprintf(`the file could not be read: %v`, err.Error())}}
+			continue
+		}
+		macroPaths := ryxProject.generateMacroPaths(filepath.Dir(file))
+		issues := doc.Validate(readAnchors, macroPaths...)
+		if len(issues) > 0 {
+			findings[file] = issues
+		}
+	}
+	return findings, nil
+}
+
+// readMacroAnchors reads a macro's anchors with tool_data_loader.  Batch macros also get the Control input, which
+// Designer adds to every batch macro.
+func readMacroAnchors(macroPath string) *macroAnchors {
+	toolData, err := tool_data_loader.ReadSingleMacro(macroPath, ``)
+	if err != nil {
+		return &macroAnchors{err: err}
+	}
+	macro, err := ryxdoc.ReadFile(macroPath)
+	if err != nil {
+		return &macroAnchors{err: err}
+	}
+	inputs := toolData.Inputs
+	if macro.Properties != nil && macro.Properties.First(`RuntimeProperties`).First(`BatchMacro`).Name != `` {
+		inputs = append(inputs, `Control`)
+	}
+	return &macroAnchors{icon: toolData.Icon, inputs: inputs, outputs: toolData.Outputs}
+}
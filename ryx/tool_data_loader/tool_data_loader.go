@@ -4,12 +4,11 @@ import (
 	"bytes"
 	"encoding/base64"
 	"encoding/json"
-	"encoding/xml"
 	"errors"
-	"fmt"
 	"github.com/tlarsen7572/Golang-Public/ryx/ini_reader"
 	"github.com/tlarsen7572/Golang-Public/ryx/ryxdoc"
 	"github.com/tlarsen7572/Golang-Public/ryx/ryxnode"
+	"github.com/tlarsen7572/Golang-Public/ryx/ryxnode/toolconfig"
 	"github.com/tlarsen7572/Golang-Public/txml"
 	"io/ioutil"
 	"os/exec"
@@ -236,23 +235,14 @@ func ReadSingleMacro(path string, macroPath string) (ToolData, error) {
 }
 
 func processNode(node *ryxnode.RyxNode, inputs []string, outputs []string) ([]string, []string) {
-	if node.ReadPlugin() == `AlteryxBasePluginsGui.MacroOutput.MacroOutput` {
-		var config *txml.Node
-		err := xml.Unmarshal([]byte(fmt.Sprintf(`<Configuration>%v</Configuration>`, node.Properties.Configuration.InnerXml)), &config)
-		if err != nil || config == nil {
-			return inputs, outputs
-		}
-		name := config.First(`Name`).InnerText
-		outputs = append(outputs, name)
-	}
-	if node.ReadPlugin() == `AlteryxBasePluginsGui.MacroInput.MacroInput` {
-		var config *txml.Node
-		err := xml.Unmarshal([]byte(fmt.Sprintf(`<Configuration>%v</Configuration>`, node.Properties.Configuration.InnerXml)), &config)
-		if err != nil || config == nil {
-			return inputs, outputs
+	tool, err := toolconfig.Read(node)
+	if err == nil {
+		switch anchor := tool.(type) {
+		case *toolconfig.MacroInput:
+			inputs = append(inputs, anchor.Name())
+		case *toolconfig.MacroOutput:
+			outputs = append(outputs, anchor.Name())
 		}
-		name := config.First(`Name`).InnerText
-		inputs = append(inputs, name)
 	}
 	for _, childNode := range node.ChildNodes {
 		inputs, outputs = processNode(childNode, inputs, outputs)
@@ -3,6 +3,7 @@ package traffic_cop
 import (
 	"errors"
 	"fmt"
+	"github.com/tlarsen7572/Golang-Public/ryx/ryxproject"
 )
 
 func _parseStringList(parameters map[string]interface{}, param string) ([]string, error) {
@@ -23,6 +24,44 @@ func _parseStringList(parameters map[string]interface{}, param string) ([]string
 	return valueStringList, nil
 }
 
+func _parseIntList(parameters map[string]interface{}, param string) ([]int, error) {
+	valueList, listOk := parameters[param].([]interface{})
+	valueIntList := []int{}
+	valuesOk := true
+	var valueFloat float64
+	for _, value := range valueList {
+		valueFloat, valuesOk = value.(float64)
+		if !valuesOk {
+			break
+		}
+		valueIntList = append(valueIntList, int(valueFloat))
+	}
+	if !listOk || !valuesOk {
+		return nil, errors.New(fmt.Sprintf(`the %v parameter was not included or was not a list of numbers`, param))
+	}
+	return valueIntList, nil
+}
+
+func _parseOptionalStringMap(parameters map[string]interface{}, param string) (map[string]string, error) {
+	value, ok := parameters[param]
+	if !ok || value == nil {
+		return nil, nil
+	}
+	valueMap, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, errors.New(fmt.Sprintf(`the %v parameter was not a map of strings`, param))
+	}
+	stringMap := map[string]string{}
+	for key, item := range valueMap {
+		itemString, ok := item.(string)
+		if !ok {
+			return nil, errors.New(fmt.Sprintf(`the %v parameter was not a map of strings`, param))
+		}
+		stringMap[key] = itemString
+	}
+	return stringMap, nil
+}
+
 func _errorResponse(err error) FunctionResponse {
 	return FunctionResponse{
 		Err:      err,
@@ -37,3 +76,33 @@ func _validResponse(value interface{}) FunctionResponse {
 func _stringParamErr(param string) error {
 	return errors.New(fmt.Sprintf(`the %v parameter was not included or was not a string`, param))
 }
+
+func _numberParamErr(param string) error {
+	return errors.New(fmt.Sprintf(`the %v parameter was not included or was not a number`, param))
+}
+
+func _boolParamErr(param string) error {
+	return errors.New(fmt.Sprintf(`the %v parameter was not included or was not a boolean`, param))
+}
+
+func _parseConflictResolutions(parameters map[string]interface{}, param string) (map[string]ryxproject.ConflictResolution, error) {
+	paramErr := errors.New(fmt.Sprintf(`the %v parameter was not included or was not a map of Choice/NewName objects`, param))
+	valueMap, ok := parameters[param].(map[string]interface{})
+	if !ok {
+		return nil, paramErr
+	}
+	resolutions := map[string]ryxproject.ConflictResolution{}
+	for entry, value := range valueMap {
+		resolutionMap, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, paramErr
+		}
+		choice, ok := resolutionMap[`Choice`].(string)
+		if !ok {
+			return nil, paramErr
+		}
+		newName, _ := resolutionMap[`NewName`].(string)
+		resolutions[entry] = ryxproject.ConflictResolution{Choice: choice, NewName: newName}
+	}
+	return resolutions, nil
+}
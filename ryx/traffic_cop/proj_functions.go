@@ -26,6 +26,33 @@ type DocumentStructure struct {
 	MacroToolData []tool_data_loader.ToolData
 }
 
+type DocumentDiff struct {
+	Diff *ryxdoc.DocDiff
+	Text string
+}
+
+type ToolLineage struct {
+	Upstream   []int
+	Downstream []int
+}
+
+type DocumentGraph struct {
+	TopologicalOrder    []int
+	Sources             []int
+	Sinks               []int
+	ConnectedComponents [][]int
+}
+
+type MacroInline struct {
+	Document            DocumentStructure
+	DroppedInterfaceIds []int
+}
+
+type FieldRename struct {
+	Document DocumentStructure
+	Issues   []*ryxdoc.FieldIssue
+}
+
 const getProjectStructureFunc = `GetProjectStructure`
 const getDocumentStructureFunc = `GetDocumentStructure`
 const whereUsedFunc = `WhereUsed`
@@ -38,6 +65,34 @@ const makeAllAbsoluteFunc = `MakeAllFilesAbsolute`
 const renameFolderFunc = `RenameFolder`
 const listMacrosInProjectFunc = `ListMacrosInProject`
 const batchUpdateMacroSettingsFunc = `BatchUpdateMacroSettings`
+const exportPackageFunc = `ExportPackage`
+const inspectPackageFunc = `InspectPackage`
+const importPackageFunc = `ImportPackage`
+const findDuplicateMacrosFunc = `FindDuplicateMacros`
+const consolidateMacrosFunc = `ConsolidateMacros`
+const previewRenameByPatternFunc = `PreviewRenameByPattern`
+const renameByPatternFunc = `RenameByPattern`
+const moveFolderFunc = `MoveFolder`
+const extractMacroFunc = `ExtractMacro`
+const inlineMacroFunc = `InlineMacro`
+const autoLayoutFunc = `AutoLayout`
+const diffDocumentsFunc = `DiffDocuments`
+const diffRevisionFunc = `DiffRevision`
+const getToolLineageFunc = `GetToolLineage`
+const getDocumentGraphFunc = `GetDocumentGraph`
+const getShortestPathFunc = `GetShortestPath`
+const wrapInContainerFunc = `WrapInContainer`
+const unwrapContainerFunc = `UnwrapContainer`
+const removeToolsAndReconnectFunc = `RemoveToolsAndReconnect`
+const validateWorkflowsFunc = `ValidateWorkflows`
+const renumberToolsFunc = `RenumberTools`
+const convertDocumentTypeFunc = `ConvertDocumentType`
+const copyToolsFunc = `CopyTools`
+const renderSvgFunc = `RenderSvg`
+const generateDocsFunc = `GenerateDocs`
+const renameFieldFunc = `RenameField`
+const traceFieldFunc = `TraceField`
+const searchFunc = `Search`
 const invalidProjFunc = `invalid project function`
 
 func handleProjFunction(call FunctionCall, data *TrafficCopData) FunctionResponse {
@@ -66,6 +121,62 @@ func handleProjFunction(call FunctionCall, data *TrafficCopData) FunctionRespons
 		return listMacrosInProject(data)
 	case batchUpdateMacroSettingsFunc:
 		return batchUpdateMacroSettings(call, data)
+	case exportPackageFunc:
+		return exportPackage(call, data)
+	case inspectPackageFunc:
+		return inspectPackage(call, data)
+	case importPackageFunc:
+		return importPackage(call, data)
+	case findDuplicateMacrosFunc:
+		return findDuplicateMacros(data)
+	case consolidateMacrosFunc:
+		return consolidateMacros(call, data)
+	case previewRenameByPatternFunc:
+		return previewRenameByPattern(call, data)
+	case renameByPatternFunc:
+		return renameByPattern(call, data)
+	case moveFolderFunc:
+		return moveFolder(call, data)
+	case extractMacroFunc:
+		return extractMacro(call, data)
+	case inlineMacroFunc:
+		return inlineMacro(call, data)
+	case autoLayoutFunc:
+		return autoLayout(call, data)
+	case diffDocumentsFunc:
+		return diffDocuments(call, data)
+	case diffRevisionFunc:
+		return diffRevision(call, data)
+	case getToolLineageFunc:
+		return getToolLineage(call, data)
+	case getDocumentGraphFunc:
+		return getDocumentGraph(call, data)
+	case getShortestPathFunc:
+		return getShortestPath(call, data)
+	case wrapInContainerFunc:
+		return wrapInContainer(call, data)
+	case unwrapContainerFunc:
+		return unwrapContainer(call, data)
+	case removeToolsAndReconnectFunc:
+		return removeToolsAndReconnect(call, data)
+	case validateWorkflowsFunc:
+		return validateWorkflows(data)
+	case renumberToolsFunc:
+		return renumberTools(call, data)
+	case convertDocumentTypeFunc:
+		return convertDocumentType(call, data)
+	case copyToolsFunc:
+		return copyTools(call, data)
+	case renderSvgFunc:
+		return renderSvg(call, data)
+	case generateDocsFunc:
+		return generateDocs(call, data)
+	case renameFieldFunc:
+		return renameField(call, data)
+	case traceFieldFunc:
+		return traceField(call, data)
+	case searchFunc:
+		return search(call, data)
 	default:
 		return _errorResponse(errors.New(invalidProjFunc))
 	}
@@ -90,7 +201,10 @@ func getDocumentStructure(call FunctionCall, data *TrafficCopData) FunctionRespo
 	if err != nil {
 		return _errorResponse(err)
 	}
+	return _validResponse(_buildDocumentStructure(call, data, doc, filePath))
+}
 
+func _buildDocumentStructure(call FunctionCall, data *TrafficCopData, doc *ryxdoc.RyxDoc, filePath string) DocumentStructure {
 	folderPath := filepath.Dir(filePath)
 	macroPaths := append(data.MacroPaths, folderPath)
 
@@ -105,12 +219,11 @@ func getDocumentStructure(call FunctionCall, data *TrafficCopData) FunctionRespo
 		connections = []*ryxdoc.RyxConn{}
 	}
 
-	docStructure := DocumentStructure{
+	return DocumentStructure{
 		Nodes:         nodes,
 		Connections:   connections,
 		MacroToolData: toolData,
 	}
-	return _validResponse(docStructure)
 }
 
 func processDocStructureNode(call FunctionCall, node *ryxnode.RyxNode, macroPaths []string, nodes []NodeStructure, toolData []tool_data_loader.ToolData) ([]NodeStructure, []tool_data_loader.ToolData) {
@@ -281,3 +394,537 @@ func batchUpdateMacroSettings(call FunctionCall, data *TrafficCopData) FunctionR
 	}
 	return _validResponse(changed)
 }
+
+func exportPackage(call FunctionCall, data *TrafficCopData) FunctionResponse {
+	files, err := _parseStringList(call.Parameters, `Files`)
+	if err != nil {
+		return _errorResponse(err)
+	}
+	packagePath, ok := call.Parameters[`PackagePath`].(string)
+	if !ok {
+		return _errorResponse(_stringParamErr(`PackagePath`))
+	}
+	includeData, ok := call.Parameters[`IncludeData`].(bool)
+	if !ok {
+		return _errorResponse(_boolParamErr(`IncludeData`))
+	}
+	result, err := data.Project.ExportPackage(files, packagePath, includeData)
+	if err != nil {
+		return _errorResponse(err)
+	}
+	return _validResponse(result)
+}
+
+func inspectPackage(call FunctionCall, data *TrafficCopData) FunctionResponse {
+	packagePath, ok := call.Parameters[`PackagePath`].(string)
+	if !ok {
+		return _errorResponse(_stringParamErr(`PackagePath`))
+	}
+	targetFolder, ok := call.Parameters[`TargetFolder`].(string)
+	if !ok {
+		return _errorResponse(_stringParamErr(`TargetFolder`))
+	}
+	conflicts, err := data.Project.InspectPackage(packagePath, targetFolder)
+	if err != nil {
+		return _errorResponse(err)
+	}
+	return _validResponse(conflicts)
+}
+
+func importPackage(call FunctionCall, data *TrafficCopData) FunctionResponse {
+	packagePath, ok := call.Parameters[`PackagePath`].(string)
+	if !ok {
+		return _errorResponse(_stringParamErr(`PackagePath`))
+	}
+	targetFolder, ok := call.Parameters[`TargetFolder`].(string)
+	if !ok {
+		return _errorResponse(_stringParamErr(`TargetFolder`))
+	}
+	resolutions, err := _parseConflictResolutions(call.Parameters, `Resolutions`)
+	if err != nil {
+		return _errorResponse(err)
+	}
+	written, err := data.Project.ImportPackage(packagePath, targetFolder, resolutions)
+	if err != nil {
+		return _errorResponse(err)
+	}
+	return _validResponse(written)
+}
+
+func findDuplicateMacros(data *TrafficCopData) FunctionResponse {
+	groups, err := data.Project.FindDuplicateMacros()
+	if err != nil {
+		return _errorResponse(err)
+	}
+	return _validResponse(groups)
+}
+
+func consolidateMacros(call FunctionCall, data *TrafficCopData) FunctionResponse {
+	canonical, ok := call.Parameters[`Canonical`].(string)
+	if !ok {
+		return _errorResponse(_stringParamErr(`Canonical`))
+	}
+	duplicates, err := _parseStringList(call.Parameters, `Duplicates`)
+	if err != nil {
+		return _errorResponse(err)
+	}
+	changed, err := data.Project.ConsolidateMacros(canonical, duplicates)
+	if err != nil {
+		return _errorResponse(err)
+	}
+	return _validResponse(changed)
+}
+
+func previewRenameByPattern(call FunctionCall, data *TrafficCopData) FunctionResponse {
+	pattern, replacement, isGlob, err := _parseRenamePattern(call.Parameters)
+	if err != nil {
+		return _errorResponse(err)
+	}
+	renames, err := data.Project.PreviewRenameByPattern(pattern, replacement, isGlob)
+	if err != nil {
+		return _errorResponse(err)
+	}
+	return _validResponse(renames)
+}
+
+func renameByPattern(call FunctionCall, data *TrafficCopData) FunctionResponse {
+	pattern, replacement, isGlob, err := _parseRenamePattern(call.Parameters)
+	if err != nil {
+		return _errorResponse(err)
+	}
+	errFiles, err := data.Project.RenameByPattern(pattern, replacement, isGlob)
+	if err != nil {
+		return _errorResponse(err)
+	}
+	return _validResponse(errFiles)
+}
+
+func _parseRenamePattern(parameters map[string]interface{}) (string, string, bool, error) {
+	pattern, ok := parameters[`Pattern`].(string)
+	if !ok {
+		return ``, ``, false, _stringParamErr(`Pattern`)
+	}
+	replacement, ok := parameters[`Replacement`].(string)
+	if !ok {
+		return ``, ``, false, _stringParamErr(`Replacement`)
+	}
+	isGlob, ok := parameters[`IsGlob`].(bool)
+	if !ok {
+		return ``, ``, false, _boolParamErr(`IsGlob`)
+	}
+	return pattern, replacement, isGlob, nil
+}
+
+func moveFolder(call FunctionCall, data *TrafficCopData) FunctionResponse {
+	from, ok := call.Parameters[`From`].(string)
+	if !ok {
+		return _errorResponse(_stringParamErr(`From`))
+	}
+	toParent, ok := call.Parameters[`ToParent`].(string)
+	if !ok {
+		return _errorResponse(_stringParamErr(`ToParent`))
+	}
+	merge, ok := call.Parameters[`Merge`].(bool)
+	if !ok {
+		return _errorResponse(_boolParamErr(`Merge`))
+	}
+	errFiles, err := data.Project.MoveFolder(from, toParent, merge)
+	if err != nil {
+		return _errorResponse(err)
+	}
+	return _validResponse(errFiles)
+}
+
+func extractMacro(call FunctionCall, data *TrafficCopData) FunctionResponse {
+	filePath, ok := call.Parameters[`FilePath`].(string)
+	if !ok {
+		return _errorResponse(_stringParamErr(`FilePath`))
+	}
+	toolIds, err := _parseIntList(call.Parameters, `ToolIds`)
+	if err != nil {
+		return _errorResponse(err)
+	}
+	macroPath, ok := call.Parameters[`MacroPath`].(string)
+	if !ok {
+		return _errorResponse(_stringParamErr(`MacroPath`))
+	}
+	relative, ok := call.Parameters[`Relative`].(bool)
+	if !ok {
+		return _errorResponse(_boolParamErr(`Relative`))
+	}
+	anchorNames, err := _parseOptionalStringMap(call.Parameters, `AnchorNames`)
+	if err != nil {
+		return _errorResponse(err)
+	}
+	doc, err := data.Project.ExtractMacro(filePath, macroPath, relative, anchorNames, toolIds...)
+	if err != nil {
+		return _errorResponse(err)
+	}
+	return _validResponse(_buildDocumentStructure(call, data, doc, filePath))
+}
+
+func inlineMacro(call FunctionCall, data *TrafficCopData) FunctionResponse {
+	filePath, ok := call.Parameters[`FilePath`].(string)
+	if !ok {
+		return _errorResponse(_stringParamErr(`FilePath`))
+	}
+	toolId, ok := call.Parameters[`ToolId`].(float64)
+	if !ok {
+		return _errorResponse(_numberParamErr(`ToolId`))
+	}
+	doc, dropped, err := data.Project.InlineMacro(filePath, int(toolId))
+	if err != nil {
+		return _errorResponse(err)
+	}
+	return _validResponse(MacroInline{
+		Document:            _buildDocumentStructure(call, data, doc, filePath),
+		DroppedInterfaceIds: dropped,
+	})
+}
+
+func autoLayout(call FunctionCall, data *TrafficCopData) FunctionResponse {
+	filePath, ok := call.Parameters[`FilePath`].(string)
+	if !ok {
+		return _errorResponse(_stringParamErr(`FilePath`))
+	}
+	doc, err := data.Project.AutoLayout(filePath)
+	if err != nil {
+		return _errorResponse(err)
+	}
+	return _validResponse(_buildDocumentStructure(call, data, doc, filePath))
+}
+
+func diffDocuments(call FunctionCall, data *TrafficCopData) FunctionResponse {
+	fromPath, ok := call.Parameters[`FromPath`].(string)
+	if !ok {
+		return _errorResponse(_stringParamErr(`FromPath`))
+	}
+	toPath, ok := call.Parameters[`ToPath`].(string)
+	if !ok {
+		return _errorResponse(_stringParamErr(`ToPath`))
+	}
+	diff, err := data.Project.DiffDocuments(fromPath, toPath)
+	if err != nil {
+		return _errorResponse(err)
+	}
+	return _validResponse(DocumentDiff{Diff: diff, Text: diff.String()})
+}
+
+func diffRevision(call FunctionCall, data *TrafficCopData) FunctionResponse {
+	filePath, ok := call.Parameters[`FilePath`].(string)
+	if !ok {
+		return _errorResponse(_stringParamErr(`FilePath`))
+	}
+	revision, ok := call.Parameters[`Revision`].(string)
+	if !ok {
+		return _errorResponse(_stringParamErr(`Revision`))
+	}
+	diff, err := data.Project.DiffRevision(filePath, revision)
+	if err != nil {
+		return _errorResponse(err)
+	}
+	return _validResponse(DocumentDiff{Diff: diff, Text: diff.String()})
+}
+
+func getToolLineage(call FunctionCall, data *TrafficCopData) FunctionResponse {
+	filePath, ok := call.Parameters[`FilePath`].(string)
+	if !ok {
+		return _errorResponse(_stringParamErr(`FilePath`))
+	}
+	toolId, ok := call.Parameters[`ToolId`].(float64)
+	if !ok {
+		return _errorResponse(_numberParamErr(`ToolId`))
+	}
+	doc, err := data.Project.RetrieveDocument(filePath)
+	if err != nil {
+		return _errorResponse(err)
+	}
+	return _validResponse(ToolLineage{
+		Upstream:   doc.Upstream(int(toolId)),
+		Downstream: doc.Downstream(int(toolId)),
+	})
+}
+
+func getDocumentGraph(call FunctionCall, data *TrafficCopData) FunctionResponse {
+	filePath, ok := call.Parameters[`FilePath`].(string)
+	if !ok {
+		return _errorResponse(_stringParamErr(`FilePath`))
+	}
+	doc, err := data.Project.RetrieveDocument(filePath)
+	if err != nil {
+		return _errorResponse(err)
+	}
+	order, err := doc.TopologicalOrder()
+	if err != nil {
+		return _errorResponse(err)
+	}
+	return _validResponse(DocumentGraph{
+		TopologicalOrder:    order,
+		Sources:             doc.Sources(),
+		Sinks:               doc.Sinks(),
+		ConnectedComponents: doc.ConnectedComponents(),
+	})
+}
+
+func getShortestPath(call FunctionCall, data *TrafficCopData) FunctionResponse {
+	filePath, ok := call.Parameters[`FilePath`].(string)
+	if !ok {
+		return _errorResponse(_stringParamErr(`FilePath`))
+	}
+	fromId, ok := call.Parameters[`FromId`].(float64)
+	if !ok {
+		return _errorResponse(_numberParamErr(`FromId`))
+	}
+	toId, ok := call.Parameters[`ToId`].(float64)
+	if !ok {
+		return _errorResponse(_numberParamErr(`ToId`))
+	}
+	doc, err := data.Project.RetrieveDocument(filePath)
+	if err != nil {
+		return _errorResponse(err)
+	}
+	path, err := doc.ShortestPath(int(fromId), int(toId))
+	if err != nil {
+		return _errorResponse(err)
+	}
+	return _validResponse(path)
+}
+
+func wrapInContainer(call FunctionCall, data *TrafficCopData) FunctionResponse {
+	filePath, ok := call.Parameters[`FilePath`].(string)
+	if !ok {
+		return _errorResponse(_stringParamErr(`FilePath`))
+	}
+	toolIds, err := _parseIntList(call.Parameters, `ToolIds`)
+	if err != nil {
+		return _errorResponse(err)
+	}
+	caption, ok := call.Parameters[`Caption`].(string)
+	if !ok {
+		return _errorResponse(_stringParamErr(`Caption`))
+	}
+	color, _ := call.Parameters[`Color`].(string)
+	doc, err := data.Project.WrapInContainer(filePath, caption, color, toolIds...)
+	if err != nil {
+		return _errorResponse(err)
+	}
+	return _validResponse(_buildDocumentStructure(call, data, doc, filePath))
+}
+
+func unwrapContainer(call FunctionCall, data *TrafficCopData) FunctionResponse {
+	filePath, ok := call.Parameters[`FilePath`].(string)
+	if !ok {
+		return _errorResponse(_stringParamErr(`FilePath`))
+	}
+	containerId, ok := call.Parameters[`ContainerId`].(float64)
+	if !ok {
+		return _errorResponse(_numberParamErr(`ContainerId`))
+	}
+	doc, err := data.Project.UnwrapContainer(filePath, int(containerId))
+	if err != nil {
+		return _errorResponse(err)
+	}
+	return _validResponse(_buildDocumentStructure(call, data, doc, filePath))
+}
+
+func removeToolsAndReconnect(call FunctionCall, data *TrafficCopData) FunctionResponse {
+	filePath, ok := call.Parameters[`FilePath`].(string)
+	if !ok {
+		return _errorResponse(_stringParamErr(`FilePath`))
+	}
+	toolIds, err := _parseIntList(call.Parameters, `ToolIds`)
+	if err != nil {
+		return _errorResponse(err)
+	}
+	doc, err := data.Project.RemoveToolsAndReconnect(filePath, toolIds...)
+	if err != nil {
+		return _errorResponse(err)
+	}
+	return _validResponse(_buildDocumentStructure(call, data, doc, filePath))
+}
+
+func validateWorkflows(data *TrafficCopData) FunctionResponse {
+	findings, err := data.Project.ValidateWorkflows()
+	if err != nil {
+		return _errorResponse(err)
+	}
+	return _validResponse(findings)
+}
+
+func renumberTools(call FunctionCall, data *TrafficCopData) FunctionResponse {
+	filePath, ok := call.Parameters[`FilePath`].(string)
+	if !ok {
+		return _errorResponse(_stringParamErr(`FilePath`))
+	}
+	order, ok := call.Parameters[`Order`].(string)
+	if !ok {
+		return _errorResponse(_stringParamErr(`Order`))
+	}
+	doc, err := data.Project.RenumberTools(filePath, ryxdoc.RenumberOrder(order))
+	if err != nil {
+		return _errorResponse(err)
+	}
+	return _validResponse(_buildDocumentStructure(call, data, doc, filePath))
+}
+
+func convertDocumentType(call FunctionCall, data *TrafficCopData) FunctionResponse {
+	filePath, ok := call.Parameters[`FilePath`].(string)
+	if !ok {
+		return _errorResponse(_stringParamErr(`FilePath`))
+	}
+	docType, ok := call.Parameters[`DocumentType`].(string)
+	if !ok {
+		return _errorResponse(_stringParamErr(`DocumentType`))
+	}
+	newPath, err := data.Project.ConvertDocumentType(filePath, ryxdoc.DocumentType(docType), _toolAnchors(call))
+	if err != nil {
+		return _errorResponse(err)
+	}
+	return _validResponse(newPath)
+}
+
+// _toolAnchors looks up the anchors of tools in the tool data the GUI has loaded.  Macros are looked up by their
+// stored path, the same way the document structure reports them.
+func _toolAnchors(call FunctionCall) ryxdoc.ToolAnchors {
+	toolData := map[string]tool_data_loader.ToolData{}
+	if call.Config != nil {
+		for _, tool := range call.Config.ToolData {
+			toolData[tool.Plugin] = tool
+		}
+	}
+	return func(node *ryxnode.RyxNode) ([]string, []string, bool) {
+		plugin := node.ReadPlugin()
+		if node.ReadCategory() == ryxnode.Macro {
+			plugin = node.ReadMacro().StoredPath
+		}
+		tool, ok := toolData[plugin]
+		return tool.Inputs, tool.Outputs, ok
+	}
+}
+
+func copyTools(call FunctionCall, data *TrafficCopData) FunctionResponse {
+	fromPath, ok := call.Parameters[`FromPath`].(string)
+	if !ok {
+		return _errorResponse(_stringParamErr(`FromPath`))
+	}
+	toolIds, err := _parseIntList(call.Parameters, `ToolIds`)
+	if err != nil {
+		return _errorResponse(err)
+	}
+	toPath, ok := call.Parameters[`ToPath`].(string)
+	if !ok {
+		return _errorResponse(_stringParamErr(`ToPath`))
+	}
+	x, ok := call.Parameters[`X`].(float64)
+	if !ok {
+		return _errorResponse(_numberParamErr(`X`))
+	}
+	y, ok := call.Parameters[`Y`].(float64)
+	if !ok {
+		return _errorResponse(_numberParamErr(`Y`))
+	}
+	doc, err := data.Project.CopyTools(fromPath, toPath, x, y, toolIds...)
+	if err != nil {
+		return _errorResponse(err)
+	}
+	return _validResponse(_buildDocumentStructure(call, data, doc, toPath))
+}
+
+func renderSvg(call FunctionCall, data *TrafficCopData) FunctionResponse {
+	filePath, ok := call.Parameters[`FilePath`].(string)
+	if !ok {
+		return _errorResponse(_stringParamErr(`FilePath`))
+	}
+	var toolData []tool_data_loader.ToolData
+	if call.Config != nil {
+		toolData = call.Config.ToolData
+	}
+	svg, err := data.Project.RenderSvg(filePath, toolData)
+	if err != nil {
+		return _errorResponse(err)
+	}
+	return _validResponse(string(svg))
+}
+
+func generateDocs(call FunctionCall, data *TrafficCopData) FunctionResponse {
+	outputFolder, ok := call.Parameters[`OutputFolder`].(string)
+	if !ok {
+		return _errorResponse(_stringParamErr(`OutputFolder`))
+	}
+	var toolData []tool_data_loader.ToolData
+	if call.Config != nil {
+		toolData = call.Config.ToolData
+	}
+	indexPath, err := data.Project.GenerateDocs(outputFolder, toolData)
+	if err != nil {
+		return _errorResponse(err)
+	}
+	return _validResponse(indexPath)
+}
+
+func renameField(call FunctionCall, data *TrafficCopData) FunctionResponse {
+	filePath, ok := call.Parameters[`FilePath`].(string)
+	if !ok {
+		return _errorResponse(_stringParamErr(`FilePath`))
+	}
+	toolId, ok := call.Parameters[`ToolId`].(float64)
+	if !ok {
+		return _errorResponse(_numberParamErr(`ToolId`))
+	}
+	oldName, ok := call.Parameters[`OldName`].(string)
+	if !ok {
+		return _errorResponse(_stringParamErr(`OldName`))
+	}
+	newName, ok := call.Parameters[`NewName`].(string)
+	if !ok {
+		return _errorResponse(_stringParamErr(`NewName`))
+	}
+	doc, issues, err := data.Project.RenameField(filePath, int(toolId), oldName, newName)
+	if err != nil {
+		return _errorResponse(err)
+	}
+	return _validResponse(FieldRename{
+		Document: _buildDocumentStructure(call, data, doc, filePath),
+		Issues:   issues,
+	})
+}
+
+func traceField(call FunctionCall, data *TrafficCopData) FunctionResponse {
+	filePath, ok := call.Parameters[`FilePath`].(string)
+	if !ok {
+		return _errorResponse(_stringParamErr(`FilePath`))
+	}
+	toolId, ok := call.Parameters[`ToolId`].(float64)
+	if !ok {
+		return _errorResponse(_numberParamErr(`ToolId`))
+	}
+	field, ok := call.Parameters[`Field`].(string)
+	if !ok {
+		return _errorResponse(_stringParamErr(`Field`))
+	}
+	doc, err := data.Project.RetrieveDocument(filePath)
+	if err != nil {
+		return _errorResponse(err)
+	}
+	trace, err := doc.TraceField(int(toolId), field)
+	if err != nil {
+		return _errorResponse(err)
+	}
+	return _validResponse(trace)
+}
+
+func search(call FunctionCall, data *TrafficCopData) FunctionResponse {
+	query, ok := call.Parameters[`Query`].(string)
+	if !ok {
+		return _errorResponse(_stringParamErr(`Query`))
+	}
+	isRegex, ok := call.Parameters[`IsRegex`].(bool)
+	if !ok {
+		return _errorResponse(_boolParamErr(`IsRegex`))
+	}
+	results, err := data.Project.Search(query, isRegex)
+	if err != nil {
+		return _errorResponse(err)
+	}
+	return _validResponse(results)
+}
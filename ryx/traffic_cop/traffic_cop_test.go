@@ -3,12 +3,16 @@ package traffic_cop_test
 import (
 	"encoding/json"
 	"github.com/tlarsen7572/Golang-Public/ryx/config"
+	"github.com/tlarsen7572/Golang-Public/ryx/ryxdoc"
 	"github.com/tlarsen7572/Golang-Public/ryx/ryxfolder"
+	"github.com/tlarsen7572/Golang-Public/ryx/ryxproject"
 	"github.com/tlarsen7572/Golang-Public/ryx/testdocbuilder"
 	"github.com/tlarsen7572/Golang-Public/ryx/tool_data_loader"
 	cop "github.com/tlarsen7572/Golang-Public/ryx/traffic_cop"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -830,6 +834,967 @@ func TestBatchUpdateMacroSettingsWithoutOnlyStoredPaths(t *testing.T) {
 	t.Logf(jsonResponse(response))
 }
 
+func TestExportPackage(t *testing.T) {
+	rebuildTestDocs()
+	defer rebuildTestDocs()
+
+	in := make(chan cop.FunctionCall)
+	out := make(chan cop.FunctionResponse)
+	go cop.StartTrafficCop(in)
+
+	workflow := filepath.Join(workFolder, `01 SETLEAF Equations Completed.yxmd`)
+	packagePath := filepath.Join(workFolder, `export.yxzp`)
+	in <- cop.FunctionCall{
+		Out:        out,
+		Project:    workFolder,
+		Function:   "ExportPackage",
+		Parameters: params{`Files`: []interface{}{workflow}, `PackagePath`: packagePath, `IncludeData`: true},
+		Config:     &config.Config{},
+	}
+	response := <-out
+	if response.Err != nil {
+		t.Fatalf(`expected no error but got: %v`, response.Err.Error())
+	}
+	if _, err := os.Stat(packagePath); err != nil {
+		t.Fatalf(`expected the package to exist but got: %v`, err.Error())
+	}
+}
+
+func TestExportPackageWithoutIncludeData(t *testing.T) {
+	rebuildTestDocs()
+	defer rebuildTestDocs()
+
+	in := make(chan cop.FunctionCall)
+	out := make(chan cop.FunctionResponse)
+	go cop.StartTrafficCop(in)
+
+	workflow := filepath.Join(workFolder, `01 SETLEAF Equations Completed.yxmd`)
+	in <- cop.FunctionCall{
+		Out:        out,
+		Project:    workFolder,
+		Function:   "ExportPackage",
+		Parameters: params{`Files`: []interface{}{workflow}, `PackagePath`: filepath.Join(workFolder, `export.yxzp`)},
+		Config:     &config.Config{},
+	}
+	response := <-out
+	if response.Err == nil {
+		t.Fatalf(`expected an error but got none`)
+	}
+}
+
+func TestImportPackage(t *testing.T) {
+	rebuildTestDocs()
+	defer rebuildTestDocs()
+
+	in := make(chan cop.FunctionCall)
+	out := make(chan cop.FunctionResponse)
+	go cop.StartTrafficCop(in)
+
+	packagePath := filepath.Join(workFolder, `export.yxzp`)
+	in <- cop.FunctionCall{
+		Out:        out,
+		Project:    workFolder,
+		Function:   "ExportPackage",
+		Parameters: params{`Files`: []interface{}{filepath.Join(workFolder, `MultiInOut.yxmd`)}, `PackagePath`: packagePath, `IncludeData`: false},
+		Config:     &config.Config{},
+	}
+	<-out
+	in <- cop.FunctionCall{
+		Out:      out,
+		Project:  workFolder,
+		Function: "ImportPackage",
+		Parameters: params{
+			`PackagePath`:  packagePath,
+			`TargetFolder`: filepath.Join(workFolder, `imported`),
+			`Resolutions`:  map[string]interface{}{`MultiInOut.yxmc`: map[string]interface{}{`Choice`: `Overwrite`}},
+		},
+		Config: &config.Config{},
+	}
+	response := <-out
+	if response.Err != nil {
+		t.Fatalf(`expected no error but got: %v`, response.Err.Error())
+	}
+	if count := len(response.Response.([]string)); count != 2 {
+		t.Fatalf(`expected 2 written files but got %v`, count)
+	}
+}
+
+func TestImportPackageWithoutResolutions(t *testing.T) {
+	rebuildTestDocs()
+	defer rebuildTestDocs()
+
+	in := make(chan cop.FunctionCall)
+	out := make(chan cop.FunctionResponse)
+	go cop.StartTrafficCop(in)
+
+	in <- cop.FunctionCall{
+		Out:        out,
+		Project:    workFolder,
+		Function:   "ImportPackage",
+		Parameters: params{`PackagePath`: filepath.Join(workFolder, `export.yxzp`), `TargetFolder`: workFolder},
+		Config:     &config.Config{},
+	}
+	response := <-out
+	if response.Err == nil {
+		t.Fatalf(`expected an error but got none`)
+	}
+}
+
+func TestFindDuplicateMacros(t *testing.T) {
+	rebuildTestDocs()
+	defer rebuildTestDocs()
+
+	in := make(chan cop.FunctionCall)
+	out := make(chan cop.FunctionResponse)
+	go cop.StartTrafficCop(in)
+
+	in <- cop.FunctionCall{
+		Out:        out,
+		Project:    workFolder,
+		Function:   "FindDuplicateMacros",
+		Parameters: params{},
+		Config:     &config.Config{},
+	}
+	response := <-out
+	if response.Err != nil {
+		t.Fatalf(`expected no error but got: %v`, response.Err.Error())
+	}
+	if count := len(response.Response.([]*ryxproject.DuplicateMacroGroup)); count != 0 {
+		t.Fatalf(`expected 0 duplicate groups but got %v`, count)
+	}
+}
+
+func TestConsolidateMacrosWithoutDuplicates(t *testing.T) {
+	rebuildTestDocs()
+	defer rebuildTestDocs()
+
+	in := make(chan cop.FunctionCall)
+	out := make(chan cop.FunctionResponse)
+	go cop.StartTrafficCop(in)
+
+	in <- cop.FunctionCall{
+		Out:        out,
+		Project:    workFolder,
+		Function:   "ConsolidateMacros",
+		Parameters: params{`Canonical`: filepath.Join(workFolder, `MultiInOut.yxmc`)},
+		Config:     &config.Config{},
+	}
+	response := <-out
+	if response.Err == nil {
+		t.Fatalf(`expected an error but got none`)
+	}
+}
+
+func TestPreviewRenameByPattern(t *testing.T) {
+	rebuildTestDocs()
+	defer rebuildTestDocs()
+
+	in := make(chan cop.FunctionCall)
+	out := make(chan cop.FunctionResponse)
+	go cop.StartTrafficCop(in)
+
+	in <- cop.FunctionCall{
+		Out:        out,
+		Project:    workFolder,
+		Function:   "PreviewRenameByPattern",
+		Parameters: params{`Pattern`: `Tag with *`, `Replacement`: `Tagged $1`, `IsGlob`: true},
+		Config:     &config.Config{},
+	}
+	response := <-out
+	if response.Err != nil {
+		t.Fatalf(`expected no error but got: %v`, response.Err.Error())
+	}
+	if count := len(response.Response.([]*ryxproject.PatternRename)); count != 1 {
+		t.Fatalf(`expected 1 rename but got %v`, count)
+	}
+}
+
+func TestRenameByPatternWithoutIsGlob(t *testing.T) {
+	rebuildTestDocs()
+	defer rebuildTestDocs()
+
+	in := make(chan cop.FunctionCall)
+	out := make(chan cop.FunctionResponse)
+	go cop.StartTrafficCop(in)
+
+	in <- cop.FunctionCall{
+		Out:        out,
+		Project:    workFolder,
+		Function:   "RenameByPattern",
+		Parameters: params{`Pattern`: `Tag with *`, `Replacement`: `Tagged $1`},
+		Config:     &config.Config{},
+	}
+	response := <-out
+	if response.Err == nil {
+		t.Fatalf(`expected an error but got none`)
+	}
+}
+
+func TestMoveFolder(t *testing.T) {
+	rebuildTestDocs()
+	defer rebuildTestDocs()
+
+	in := make(chan cop.FunctionCall)
+	out := make(chan cop.FunctionResponse)
+	go cop.StartTrafficCop(in)
+
+	toParent := filepath.Join(workFolder, `library`)
+	_ = os.Mkdir(toParent, 0777)
+	in <- cop.FunctionCall{
+		Out:        out,
+		Project:    workFolder,
+		Function:   "MoveFolder",
+		Parameters: params{`From`: filepath.Join(workFolder, `macros`), `ToParent`: toParent, `Merge`: false},
+		Config:     &config.Config{},
+	}
+	response := <-out
+	if response.Err != nil {
+		t.Fatalf(`expected no error but got: %v`, response.Err.Error())
+	}
+}
+
+func TestMoveFolderWithoutToParent(t *testing.T) {
+	rebuildTestDocs()
+	defer rebuildTestDocs()
+
+	in := make(chan cop.FunctionCall)
+	out := make(chan cop.FunctionResponse)
+	go cop.StartTrafficCop(in)
+
+	in <- cop.FunctionCall{
+		Out:        out,
+		Project:    workFolder,
+		Function:   "MoveFolder",
+		Parameters: params{`From`: filepath.Join(workFolder, `macros`), `Merge`: false},
+		Config:     &config.Config{},
+	}
+	response := <-out
+	if response.Err == nil {
+		t.Fatalf(`expected an error but got none`)
+	}
+}
+
+func TestExtractMacro(t *testing.T) {
+	rebuildTestDocs()
+	defer rebuildTestDocs()
+
+	in := make(chan cop.FunctionCall)
+	out := make(chan cop.FunctionResponse)
+	go cop.StartTrafficCop(in)
+
+	in <- cop.FunctionCall{
+		Out:      out,
+		Project:  workFolder,
+		Function: "ExtractMacro",
+		Parameters: params{
+			`FilePath`:  filepath.Join(workFolder, `01 SETLEAF Equations Completed.yxmd`),
+			`ToolIds`:   []interface{}{14.0, 15.0, 16.0},
+			`MacroPath`: filepath.Join(workFolder, `Extracted.yxmc`),
+			`Relative`:  true,
+		},
+		Config: &config.Config{ToolData: []tool_data_loader.ToolData{}},
+	}
+	response := <-out
+	if response.Err != nil {
+		t.Fatalf(`expected no error but got: %v`, response.Err.Error())
+	}
+	structure := response.Response.(cop.DocumentStructure)
+	if count := len(structure.Nodes); count != 14 {
+		t.Fatalf(`expected 14 nodes but got %v`, count)
+	}
+}
+
+func TestInlineMacro(t *testing.T) {
+	rebuildTestDocs()
+	defer rebuildTestDocs()
+
+	in := make(chan cop.FunctionCall)
+	out := make(chan cop.FunctionResponse)
+	go cop.StartTrafficCop(in)
+
+	in <- cop.FunctionCall{
+		Out:      out,
+		Project:  workFolder,
+		Function: "InlineMacro",
+		Parameters: params{
+			`FilePath`: filepath.Join(workFolder, `01 SETLEAF Equations Completed.yxmd`),
+			`ToolId`:   float64(12),
+		},
+		Config: &config.Config{ToolData: []tool_data_loader.ToolData{}},
+	}
+	response := <-out
+	if response.Err != nil {
+		t.Fatalf(`expected no error but got: %v`, response.Err.Error())
+	}
+	inline := response.Response.(cop.MacroInline)
+	if count := len(inline.Document.Nodes); count != 18 {
+		t.Fatalf(`expected 18 nodes but got %v`, count)
+	}
+	if len(inline.DroppedInterfaceIds) != 1 || inline.DroppedInterfaceIds[0] != 5 {
+		t.Fatalf(`expected interface tool 5 to be dropped but got %v`, inline.DroppedInterfaceIds)
+	}
+}
+
+func TestExtractMacroWithHole(t *testing.T) {
+	rebuildTestDocs()
+	defer rebuildTestDocs()
+
+	in := make(chan cop.FunctionCall)
+	out := make(chan cop.FunctionResponse)
+	go cop.StartTrafficCop(in)
+
+	in <- cop.FunctionCall{
+		Out:      out,
+		Project:  workFolder,
+		Function: "ExtractMacro",
+		Parameters: params{
+			`FilePath`:  filepath.Join(workFolder, `01 SETLEAF Equations Completed.yxmd`),
+			`ToolIds`:   []interface{}{13.0, 15.0, 16.0, 17.0},
+			`MacroPath`: filepath.Join(workFolder, `Extracted.yxmc`),
+			`Relative`:  true,
+		},
+		Config: &config.Config{ToolData: []tool_data_loader.ToolData{}},
+	}
+	response := <-out
+	if response.Err == nil {
+		t.Fatalf(`expected an error but got none`)
+	}
+}
+
+func TestExtractMacroWithoutToolIds(t *testing.T) {
+	rebuildTestDocs()
+	defer rebuildTestDocs()
+
+	in := make(chan cop.FunctionCall)
+	out := make(chan cop.FunctionResponse)
+	go cop.StartTrafficCop(in)
+
+	in <- cop.FunctionCall{
+		Out:      out,
+		Project:  workFolder,
+		Function: "ExtractMacro",
+		Parameters: params{
+			`FilePath`:  filepath.Join(workFolder, `01 SETLEAF Equations Completed.yxmd`),
+			`MacroPath`: filepath.Join(workFolder, `Extracted.yxmc`),
+			`Relative`:  true,
+		},
+		Config: &config.Config{},
+	}
+	response := <-out
+	if response.Err == nil {
+		t.Fatalf(`expected an error but got none`)
+	}
+}
+
+func TestAutoLayout(t *testing.T) {
+	rebuildTestDocs()
+	defer rebuildTestDocs()
+
+	in := make(chan cop.FunctionCall)
+	out := make(chan cop.FunctionResponse)
+	go cop.StartTrafficCop(in)
+
+	in <- cop.FunctionCall{
+		Out:      out,
+		Project:  workFolder,
+		Function: "AutoLayout",
+		Parameters: params{
+			`FilePath`: filepath.Join(workFolder, `01 SETLEAF Equations Completed.yxmd`),
+		},
+		Config: &config.Config{ToolData: []tool_data_loader.ToolData{}},
+	}
+	response := <-out
+	if response.Err != nil {
+		t.Fatalf(`expected no error but got: %v`, response.Err.Error())
+	}
+	structure := response.Response.(cop.DocumentStructure)
+	if count := len(structure.Nodes); count != 16 {
+		t.Fatalf(`expected 16 nodes but got %v`, count)
+	}
+}
+
+func TestDiffDocuments(t *testing.T) {
+	rebuildTestDocs()
+	defer rebuildTestDocs()
+
+	in := make(chan cop.FunctionCall)
+	out := make(chan cop.FunctionResponse)
+	go cop.StartTrafficCop(in)
+
+	in <- cop.FunctionCall{
+		Out:      out,
+		Project:  workFolder,
+		Function: "DiffDocuments",
+		Parameters: params{
+			`FromPath`: filepath.Join(workFolder, `MultiInOut.yxmd`),
+			`ToPath`:   filepath.Join(workFolder, `01 SETLEAF Equations Completed.yxmd`),
+		},
+		Config: &config.Config{},
+	}
+	response := <-out
+	if response.Err != nil {
+		t.Fatalf(`expected no error but got: %v`, response.Err.Error())
+	}
+	diff := response.Response.(cop.DocumentDiff)
+	if diff.Diff.IsEmpty() || diff.Text == `` {
+		t.Fatalf(`expected differences but got none`)
+	}
+}
+
+func TestDiffRevisionWithoutRevision(t *testing.T) {
+	rebuildTestDocs()
+	defer rebuildTestDocs()
+
+	in := make(chan cop.FunctionCall)
+	out := make(chan cop.FunctionResponse)
+	go cop.StartTrafficCop(in)
+
+	in <- cop.FunctionCall{
+		Out:      out,
+		Project:  workFolder,
+		Function: "DiffRevision",
+		Parameters: params{
+			`FilePath`: filepath.Join(workFolder, `MultiInOut.yxmd`),
+		},
+		Config: &config.Config{},
+	}
+	response := <-out
+	if response.Err == nil {
+		t.Fatalf(`expected an error but got none`)
+	}
+}
+
+func TestGetToolLineage(t *testing.T) {
+	rebuildTestDocs()
+	defer rebuildTestDocs()
+
+	in := make(chan cop.FunctionCall)
+	out := make(chan cop.FunctionResponse)
+	go cop.StartTrafficCop(in)
+
+	in <- cop.FunctionCall{
+		Out:      out,
+		Project:  workFolder,
+		Function: "GetToolLineage",
+		Parameters: params{
+			`FilePath`: filepath.Join(workFolder, `01 SETLEAF Equations Completed.yxmd`),
+			`ToolId`:   float64(13),
+		},
+		Config: &config.Config{},
+	}
+	response := <-out
+	if response.Err != nil {
+		t.Fatalf(`expected no error but got: %v`, response.Err.Error())
+	}
+	lineage := response.Response.(cop.ToolLineage)
+	if len(lineage.Upstream) != 4 || len(lineage.Downstream) != 6 {
+		t.Fatalf(`expected 4 upstream and 6 downstream tools but got %v and %v`, lineage.Upstream, lineage.Downstream)
+	}
+}
+
+func TestGetToolLineageWithoutToolId(t *testing.T) {
+	rebuildTestDocs()
+	defer rebuildTestDocs()
+
+	in := make(chan cop.FunctionCall)
+	out := make(chan cop.FunctionResponse)
+	go cop.StartTrafficCop(in)
+
+	in <- cop.FunctionCall{
+		Out:      out,
+		Project:  workFolder,
+		Function: "GetToolLineage",
+		Parameters: params{
+			`FilePath`: filepath.Join(workFolder, `01 SETLEAF Equations Completed.yxmd`),
+		},
+		Config: &config.Config{},
+	}
+	response := <-out
+	if response.Err == nil {
+		t.Fatalf(`expected an error but got none`)
+	}
+}
+
+func TestGetDocumentGraph(t *testing.T) {
+	rebuildTestDocs()
+	defer rebuildTestDocs()
+
+	in := make(chan cop.FunctionCall)
+	out := make(chan cop.FunctionResponse)
+	go cop.StartTrafficCop(in)
+
+	in <- cop.FunctionCall{
+		Out:      out,
+		Project:  workFolder,
+		Function: "GetDocumentGraph",
+		Parameters: params{
+			`FilePath`: filepath.Join(workFolder, `01 SETLEAF Equations Completed.yxmd`),
+		},
+		Config: &config.Config{},
+	}
+	response := <-out
+	if response.Err != nil {
+		t.Fatalf(`expected no error but got: %v`, response.Err.Error())
+	}
+	graph := response.Response.(cop.DocumentGraph)
+	if count := len(graph.TopologicalOrder); count != 16 {
+		t.Fatalf(`expected 16 tools in the topological order but got %v`, count)
+	}
+	if count := len(graph.Sources); count != 3 {
+		t.Fatalf(`expected 3 sources but got %v`, count)
+	}
+	if count := len(graph.ConnectedComponents); count != 5 {
+		t.Fatalf(`expected 5 connected components but got %v`, count)
+	}
+}
+
+func TestGetShortestPath(t *testing.T) {
+	rebuildTestDocs()
+	defer rebuildTestDocs()
+
+	in := make(chan cop.FunctionCall)
+	out := make(chan cop.FunctionResponse)
+	go cop.StartTrafficCop(in)
+
+	in <- cop.FunctionCall{
+		Out:      out,
+		Project:  workFolder,
+		Function: "GetShortestPath",
+		Parameters: params{
+			`FilePath`: filepath.Join(workFolder, `01 SETLEAF Equations Completed.yxmd`),
+			`FromId`:   float64(4),
+			`ToId`:     float64(13),
+		},
+		Config: &config.Config{},
+	}
+	response := <-out
+	if response.Err != nil {
+		t.Fatalf(`expected no error but got: %v`, response.Err.Error())
+	}
+	path := response.Response.([]int)
+	if len(path) != 4 || path[0] != 4 || path[3] != 13 {
+		t.Fatalf(`expected path [4 6 12 13] but got %v`, path)
+	}
+}
+
+func TestWrapInContainer(t *testing.T) {
+	rebuildTestDocs()
+	defer rebuildTestDocs()
+
+	in := make(chan cop.FunctionCall)
+	out := make(chan cop.FunctionResponse)
+	go cop.StartTrafficCop(in)
+
+	in <- cop.FunctionCall{
+		Out:      out,
+		Project:  workFolder,
+		Function: "WrapInContainer",
+		Parameters: params{
+			`FilePath`: filepath.Join(workFolder, `01 SETLEAF Equations Completed.yxmd`),
+			`ToolIds`:  []interface{}{float64(14), float64(15)},
+			`Caption`:  `Branches`,
+		},
+		Config: &config.Config{ToolData: []tool_data_loader.ToolData{}},
+	}
+	response := <-out
+	if response.Err != nil {
+		t.Fatalf(`expected no error but got: %v`, response.Err.Error())
+	}
+	structure := response.Response.(cop.DocumentStructure)
+	if count := len(structure.Nodes); count != 17 {
+		t.Fatalf(`expected 17 nodes but got %v`, count)
+	}
+}
+
+func TestUnwrapContainer(t *testing.T) {
+	rebuildTestDocs()
+	defer rebuildTestDocs()
+
+	in := make(chan cop.FunctionCall)
+	out := make(chan cop.FunctionResponse)
+	go cop.StartTrafficCop(in)
+
+	in <- cop.FunctionCall{
+		Out:      out,
+		Project:  workFolder,
+		Function: "UnwrapContainer",
+		Parameters: params{
+			`FilePath`:    filepath.Join(workFolder, `01 SETLEAF Equations Completed.yxmd`),
+			`ContainerId`: float64(22),
+		},
+		Config: &config.Config{ToolData: []tool_data_loader.ToolData{}},
+	}
+	response := <-out
+	if response.Err != nil {
+		t.Fatalf(`expected no error but got: %v`, response.Err.Error())
+	}
+	structure := response.Response.(cop.DocumentStructure)
+	if count := len(structure.Nodes); count != 15 {
+		t.Fatalf(`expected 15 nodes but got %v`, count)
+	}
+}
+
+func TestRemoveToolsAndReconnect(t *testing.T) {
+	rebuildTestDocs()
+	defer rebuildTestDocs()
+
+	in := make(chan cop.FunctionCall)
+	out := make(chan cop.FunctionResponse)
+	go cop.StartTrafficCop(in)
+
+	in <- cop.FunctionCall{
+		Out:      out,
+		Project:  workFolder,
+		Function: "RemoveToolsAndReconnect",
+		Parameters: params{
+			`FilePath`: filepath.Join(workFolder, `01 SETLEAF Equations Completed.yxmd`),
+			`ToolIds`:  []interface{}{float64(12), float64(19)},
+		},
+		Config: &config.Config{ToolData: []tool_data_loader.ToolData{}},
+	}
+	response := <-out
+	if response.Err != nil {
+		t.Fatalf(`expected no error but got: %v`, response.Err.Error())
+	}
+	structure := response.Response.(cop.DocumentStructure)
+	if count := len(structure.Nodes); count != 14 {
+		t.Fatalf(`expected 14 nodes but got %v`, count)
+	}
+	if count := len(structure.Connections); count != 10 {
+		t.Fatalf(`expected 10 connections but got %v`, count)
+	}
+}
+
+func TestValidateWorkflows(t *testing.T) {
+	rebuildTestDocs()
+	defer rebuildTestDocs()
+
+	in := make(chan cop.FunctionCall)
+	out := make(chan cop.FunctionResponse)
+	go cop.StartTrafficCop(in)
+
+	in <- cop.FunctionCall{
+		Out:        out,
+		Project:    workFolder,
+		Function:   "ValidateWorkflows",
+		Parameters: params{},
+		Config:     &config.Config{},
+	}
+	response := <-out
+	if response.Err != nil {
+		t.Fatalf(`expected no error but got: %v`, response.Err.Error())
+	}
+	findings := response.Response.(map[string][]*ryxdoc.ValidationIssue)
+	if count := len(findings); count != 0 {
+		t.Fatalf(`expected no findings but got %v`, count)
+	}
+}
+
+func TestRenumberTools(t *testing.T) {
+	rebuildTestDocs()
+	defer rebuildTestDocs()
+
+	in := make(chan cop.FunctionCall)
+	out := make(chan cop.FunctionResponse)
+	go cop.StartTrafficCop(in)
+
+	in <- cop.FunctionCall{
+		Out:      out,
+		Project:  workFolder,
+		Function: "RenumberTools",
+		Parameters: params{
+			`FilePath`: filepath.Join(workFolder, `01 SETLEAF Equations Completed.yxmd`),
+			`Order`:    `Flow`,
+		},
+		Config: &config.Config{ToolData: []tool_data_loader.ToolData{}},
+	}
+	response := <-out
+	if response.Err != nil {
+		t.Fatalf(`expected no error but got: %v`, response.Err.Error())
+	}
+	structure := response.Response.(cop.DocumentStructure)
+	for _, node := range structure.Nodes {
+		if node.ToolId < 1 || node.ToolId > 16 {
+			t.Fatalf(`expected tool IDs from 1 to 16 but got %v`, node.ToolId)
+		}
+	}
+}
+
+func TestRenumberToolsInvalidOrder(t *testing.T) {
+	rebuildTestDocs()
+	defer rebuildTestDocs()
+
+	in := make(chan cop.FunctionCall)
+	out := make(chan cop.FunctionResponse)
+	go cop.StartTrafficCop(in)
+
+	in <- cop.FunctionCall{
+		Out:      out,
+		Project:  workFolder,
+		Function: "RenumberTools",
+		Parameters: params{
+			`FilePath`: filepath.Join(workFolder, `01 SETLEAF Equations Completed.yxmd`),
+			`Order`:    `Alphabetical`,
+		},
+		Config: &config.Config{ToolData: []tool_data_loader.ToolData{}},
+	}
+	response := <-out
+	if response.Err == nil {
+		t.Fatalf(`expected an error but got none`)
+	}
+}
+
+func TestConvertDocumentType(t *testing.T) {
+	rebuildTestDocs()
+	defer rebuildTestDocs()
+
+	in := make(chan cop.FunctionCall)
+	out := make(chan cop.FunctionResponse)
+	go cop.StartTrafficCop(in)
+
+	in <- cop.FunctionCall{
+		Out:      out,
+		Project:  workFolder,
+		Function: "ConvertDocumentType",
+		Parameters: params{
+			`FilePath`:     filepath.Join(workFolder, `Interface.yxmc`),
+			`DocumentType`: `Wizard`,
+		},
+		Config: &config.Config{ToolData: []tool_data_loader.ToolData{}},
+	}
+	response := <-out
+	if response.Err != nil {
+		t.Fatalf(`expected no error but got: %v`, response.Err.Error())
+	}
+	expected := filepath.Join(workFolder, `Interface.yxwz`)
+	if newPath := response.Response.(string); newPath != expected {
+		t.Fatalf(`expected '%v' but got '%v'`, expected, newPath)
+	}
+}
+
+func TestCopyTools(t *testing.T) {
+	rebuildTestDocs()
+	defer rebuildTestDocs()
+
+	in := make(chan cop.FunctionCall)
+	out := make(chan cop.FunctionResponse)
+	go cop.StartTrafficCop(in)
+
+	in <- cop.FunctionCall{
+		Out:      out,
+		Project:  workFolder,
+		Function: "CopyTools",
+		Parameters: params{
+			`FromPath`: filepath.Join(workFolder, `01 SETLEAF Equations Completed.yxmd`),
+			`ToolIds`:  []interface{}{float64(14), float64(15)},
+			`ToPath`:   filepath.Join(workFolder, `MultiInOut.yxmd`),
+			`X`:        float64(0),
+			`Y`:        float64(0),
+		},
+		Config: &config.Config{ToolData: []tool_data_loader.ToolData{}},
+	}
+	response := <-out
+	if response.Err != nil {
+		t.Fatalf(`expected no error but got: %v`, response.Err.Error())
+	}
+	structure := response.Response.(cop.DocumentStructure)
+	if count := len(structure.Nodes); count != 7 {
+		t.Fatalf(`expected 7 nodes but got %v`, count)
+	}
+}
+
+func TestRenderSvg(t *testing.T) {
+	rebuildTestDocs()
+	defer rebuildTestDocs()
+
+	in := make(chan cop.FunctionCall)
+	out := make(chan cop.FunctionResponse)
+	go cop.StartTrafficCop(in)
+
+	in <- cop.FunctionCall{
+		Out:      out,
+		Project:  workFolder,
+		Function: "RenderSvg",
+		Parameters: params{
+			`FilePath`: filepath.Join(workFolder, `01 SETLEAF Equations Completed.yxmd`),
+		},
+		Config: &config.Config{ToolData: []tool_data_loader.ToolData{}},
+	}
+	response := <-out
+	if response.Err != nil {
+		t.Fatalf(`expected no error but got: %v`, response.Err.Error())
+	}
+	if svg := response.Response.(string); !strings.HasPrefix(svg, `<svg `) {
+		t.Fatalf(`expected an svg image but got: %v`, svg)
+	}
+}
+
+func TestGenerateDocs(t *testing.T) {
+	rebuildTestDocs()
+	defer rebuildTestDocs()
+
+	outputFolder, _ := ioutil.TempDir(``, `ryxdocs`)
+	defer os.RemoveAll(outputFolder)
+
+	in := make(chan cop.FunctionCall)
+	out := make(chan cop.FunctionResponse)
+	go cop.StartTrafficCop(in)
+
+	in <- cop.FunctionCall{
+		Out:      out,
+		Project:  workFolder,
+		Function: "GenerateDocs",
+		Parameters: params{
+			`OutputFolder`: outputFolder,
+		},
+		Config: &config.Config{ToolData: []tool_data_loader.ToolData{}},
+	}
+	response := <-out
+	if response.Err != nil {
+		t.Fatalf(`expected no error but got: %v`, response.Err.Error())
+	}
+	if indexPath := response.Response.(string); indexPath != filepath.Join(outputFolder, `index.html`) {
+		t.Fatalf(`expected the index in the output folder but got %v`, indexPath)
+	}
+}
+
+func TestRenameField(t *testing.T) {
+	rebuildTestDocs()
+	defer rebuildTestDocs()
+
+	in := make(chan cop.FunctionCall)
+	out := make(chan cop.FunctionResponse)
+	go cop.StartTrafficCop(in)
+
+	in <- cop.FunctionCall{
+		Out:      out,
+		Project:  workFolder,
+		Function: "RenameField",
+		Parameters: params{
+			`FilePath`: filepath.Join(workFolder, `01 SETLEAF Equations Completed.yxmd`),
+			`ToolId`:   float64(14),
+			`OldName`:  `Filter`,
+			`NewName`:  `Filters`,
+		},
+		Config: &config.Config{ToolData: []tool_data_loader.ToolData{}},
+	}
+	response := <-out
+	if response.Err != nil {
+		t.Fatalf(`expected no error but got: %v`, response.Err.Error())
+	}
+	rename := response.Response.(cop.FieldRename)
+	if count := len(rename.Document.Nodes); count != 16 {
+		t.Fatalf(`expected 16 nodes but got %v`, count)
+	}
+	if len(rename.Issues) != 2 || rename.Issues[0].ToolId != 16 || rename.Issues[1].ToolId != 18 {
+		t.Fatalf(`expected issues for tools 16 and 18 but got %v`, rename.Issues)
+	}
+}
+
+func TestTraceField(t *testing.T) {
+	rebuildTestDocs()
+	defer rebuildTestDocs()
+
+	in := make(chan cop.FunctionCall)
+	out := make(chan cop.FunctionResponse)
+	go cop.StartTrafficCop(in)
+
+	in <- cop.FunctionCall{
+		Out:      out,
+		Project:  workFolder,
+		Function: "TraceField",
+		Parameters: params{
+			`FilePath`: filepath.Join(workFolder, `01 SETLEAF Equations Completed.yxmd`),
+			`ToolId`:   float64(6),
+			`Field`:    `SETNAME`,
+		},
+		Config: &config.Config{},
+	}
+	response := <-out
+	if response.Err != nil {
+		t.Fatalf(`expected no error but got: %v`, response.Err.Error())
+	}
+	trace := response.Response.(*ryxdoc.FieldTrace)
+	if len(trace.Origins) != 1 || trace.Origins[0] != 1 {
+		t.Fatalf(`expected the field to come from tool 1 but got %v`, trace.Origins)
+	}
+}
+
+func TestTraceFieldWithoutField(t *testing.T) {
+	rebuildTestDocs()
+	defer rebuildTestDocs()
+
+	in := make(chan cop.FunctionCall)
+	out := make(chan cop.FunctionResponse)
+	go cop.StartTrafficCop(in)
+
+	in <- cop.FunctionCall{
+		Out:      out,
+		Project:  workFolder,
+		Function: "TraceField",
+		Parameters: params{
+			`FilePath`: filepath.Join(workFolder, `01 SETLEAF Equations Completed.yxmd`),
+			`ToolId`:   float64(6),
+		},
+		Config: &config.Config{},
+	}
+	response := <-out
+	if response.Err == nil {
+		t.Fatalf(`expected an error but got none`)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	rebuildTestDocs()
+	defer rebuildTestDocs()
+
+	in := make(chan cop.FunctionCall)
+	out := make(chan cop.FunctionResponse)
+	go cop.StartTrafficCop(in)
+
+	in <- cop.FunctionCall{
+		Out:      out,
+		Project:  workFolder,
+		Function: "Search",
+		Parameters: params{
+			`Query`:   `Split I / E`,
+			`IsRegex`: false,
+		},
+		Config: &config.Config{},
+	}
+	response := <-out
+	if response.Err != nil {
+		t.Fatalf(`expected no error but got: %v`, response.Err.Error())
+	}
+	results := response.Response.([]*ryxproject.SearchResult)
+	if len(results) != 1 || results[0].ToolId != 13 {
+		t.Fatalf(`expected the annotation of tool 13 but got %v`, results)
+	}
+}
+
+func TestSearchWithoutIsRegex(t *testing.T) {
+	rebuildTestDocs()
+	defer rebuildTestDocs()
+
+	in := make(chan cop.FunctionCall)
+	out := make(chan cop.FunctionResponse)
+	go cop.StartTrafficCop(in)
+
+	in <- cop.FunctionCall{
+		Out:      out,
+		Project:  workFolder,
+		Function: "Search",
+		Parameters: params{
+			`Query`: `SETLEAF`,
+		},
+		Config: &config.Config{},
+	}
+	response := <-out
+	if response.Err == nil {
+		t.Fatalf(`expected an error but got none`)
+	}
+}
+
 func jsonResponse(response cop.FunctionResponse) string {
 	marshalled, err := json.Marshal(response)
 	if err != nil {
@@ -20,6 +20,7 @@ type Node struct {
 	Attributes map[string]string `xml:"-"`
 	InnerText  string            `xml:",innerxml"`
 	Nodes      []*Node           `xml:",any"`
+	attrOrder  []string
 }
 
 func NilNode() *Node {
@@ -32,8 +33,10 @@ func (node *Node) IsNil() bool {
 
 func (node *Node) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 	node.Attributes = make(map[string]string)
+	node.attrOrder = nil
 	for _, attr := range start.Attr {
 		node.Attributes[attr.Name.Local] = attr.Value
+		node.attrOrder = append(node.attrOrder, attr.Name.Local)
 	}
 	node.Name = start.Name.Local
 	type nodePointer Node
@@ -111,8 +114,8 @@ func (node *Node) RemoveAll(findName string) {
 }
 
 func (node *Node) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
-	var sortedKeys = sortKeys(node.Attributes)
-	for _, key := range sortedKeys {
+	var orderedKeys = orderKeys(node.Attributes, node.attrOrder)
+	for _, key := range orderedKeys {
 		var value = node.Attributes[key]
 		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: key}, Value: value})
 	}
@@ -127,6 +130,33 @@ func (node *Node) ToXml(indent string) (string, error) {
 	return string(value), err
 }
 
+// SortAttributes discards the parsed attribute order of the node and its children so they marshal with their
+// attributes in alphabetical order.
+func (node *Node) SortAttributes() {
+	node.attrOrder = nil
+	for _, child := range node.Nodes {
+		child.SortAttributes()
+	}
+}
+
+// orderKeys returns the attributes in the order they were parsed, followed by any new attributes in alphabetical order.
+func orderKeys(dict map[string]string, order []string) []string {
+	var keys []string
+	seen := map[string]bool{}
+	for _, key := range order {
+		if _, ok := dict[key]; ok && !seen[key] {
+			keys = append(keys, key)
+			seen[key] = true
+		}
+	}
+	for _, key := range sortKeys(dict) {
+		if !seen[key] {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
 func sortKeys(dict map[string]string) []string {
 	var keys []string
 	for key := range dict {
@@ -265,3 +265,19 @@ func TestInnerTextLeadingSpaceToXml(t *testing.T) {
 		t.Fatalf(`expected xml '%v' but got '%v'`, expectedXml, xml)
 	}
 }
+
+func TestAttributeOrderPreserved(t *testing.T) {
+	parsed, _ := txml.Parse(`<Element z="1" a="2"><Sub y="3" b="4" /></Element>`)
+	parsed.Attributes[`m`] = `5`
+	xml, _ := parsed.ToXml(``)
+	expected := `<Element z="1" a="2" m="5"><Sub y="3" b="4"></Sub></Element>`
+	if xml != expected {
+		t.Fatalf(`expected xml '%v' but got '%v'`, expected, xml)
+	}
+	parsed.SortAttributes()
+	xml, _ = parsed.ToXml(``)
+	expected = `<Element a="2" m="5" z="1"><Sub b="4" y="3"></Sub></Element>`
+	if xml != expected {
+		t.Fatalf(`expected xml '%v' but got '%v'`, expected, xml)
+	}
+}